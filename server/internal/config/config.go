@@ -32,7 +32,17 @@ type Config struct {
   JwtExpireHours int
   SyncTargetURL string
   SyncAPIKey    string
+  SyncKeyID     string
+  SyncKeys      string
+  SyncSignatureSkewSeconds int
+  SyncMaxBodyMB int
   SyncTimeoutSeconds int
+  SyncWorkers   int
+  SyncMaxAttempts int
+  SyncRetryBaseSeconds int
+  SyncDriftIntervalMinutes int
+  DraftTrashRetentionDays int
+  AuditAnchorIntervalMinutes int
 }
 
 func Load() (*Config, error) {
@@ -71,7 +81,17 @@ func Load() (*Config, error) {
     JwtExpireHours: envInt("JWT_EXPIRE_HOURS", 24),
     SyncTargetURL: strings.TrimSpace(os.Getenv("SYNC_TARGET_URL")),
     SyncAPIKey:    strings.TrimSpace(os.Getenv("SYNC_API_KEY")),
+    SyncKeyID:     strings.TrimSpace(os.Getenv("SYNC_KEY_ID")),
+    SyncKeys:      strings.TrimSpace(os.Getenv("SYNC_KEYS")),
+    SyncSignatureSkewSeconds: envInt("SYNC_SIGNATURE_SKEW_SECONDS", 300),
+    SyncMaxBodyMB: envInt("SYNC_MAX_BODY_MB", 64),
     SyncTimeoutSeconds: envInt("SYNC_TIMEOUT_SECONDS", 20),
+    SyncWorkers:   envInt("SYNC_WORKERS", 2),
+    SyncMaxAttempts: envInt("SYNC_MAX_ATTEMPTS", 5),
+    SyncRetryBaseSeconds: envInt("SYNC_RETRY_BASE_SECONDS", 10),
+    SyncDriftIntervalMinutes: envInt("SYNC_DRIFT_INTERVAL_MINUTES", 60),
+    DraftTrashRetentionDays: envInt("DRAFT_TRASH_RETENTION_DAYS", 30),
+    AuditAnchorIntervalMinutes: envInt("AUDIT_ANCHOR_INTERVAL_MINUTES", 60),
   }
 
   return cfg, nil
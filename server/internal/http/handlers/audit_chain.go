@@ -0,0 +1,429 @@
+package handlers
+
+import (
+  "context"
+  "crypto/sha256"
+  "database/sql"
+  "encoding/hex"
+  "encoding/json"
+  "log"
+  "net/http"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/config"
+)
+
+const (
+  auditChainHeadID    = 1
+  auditVerifyPageSize = 1000
+)
+
+type sqlRowExecutor interface {
+  sqlExecutor
+  sqlRowQueryer
+}
+
+// AuditChainEntry is an audit row as covered by the hash chain.
+type AuditChainEntry struct {
+  ID             int64
+  DraftVersionID *int64
+  EntityTable    *string
+  EntityID       *int64
+  Action         *string
+  ActorID        *int64
+  DetailJSON     *string
+  CreatedAt      *time.Time
+  PrevHash       string
+  Hash           string
+}
+
+// AuditChainBreak describes the first broken link of the audit chain.
+type AuditChainBreak struct {
+  ID       int64  `json:"id"`
+  Reason   string `json:"reason"`
+  Expected string `json:"expected,omitempty"`
+  Actual   string `json:"actual,omitempty"`
+}
+
+// AuditAnchorer periodically copies the audit chain head into app_db_audit_anchors.
+type AuditAnchorer struct {
+  db       *sql.DB
+  interval time.Duration
+}
+
+// ComputeAuditHash hashes an audit row together with the hash of the previous row.
+// Args:
+//   entry: Audit row; Hash is ignored.
+// Returns:
+//   string: Hex encoded SHA-256.
+func ComputeAuditHash(entry AuditChainEntry) string {
+  var createdAt interface{}
+  if entry.CreatedAt != nil {
+    createdAt = entry.CreatedAt.UTC().Format(time.RFC3339)
+  }
+  raw, _ := json.Marshal([]interface{}{
+    entry.PrevHash,
+    entry.ID,
+    entry.DraftVersionID,
+    entry.EntityTable,
+    entry.EntityID,
+    entry.Action,
+    entry.ActorID,
+    entry.DetailJSON,
+    createdAt,
+  })
+  sum := sha256.Sum256(raw)
+  return hex.EncodeToString(sum[:])
+}
+
+// VerifyAuditChain checks consecutive audit rows against each other.
+// Args:
+//   entries: Rows ordered by id.
+//   prevHash: Hash of the row before the first entry, empty at the start of the chain.
+// Returns:
+//   *AuditChainBreak: First broken link, nil when the rows are intact.
+func VerifyAuditChain(entries []AuditChainEntry, prevHash string) *AuditChainBreak {
+  for _, entry := range entries {
+    if entry.Hash == "" {
+      return &AuditChainBreak{ID: entry.ID, Reason: "missing_hash"}
+    }
+    if entry.PrevHash != prevHash {
+      return &AuditChainBreak{ID: entry.ID, Reason: "prev_hash_mismatch", Expected: prevHash, Actual: entry.PrevHash}
+    }
+    if expected := ComputeAuditHash(entry); expected != entry.Hash {
+      return &AuditChainBreak{ID: entry.ID, Reason: "hash_mismatch", Expected: expected, Actual: entry.Hash}
+    }
+    prevHash = entry.Hash
+  }
+  return nil
+}
+
+// insertAuditLog appends an audit row to the hash chain.
+// The chain head row stays locked until the caller's transaction ends, so audit writers serialize.
+// Args:
+//   db: Transaction writing the audited change.
+//   draftVersionID: Draft version id or nil.
+//   entityTable: Audited table or subject.
+//   entityID: Audited row id or nil.
+//   action: Audit action.
+//   actorID: Operator id or nil.
+//   detail: Detail JSON.
+//   now: Audit time.
+// Returns:
+//   error: Error when write fails.
+func insertAuditLog(db sqlRowExecutor, draftVersionID interface{}, entityTable string, entityID interface{}, action string, actorID interface{}, detail string, now time.Time) error {
+  var (
+    firstID  sql.NullInt64
+    lastHash sql.NullString
+  )
+  err := db.QueryRow("SELECT first_id, last_hash FROM app_db_audit_chain_head WHERE id = ? FOR UPDATE", auditChainHeadID).Scan(&firstID, &lastHash)
+  if err == sql.ErrNoRows {
+    if _, err := db.Exec("INSERT IGNORE INTO app_db_audit_chain_head (id) VALUES (?)", auditChainHeadID); err != nil {
+      return err
+    }
+    err = db.QueryRow("SELECT first_id, last_hash FROM app_db_audit_chain_head WHERE id = ? FOR UPDATE", auditChainHeadID).Scan(&firstID, &lastHash)
+  }
+  if err != nil {
+    return err
+  }
+
+  result, err := db.Exec(
+    "INSERT INTO app_db_audit_logs (draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at, prev_hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+    draftVersionID,
+    entityTable,
+    entityID,
+    action,
+    actorID,
+    detail,
+    now.Truncate(time.Second),
+    lastHash.String,
+  )
+  if err != nil {
+    return err
+  }
+  id, err := result.LastInsertId()
+  if err != nil {
+    return err
+  }
+
+  // Hash the stored values: MySQL normalizes JSON and datetime on write.
+  entry, err := scanAuditChainEntry(db.QueryRow(
+    "SELECT id, draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at, prev_hash, hash FROM app_db_audit_logs WHERE id = ?",
+    id,
+  ))
+  if err != nil {
+    return err
+  }
+  hash := ComputeAuditHash(entry)
+  if _, err := db.Exec("UPDATE app_db_audit_logs SET hash = ? WHERE id = ?", hash, id); err != nil {
+    return err
+  }
+  if !firstID.Valid {
+    firstID = sql.NullInt64{Int64: id, Valid: true}
+  }
+  _, err = db.Exec(
+    "UPDATE app_db_audit_chain_head SET first_id = ?, last_id = ?, last_hash = ?, updated_at = ? WHERE id = ?",
+    firstID.Int64,
+    id,
+    hash,
+    now,
+    auditChainHeadID,
+  )
+  return err
+}
+
+type auditRowScanner interface {
+  Scan(dest ...interface{}) error
+}
+
+func scanAuditChainEntry(row auditRowScanner) (AuditChainEntry, error) {
+  var (
+    entry          AuditChainEntry
+    draftVersionID sql.NullInt64
+    entityTable    sql.NullString
+    entityID       sql.NullInt64
+    action         sql.NullString
+    actorID        sql.NullInt64
+    detailJSON     sql.NullString
+    createdAt      sql.NullTime
+    prevHash       sql.NullString
+    hash           sql.NullString
+  )
+  if err := row.Scan(&entry.ID, &draftVersionID, &entityTable, &entityID, &action, &actorID, &detailJSON, &createdAt, &prevHash, &hash); err != nil {
+    return AuditChainEntry{}, err
+  }
+  entry.DraftVersionID = nullableInt64Pointer(draftVersionID)
+  entry.EntityTable = nullableString(entityTable)
+  entry.EntityID = nullableInt64Pointer(entityID)
+  entry.Action = nullableString(action)
+  entry.ActorID = nullableInt64Pointer(actorID)
+  entry.DetailJSON = nullableString(detailJSON)
+  entry.CreatedAt = nullableTimePointer(createdAt)
+  entry.PrevHash = prevHash.String
+  entry.Hash = hash.String
+  return entry, nil
+}
+
+// VerifyAuditLogs walks the audit hash chain and reports the first broken link.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *HistoryHandler) VerifyAuditLogs(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  var (
+    firstID  sql.NullInt64
+    lastID   sql.NullInt64
+    lastHash sql.NullString
+  )
+  if err := h.db.QueryRow("SELECT first_id, last_id, last_hash FROM app_db_audit_chain_head WHERE id = ?", auditChainHeadID).Scan(&firstID, &lastID, &lastHash); err != nil && err != sql.ErrNoRows {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  var unchained int64
+  unchainedQuery := "SELECT COUNT(*) FROM app_db_audit_logs"
+  unchainedArgs := []interface{}{}
+  if firstID.Valid {
+    unchainedQuery += " WHERE id < ?"
+    unchainedArgs = append(unchainedArgs, firstID.Int64)
+  }
+  if err := h.db.QueryRow(unchainedQuery, unchainedArgs...).Scan(&unchained); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  response := gin.H{
+    "first_id":  nullableInt64Pointer(firstID),
+    "head_id":   nullableInt64Pointer(lastID),
+    "head_hash": nullableString(lastHash),
+    "unchained": unchained,
+  }
+  if !firstID.Valid {
+    response["valid"] = true
+    response["checked"] = 0
+    response["broken"] = nil
+    c.JSON(http.StatusOK, response)
+    return
+  }
+
+  checked := 0
+  prevHash := ""
+  cursor := firstID.Int64 - 1
+  var (
+    broken *AuditChainBreak
+    tailID int64
+  )
+  for broken == nil {
+    rows, err := h.db.Query(
+      "SELECT id, draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at, prev_hash, hash FROM app_db_audit_logs WHERE id > ? AND id <= ? ORDER BY id ASC LIMIT ?",
+      cursor,
+      lastID.Int64,
+      auditVerifyPageSize,
+    )
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    page := make([]AuditChainEntry, 0, auditVerifyPageSize)
+    for rows.Next() {
+      entry, err := scanAuditChainEntry(rows)
+      if err != nil {
+        _ = rows.Close()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+        return
+      }
+      page = append(page, entry)
+    }
+    _ = rows.Close()
+    if err := rows.Err(); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    if len(page) == 0 {
+      break
+    }
+    if checked == 0 && page[0].ID != firstID.Int64 {
+      broken = &AuditChainBreak{ID: firstID.Int64, Reason: "missing_row"}
+      break
+    }
+    broken = VerifyAuditChain(page, prevHash)
+    for _, entry := range page {
+      if broken != nil && entry.ID == broken.ID {
+        break
+      }
+      checked++
+      prevHash = entry.Hash
+      tailID = entry.ID
+    }
+    cursor = page[len(page)-1].ID
+  }
+
+  // Rows removed from the end of the chain leave the head pointing past the tail.
+  if broken == nil && (tailID != lastID.Int64 || prevHash != lastHash.String) {
+    broken = &AuditChainBreak{ID: lastID.Int64, Reason: "head_mismatch", Expected: lastHash.String, Actual: prevHash}
+  }
+  if broken == nil {
+    anchorBreak, err := verifyAuditAnchors(h.db)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    broken = anchorBreak
+  }
+
+  response["valid"] = broken == nil
+  response["checked"] = checked
+  response["broken"] = broken
+  c.JSON(http.StatusOK, response)
+}
+
+// verifyAuditAnchors compares every anchor with the audit row it points at.
+func verifyAuditAnchors(db *sql.DB) (*AuditChainBreak, error) {
+  rows, err := db.Query(
+    "SELECT a.last_id, a.last_hash, l.hash FROM app_db_audit_anchors a LEFT JOIN app_db_audit_logs l ON l.id = a.last_id ORDER BY a.id ASC",
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  for rows.Next() {
+    var (
+      lastID     int64
+      anchorHash string
+      rowHash    sql.NullString
+    )
+    if err := rows.Scan(&lastID, &anchorHash, &rowHash); err != nil {
+      return nil, err
+    }
+    if rowHash.String != anchorHash {
+      return &AuditChainBreak{ID: lastID, Reason: "anchor_mismatch", Expected: anchorHash, Actual: rowHash.String}, nil
+    }
+  }
+  return nil, rows.Err()
+}
+
+// NewAuditAnchorer creates the audit chain anchoring job.
+// Args:
+//   cfg: App config instance.
+//   db: Database connection.
+// Returns:
+//   *AuditAnchorer: Initialized anchorer.
+func NewAuditAnchorer(cfg *config.Config, db *sql.DB) *AuditAnchorer {
+  return &AuditAnchorer{db: db, interval: time.Duration(cfg.AuditAnchorIntervalMinutes) * time.Minute}
+}
+
+// Start launches the anchoring goroutine; a non-positive interval disables it.
+// Args:
+//   ctx: Lifecycle context; the goroutine stops when it is cancelled.
+// Returns:
+//   None.
+func (a *AuditAnchorer) Start(ctx context.Context) {
+  if a == nil || a.db == nil || a.interval <= 0 {
+    return
+  }
+  go func() {
+    ticker := time.NewTicker(a.interval)
+    defer ticker.Stop()
+    for {
+      select {
+      case <-ctx.Done():
+        return
+      case <-ticker.C:
+      }
+      if err := a.Anchor(time.Now()); err != nil {
+        log.Printf("audit anchor failed: %v", err)
+      }
+    }
+  }()
+}
+
+// Anchor records the current chain head unless it is already anchored.
+// Args:
+//   now: Anchor time.
+// Returns:
+//   error: Error when query or insert fails.
+func (a *AuditAnchorer) Anchor(now time.Time) error {
+  var (
+    firstID  sql.NullInt64
+    lastID   sql.NullInt64
+    lastHash sql.NullString
+  )
+  if err := a.db.QueryRow("SELECT first_id, last_id, last_hash FROM app_db_audit_chain_head WHERE id = ?", auditChainHeadID).Scan(&firstID, &lastID, &lastHash); err != nil {
+    if err == sql.ErrNoRows {
+      return nil
+    }
+    return err
+  }
+  if !lastID.Valid {
+    return nil
+  }
+
+  var anchoredID sql.NullInt64
+  if err := a.db.QueryRow("SELECT MAX(last_id) FROM app_db_audit_anchors").Scan(&anchoredID); err != nil {
+    return err
+  }
+  if anchoredID.Valid && anchoredID.Int64 >= lastID.Int64 {
+    return nil
+  }
+
+  var count int64
+  if err := a.db.QueryRow("SELECT COUNT(*) FROM app_db_audit_logs WHERE id BETWEEN ? AND ?", firstID.Int64, lastID.Int64).Scan(&count); err != nil {
+    return err
+  }
+  _, err := a.db.Exec(
+    "INSERT INTO app_db_audit_anchors (last_id, last_hash, row_count, created_at) VALUES (?, ?, ?, ?)",
+    lastID.Int64,
+    lastHash.String,
+    count,
+    now,
+  )
+  return err
+}
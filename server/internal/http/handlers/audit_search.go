@@ -0,0 +1,470 @@
+package handlers
+
+import (
+  "database/sql"
+  "encoding/base64"
+  "encoding/csv"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "log"
+  "net/http"
+  "net/url"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+)
+
+const auditExportFlushRows = 500
+
+// auditLogSelect reads audit rows with the operator resolved from app_db_users.
+const auditLogSelect = `SELECT l.id, l.draft_version_id, l.entity_table, l.entity_id, l.action, l.actor_id, l.detail_json, l.created_at,
+    l.prev_hash, l.hash, u.display_name, u.username
+    FROM app_db_audit_logs l
+    LEFT JOIN app_db_users u ON u.id = l.actor_id
+    WHERE 1=1`
+
+// auditExportColumns is the CSV header of audit exports.
+var auditExportColumns = []string{
+  "id",
+  "created_at",
+  "actor_id",
+  "actor_username",
+  "actor_name",
+  "action",
+  "entity_table",
+  "entity_id",
+  "draft_version_id",
+  "detail",
+  "prev_hash",
+  "hash",
+}
+
+// AuditLogFilter holds the search conditions of audit log queries.
+type AuditLogFilter struct {
+  DraftVersionID int64
+  EntityTable    string
+  EntityID       int64
+  Actions        []string
+  ActorID        int64
+  Actor          string
+  From           *time.Time
+  To             *time.Time
+  Text           string
+  Sort           string
+  Desc           bool
+  Cursor         *AuditLogCursor
+}
+
+// AuditLogCursor is the position of the last row of a page.
+type AuditLogCursor struct {
+  ID        int64      `json:"id"`
+  CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// auditLogRecord is one scanned audit row.
+type auditLogRecord struct {
+  id           int64
+  draftVersion sql.NullInt64
+  entityTable  sql.NullString
+  entityID     sql.NullInt64
+  action       sql.NullString
+  actorID      sql.NullInt64
+  detailJSON   sql.NullString
+  createdAt    sql.NullTime
+  prevHash     sql.NullString
+  hash         sql.NullString
+  displayName  sql.NullString
+  username     sql.NullString
+}
+
+// ParseAuditLogFilter reads audit search conditions from query parameters.
+// Args:
+//   values: Query parameters (action accepts a comma separated list; from/to accept RFC3339,
+//     "YYYY-MM-DD HH:MM[:SS]" or a bare date, a bare "to" date covering the whole day).
+//   loc: App timezone for times without offset.
+// Returns:
+//   AuditLogFilter: Parsed filter.
+//   error: Error naming the invalid parameter.
+func ParseAuditLogFilter(values url.Values, loc *time.Location) (AuditLogFilter, error) {
+  filter := AuditLogFilter{
+    DraftVersionID: parseInt64Value(values.Get("draft_version_id")),
+    EntityTable:    strings.TrimSpace(values.Get("entity_table")),
+    EntityID:       parseInt64Value(values.Get("entity_id")),
+    ActorID:        parseInt64Value(values.Get("actor_id")),
+    Actor:          strings.TrimSpace(values.Get("actor")),
+    Text:           strings.TrimSpace(values.Get("q")),
+    Sort:           strings.TrimSpace(values.Get("sort")),
+    Desc:           true,
+  }
+  for _, action := range strings.Split(values.Get("action"), ",") {
+    if trimmed := strings.TrimSpace(action); trimmed != "" {
+      filter.Actions = append(filter.Actions, trimmed)
+    }
+  }
+
+  if filter.Sort == "" {
+    filter.Sort = "id"
+  }
+  if filter.Sort != "id" && filter.Sort != "created_at" {
+    return filter, errors.New("sort must be id or created_at")
+  }
+  switch strings.ToLower(strings.TrimSpace(values.Get("order"))) {
+  case "", "desc":
+  case "asc":
+    filter.Desc = false
+  default:
+    return filter, errors.New("order must be asc or desc")
+  }
+
+  var err error
+  if filter.From, err = parseAuditLogTime(values.Get("from"), loc, false); err != nil {
+    return filter, errors.New("invalid from")
+  }
+  if filter.To, err = parseAuditLogTime(values.Get("to"), loc, true); err != nil {
+    return filter, errors.New("invalid to")
+  }
+  if filter.From != nil && filter.To != nil && filter.From.After(*filter.To) {
+    return filter, errors.New("from must not be after to")
+  }
+
+  if raw := strings.TrimSpace(values.Get("cursor")); raw != "" {
+    cursor, err := DecodeAuditLogCursor(raw)
+    if err != nil || (filter.Sort == "created_at" && cursor.CreatedAt == nil) {
+      return filter, errors.New("invalid cursor")
+    }
+    filter.Cursor = cursor
+  }
+  return filter, nil
+}
+
+// BuildAuditLogQuery builds the conditions and ordering of an audit search.
+// Args:
+//   filter: Search conditions; the cursor continues after its row in the filter's order.
+// Returns:
+//   string: Conditions to append after "WHERE 1=1", each starting with " AND".
+//   string: ORDER BY clause.
+//   []interface{}: Query args of the conditions.
+func BuildAuditLogQuery(filter AuditLogFilter) (string, string, []interface{}) {
+  var where strings.Builder
+  args := make([]interface{}, 0)
+
+  if filter.DraftVersionID > 0 {
+    where.WriteString(" AND l.draft_version_id = ?")
+    args = append(args, filter.DraftVersionID)
+  }
+  if filter.EntityTable != "" {
+    where.WriteString(" AND l.entity_table = ?")
+    args = append(args, filter.EntityTable)
+  }
+  if filter.EntityID > 0 {
+    where.WriteString(" AND l.entity_id = ?")
+    args = append(args, filter.EntityID)
+  }
+  if len(filter.Actions) > 0 {
+    where.WriteString(" AND l.action IN (?" + strings.Repeat(", ?", len(filter.Actions)-1) + ")")
+    for _, action := range filter.Actions {
+      args = append(args, action)
+    }
+  }
+  if filter.ActorID > 0 {
+    where.WriteString(" AND l.actor_id = ?")
+    args = append(args, filter.ActorID)
+  }
+  if filter.Actor != "" {
+    pattern := likeContainsPattern(filter.Actor)
+    where.WriteString(" AND (u.username LIKE ? OR u.display_name LIKE ?)")
+    args = append(args, pattern, pattern)
+  }
+  if filter.From != nil {
+    where.WriteString(" AND l.created_at >= ?")
+    args = append(args, *filter.From)
+  }
+  if filter.To != nil {
+    where.WriteString(" AND l.created_at <= ?")
+    args = append(args, *filter.To)
+  }
+  if filter.Text != "" {
+    where.WriteString(" AND CAST(l.detail_json AS CHAR) LIKE ?")
+    args = append(args, likeContainsPattern(filter.Text))
+  }
+
+  direction, compare := "ASC", ">"
+  if filter.Desc {
+    direction, compare = "DESC", "<"
+  }
+  orderBy := " ORDER BY l.id " + direction
+  if filter.Sort == "created_at" {
+    orderBy = " ORDER BY l.created_at " + direction + ", l.id " + direction
+  }
+
+  if filter.Cursor != nil {
+    if filter.Sort == "created_at" && filter.Cursor.CreatedAt != nil {
+      where.WriteString(" AND (l.created_at " + compare + " ? OR (l.created_at = ? AND l.id " + compare + " ?))")
+      args = append(args, *filter.Cursor.CreatedAt, *filter.Cursor.CreatedAt, filter.Cursor.ID)
+    } else {
+      where.WriteString(" AND l.id " + compare + " ?")
+      args = append(args, filter.Cursor.ID)
+    }
+  }
+  return where.String(), orderBy, args
+}
+
+// EncodeAuditLogCursor turns a cursor into an opaque URL-safe token.
+// Args:
+//   cursor: Position of the last row of a page.
+// Returns:
+//   string: Cursor token.
+func EncodeAuditLogCursor(cursor AuditLogCursor) string {
+  raw, _ := json.Marshal(cursor)
+  return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeAuditLogCursor parses a token produced by EncodeAuditLogCursor.
+// Args:
+//   raw: Cursor token.
+// Returns:
+//   *AuditLogCursor: Decoded cursor.
+//   error: Error when the token is malformed.
+func DecodeAuditLogCursor(raw string) (*AuditLogCursor, error) {
+  decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(raw))
+  if err != nil {
+    return nil, err
+  }
+  var cursor AuditLogCursor
+  if err := json.Unmarshal(decoded, &cursor); err != nil {
+    return nil, err
+  }
+  if cursor.ID <= 0 {
+    return nil, errors.New("invalid cursor id")
+  }
+  return &cursor, nil
+}
+
+// ExportAuditLogs streams audit rows matching the search as CSV or JSONL.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *HistoryHandler) ExportAuditLogs(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "csv")))
+  if format != "csv" && format != "jsonl" {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "format must be csv or jsonl"})
+    return
+  }
+  loc := appLocation(h.cfg)
+  filter, err := ParseAuditLogFilter(c.Request.URL.Query(), loc)
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    return
+  }
+
+  if err := h.recordAuditExport(c, format); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  where, orderBy, args := BuildAuditLogQuery(filter)
+  rows, err := h.db.QueryContext(c.Request.Context(), auditLogSelect+where+orderBy, args...)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  defer rows.Close()
+
+  contentType := "text/csv; charset=utf-8"
+  if format == "jsonl" {
+    contentType = "application/x-ndjson; charset=utf-8"
+  }
+  name := fmt.Sprintf("audit_logs_%s.%s", time.Now().In(loc).Format("20060102150405"), format)
+  c.Header("Content-Type", contentType)
+  c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+  c.Status(http.StatusOK)
+
+  var (
+    csvWriter   *csv.Writer
+    jsonEncoder *json.Encoder
+  )
+  if format == "csv" {
+    if _, err := c.Writer.Write([]byte("\xef\xbb\xbf")); err != nil {
+      return
+    }
+    csvWriter = csv.NewWriter(c.Writer)
+    if err := csvWriter.Write(auditExportColumns); err != nil {
+      return
+    }
+  } else {
+    jsonEncoder = json.NewEncoder(c.Writer)
+    jsonEncoder.SetEscapeHTML(false)
+  }
+
+  count := 0
+  for rows.Next() {
+    record, err := scanAuditLogRecord(rows)
+    if err != nil {
+      log.Printf("audit export scan failed: %v", err)
+      return
+    }
+    if csvWriter != nil {
+      err = csvWriter.Write(record.csvRow(loc))
+    } else {
+      err = jsonEncoder.Encode(record.item())
+    }
+    if err != nil {
+      log.Printf("audit export write failed: %v", err)
+      return
+    }
+    count++
+    if count%auditExportFlushRows == 0 {
+      if csvWriter != nil {
+        csvWriter.Flush()
+      }
+      c.Writer.Flush()
+    }
+  }
+  if err := rows.Err(); err != nil {
+    log.Printf("audit export query failed after %d rows: %v", count, err)
+  }
+  if csvWriter != nil {
+    csvWriter.Flush()
+  }
+  c.Writer.Flush()
+}
+
+// recordAuditExport writes an audit row for the export itself, keeping the query string as detail.
+func (h *HistoryHandler) recordAuditExport(c *gin.Context, format string) error {
+  detail, err := json.Marshal(map[string]interface{}{
+    "format": format,
+    "query":  c.Request.URL.RawQuery,
+  })
+  if err != nil {
+    return err
+  }
+  tx, err := h.db.Begin()
+  if err != nil {
+    return err
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+  if err := insertAuditLog(
+    tx,
+    nil,
+    "app_db_audit_logs",
+    nil,
+    "export",
+    nullableID(draftActorID(c)),
+    string(detail),
+    time.Now(),
+  ); err != nil {
+    return err
+  }
+  return tx.Commit()
+}
+
+func scanAuditLogRecord(rows *sql.Rows) (auditLogRecord, error) {
+  var record auditLogRecord
+  err := rows.Scan(
+    &record.id,
+    &record.draftVersion,
+    &record.entityTable,
+    &record.entityID,
+    &record.action,
+    &record.actorID,
+    &record.detailJSON,
+    &record.createdAt,
+    &record.prevHash,
+    &record.hash,
+    &record.displayName,
+    &record.username,
+  )
+  return record, err
+}
+
+func (r auditLogRecord) item() gin.H {
+  return gin.H{
+    "id":               r.id,
+    "draft_version_id": nullableInt64Pointer(r.draftVersion),
+    "entity_table":     nullableStringValue(r.entityTable),
+    "entity_id":        nullableInt64Pointer(r.entityID),
+    "action":           nullableStringValue(r.action),
+    "actor_id":         nullableInt64Pointer(r.actorID),
+    "actor_name":       nullableStringValue(r.displayName),
+    "actor_username":   nullableStringValue(r.username),
+    "detail":           decodeJSON(r.detailJSON),
+    "created_at":       nullableTimePointer(r.createdAt),
+    "prev_hash":        nullableString(r.prevHash),
+    "hash":             nullableString(r.hash),
+  }
+}
+
+func (r auditLogRecord) csvRow(loc *time.Location) []string {
+  createdAt := ""
+  if r.createdAt.Valid {
+    createdAt = r.createdAt.Time.In(loc).Format(time.RFC3339)
+  }
+  return []string{
+    fmt.Sprint(r.id),
+    createdAt,
+    nullableInt64String(r.actorID),
+    nullableStringValue(r.username),
+    nullableStringValue(r.displayName),
+    nullableStringValue(r.action),
+    nullableStringValue(r.entityTable),
+    nullableInt64String(r.entityID),
+    nullableInt64String(r.draftVersion),
+    nullableStringValue(r.detailJSON),
+    nullableStringValue(r.prevHash),
+    nullableStringValue(r.hash),
+  }
+}
+
+func (r auditLogRecord) cursor() AuditLogCursor {
+  cursor := AuditLogCursor{ID: r.id}
+  if r.createdAt.Valid {
+    createdAt := r.createdAt.Time
+    cursor.CreatedAt = &createdAt
+  }
+  return cursor
+}
+
+func nullableInt64String(value sql.NullInt64) string {
+  if !value.Valid {
+    return ""
+  }
+  return fmt.Sprint(value.Int64)
+}
+
+// parseAuditLogTime parses a from/to bound; a bare "to" date is extended to the end of that day.
+func parseAuditLogTime(raw string, loc *time.Location, endOfDay bool) (*time.Time, error) {
+  trimmed := strings.TrimSpace(raw)
+  if trimmed == "" {
+    return nil, nil
+  }
+  if loc == nil {
+    loc = time.Local
+  }
+  if day, err := time.ParseInLocation("2006-01-02", trimmed, loc); err == nil {
+    if endOfDay {
+      day = day.AddDate(0, 0, 1).Add(-time.Second)
+    }
+    return &day, nil
+  }
+  parsed, err := ParseSyncScheduleTime(trimmed, loc)
+  if err != nil {
+    return nil, err
+  }
+  return &parsed, nil
+}
+
+// likeContainsPattern escapes LIKE wildcards and wraps the value for a substring match.
+func likeContainsPattern(value string) string {
+  replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+  return "%" + replacer.Replace(value) + "%"
+}
@@ -30,6 +30,10 @@ type dashboardMediaSummary struct {
 type dashboardSyncSummary struct {
   PendingVersions int64      `json:"pending_versions"`
   LastSyncAt      *time.Time `json:"last_sync_at"`
+  Drifted         bool       `json:"drifted"`
+  DriftedModules  []string   `json:"drifted_modules"`
+  DriftCheckedAt  *time.Time `json:"drift_checked_at"`
+  DriftedVersions int64      `json:"drifted_versions"`
 }
 
 // NewDashboardHandler creates a handler for dashboard summary.
@@ -193,5 +197,46 @@ func (h *DashboardHandler) loadSyncSummary(draftID int64) (dashboardSyncSummary,
     summary.LastSyncAt = nullableTimePointer(syncedAt)
   }
 
+  if err := h.loadDriftSummary(draftID, &summary); err != nil {
+    return dashboardSyncSummary{}, err
+  }
+
   return summary, nil
 }
+
+func (h *DashboardHandler) loadDriftSummary(draftID int64, summary *dashboardSyncSummary) error {
+  rows, err := h.db.Query(
+    "SELECT DISTINCT module_key FROM app_db_sync_drift_reports WHERE draft_version_id = ? AND drifted = 1 ORDER BY module_key",
+    draftID,
+  )
+  if err != nil {
+    return err
+  }
+  defer rows.Close()
+
+  summary.DriftedModules = make([]string, 0)
+  for rows.Next() {
+    var moduleKey string
+    if err := rows.Scan(&moduleKey); err != nil {
+      return err
+    }
+    summary.DriftedModules = append(summary.DriftedModules, moduleKey)
+  }
+  if err := rows.Err(); err != nil {
+    return err
+  }
+  summary.Drifted = len(summary.DriftedModules) > 0
+
+  var checkedAt sql.NullTime
+  if err := h.db.QueryRow(
+    "SELECT MAX(checked_at) FROM app_db_sync_drift_reports WHERE draft_version_id = ?",
+    draftID,
+  ).Scan(&checkedAt); err != nil {
+    return err
+  }
+  summary.DriftCheckedAt = nullableTimePointer(checkedAt)
+
+  return h.db.QueryRow(
+    "SELECT COUNT(DISTINCT draft_version_id) FROM app_db_sync_drift_reports WHERE drifted = 1",
+  ).Scan(&summary.DriftedVersions)
+}
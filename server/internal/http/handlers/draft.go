@@ -55,7 +55,7 @@ func (h *DraftHandler) ListBanners(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, title, image, sort, is_active, type, app_version_name FROM app_db_banners WHERE "+where+" ORDER BY sort ASC, id ASC",
+    "SELECT id, title, image, sort, is_active, type, app_version_name, row_version FROM app_db_banners WHERE "+where+" AND deleted_at IS NULL ORDER BY sort ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -81,9 +81,10 @@ func (h *DraftHandler) ListBanners(c *gin.Context) {
       isActive        sql.NullInt64
       bannerType      sql.NullInt64
       appVersionField sql.NullString
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &title, &image, &sort, &isActive, &bannerType, &appVersionField); err != nil {
+    if err := rows.Scan(&id, &title, &image, &sort, &isActive, &bannerType, &appVersionField, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -102,10 +103,11 @@ func (h *DraftHandler) ListBanners(c *gin.Context) {
       "app_version_name": nullableString(appVersionField),
       "submit_status":    nullableStringValue(summary.status),
       "last_submit_at":   nullableTimePointer(summary.createdAt),
+      "row_version":      rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, appVersionName)})
 }
 
 // ListIdentities returns draft identities with signed URLs.
@@ -127,7 +129,7 @@ func (h *DraftHandler) ListIdentities(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, name, image, sort, status, app_version_name FROM app_db_identities WHERE "+where+" ORDER BY sort ASC, id ASC",
+    "SELECT id, name, image, sort, status, app_version_name, row_version FROM app_db_identities WHERE "+where+" AND deleted_at IS NULL ORDER BY sort ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -152,9 +154,10 @@ func (h *DraftHandler) ListIdentities(c *gin.Context) {
       sort            sql.NullInt64
       status          sql.NullInt64
       appVersionField sql.NullString
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &name, &image, &sort, &status, &appVersionField); err != nil {
+    if err := rows.Scan(&id, &name, &image, &sort, &status, &appVersionField, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -172,10 +175,11 @@ func (h *DraftHandler) ListIdentities(c *gin.Context) {
       "app_version_name": nullableString(appVersionField),
       "submit_status":    nullableStringValue(summary.status),
       "last_submit_at":   nullableTimePointer(summary.createdAt),
+      "row_version":      rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, appVersionName)})
 }
 
 // ListScenes returns draft scenes with signed URLs.
@@ -197,7 +201,7 @@ func (h *DraftHandler) ListScenes(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, name, image, `desc`, music, sort, status, app_version_name FROM app_db_scenes WHERE "+where+" ORDER BY sort ASC, id ASC",
+    "SELECT id, name, image, `desc`, music, sort, status, app_version_name, row_version FROM app_db_scenes WHERE "+where+" AND deleted_at IS NULL ORDER BY sort ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -224,9 +228,10 @@ func (h *DraftHandler) ListScenes(c *gin.Context) {
       sort            sql.NullInt64
       status          sql.NullInt64
       appVersionField sql.NullString
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &name, &image, &desc, &music, &sort, &status, &appVersionField); err != nil {
+    if err := rows.Scan(&id, &name, &image, &desc, &music, &sort, &status, &appVersionField, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -248,10 +253,11 @@ func (h *DraftHandler) ListScenes(c *gin.Context) {
       "app_version_name": nullableString(appVersionField),
       "submit_status":    nullableStringValue(summary.status),
       "last_submit_at":   nullableTimePointer(summary.createdAt),
+      "row_version":      rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, appVersionName)})
 }
 
 // ListClothesCategories returns draft clothes categories with signed URLs.
@@ -273,7 +279,7 @@ func (h *DraftHandler) ListClothesCategories(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, name, image, sort, status, music, `desc`, music_text, app_version_name FROM app_db_clothes_categories WHERE "+where+" ORDER BY sort ASC, id ASC",
+    "SELECT id, name, image, sort, status, music, `desc`, music_text, app_version_name, row_version FROM app_db_clothes_categories WHERE "+where+" AND deleted_at IS NULL ORDER BY sort ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -301,9 +307,10 @@ func (h *DraftHandler) ListClothesCategories(c *gin.Context) {
       desc            sql.NullString
       musicText       sql.NullString
       appVersionField sql.NullString
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &name, &image, &sort, &status, &music, &desc, &musicText, &appVersionField); err != nil {
+    if err := rows.Scan(&id, &name, &image, &sort, &status, &music, &desc, &musicText, &appVersionField, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -326,10 +333,11 @@ func (h *DraftHandler) ListClothesCategories(c *gin.Context) {
       "app_version_name": nullableString(appVersionField),
       "submit_status":    nullableStringValue(summary.status),
       "last_submit_at":   nullableTimePointer(summary.createdAt),
+      "row_version":      rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, appVersionName)})
 }
 
 // ListPhotoHobbies returns draft photo hobbies with signed URLs.
@@ -351,7 +359,7 @@ func (h *DraftHandler) ListPhotoHobbies(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, name, image, sort, status, music, music_text, `desc`, app_version_name FROM app_db_photo_hobbies WHERE "+where+" ORDER BY sort ASC, id ASC",
+    "SELECT id, name, image, sort, status, music, music_text, `desc`, app_version_name, row_version FROM app_db_photo_hobbies WHERE "+where+" AND deleted_at IS NULL ORDER BY sort ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -379,9 +387,10 @@ func (h *DraftHandler) ListPhotoHobbies(c *gin.Context) {
       musicText       sql.NullString
       desc            sql.NullString
       appVersionField sql.NullString
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &name, &image, &sort, &status, &music, &musicText, &desc, &appVersionField); err != nil {
+    if err := rows.Scan(&id, &name, &image, &sort, &status, &music, &musicText, &desc, &appVersionField, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -404,10 +413,11 @@ func (h *DraftHandler) ListPhotoHobbies(c *gin.Context) {
       "app_version_name": nullableString(appVersionField),
       "submit_status":    nullableStringValue(summary.status),
       "last_submit_at":   nullableTimePointer(summary.createdAt),
+      "row_version":      rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, appVersionName)})
 }
 
 // GetAppUIFields returns draft UI fields with signed URLs.
@@ -430,7 +440,7 @@ func (h *DraftHandler) GetAppUIFields(c *gin.Context) {
   }
 
   row := h.db.QueryRow(
-    "SELECT id, app_version_name_id, home_title_left, home_title_right, home_subtitle, start_experience, step1_music, step1_music_text, step1_title, step2_music, step2_music_text, step2_title, status, print_wait FROM app_db_app_ui_fields WHERE "+where+" LIMIT 1",
+    "SELECT id, app_version_name_id, home_title_left, home_title_right, home_subtitle, start_experience, step1_music, step1_music_text, step1_title, step2_music, step2_music_text, step2_title, status, print_wait, row_version FROM app_db_app_ui_fields WHERE "+where+" LIMIT 1",
     args...,
   )
 
@@ -449,6 +459,7 @@ func (h *DraftHandler) GetAppUIFields(c *gin.Context) {
     step2Title       sql.NullString
     status           sql.NullInt64
     printWait        sql.NullString
+    rowVersion       int64
   )
 
   if err := row.Scan(
@@ -466,9 +477,10 @@ func (h *DraftHandler) GetAppUIFields(c *gin.Context) {
     &step2Title,
     &status,
     &printWait,
+    &rowVersion,
   ); err != nil {
     if err == sql.ErrNoRows {
-      c.JSON(http.StatusOK, gin.H{"data": nil})
+      c.JSON(http.StatusOK, gin.H{"data": nil, "draft_status": loadDraftStatusByFilter(h.db, draftID, "")})
       return
     }
     c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
@@ -503,9 +515,11 @@ func (h *DraftHandler) GetAppUIFields(c *gin.Context) {
     "print_wait_url":      signPath(h.cfg, ossService, nullableString(printWait), ""),
     "submit_status":       nullableStringValue(summary.status),
     "last_submit_at":      nullableTimePointer(summary.createdAt),
+    "row_version":         rowVersion,
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": data})
+  setRevisionHeader(c, rowVersion)
+  c.JSON(http.StatusOK, gin.H{"data": data, "draft_status": loadDraftStatusByFilter(h.db, draftID, "")})
 }
 
 // ListConfigExtraSteps returns draft extra steps with signed URLs.
@@ -528,7 +542,7 @@ func (h *DraftHandler) ListConfigExtraSteps(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, app_version_name_id, step_index, field_name, label, music, music_text, status FROM app_db_config_extra_steps WHERE "+where+" ORDER BY step_index ASC, id ASC",
+    "SELECT id, app_version_name_id, step_index, field_name, label, music, music_text, status, row_version FROM app_db_config_extra_steps WHERE "+where+" AND deleted_at IS NULL ORDER BY step_index ASC, id ASC",
     args...,
   )
   if err != nil {
@@ -555,9 +569,10 @@ func (h *DraftHandler) ListConfigExtraSteps(c *gin.Context) {
       music           sql.NullString
       musicText       sql.NullString
       status          sql.NullInt64
+      rowVersion      int64
     )
 
-    if err := rows.Scan(&id, &appVersionNameIDValue, &stepIndex, &fieldName, &label, &music, &musicText, &status); err != nil {
+    if err := rows.Scan(&id, &appVersionNameIDValue, &stepIndex, &fieldName, &label, &music, &musicText, &status, &rowVersion); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
@@ -575,10 +590,11 @@ func (h *DraftHandler) ListConfigExtraSteps(c *gin.Context) {
       "status":              nullableInt(status),
       "submit_status":       nullableStringValue(summary.status),
       "last_submit_at":      nullableTimePointer(summary.createdAt),
+      "row_version":         rowVersion,
     })
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "draft_status": loadDraftStatusByFilter(h.db, draftID, "")})
 }
 
 func (h *DraftHandler) loadLatestSubmissions(draftVersionID int64, moduleKey, entityTable string) (map[int64]submissionSummary, error) {
@@ -0,0 +1,621 @@
+package handlers
+
+import (
+  "database/sql"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const (
+  approvalDecisionApproved = "approved"
+  approvalDecisionRejected = "rejected"
+)
+
+type ApprovalHandler struct {
+  db *sql.DB
+}
+
+// ApprovalRequirement is one policy line: a number of approvals from a role for a scope.
+type ApprovalRequirement struct {
+  // ModuleKey is empty for the whole version.
+  ModuleKey string `json:"module_key"`
+  // Role is empty when any reviewer counts.
+  Role          string `json:"role"`
+  RequiredCount int    `json:"required_count"`
+}
+
+// ApprovalRecord is one reviewer decision within a review round.
+type ApprovalRecord struct {
+  ID           int64     `json:"id"`
+  ModuleKey    string    `json:"module_key"`
+  ApproverID   int64     `json:"approver_id"`
+  ApproverName string    `json:"approver_name"`
+  ApproverRole string    `json:"approver_role"`
+  Decision     string    `json:"decision"`
+  Reason       string    `json:"reason"`
+  UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ApprovalGap reports a requirement that is not met yet.
+type ApprovalGap struct {
+  ModuleKey string `json:"module_key"`
+  Role      string `json:"role"`
+  Required  int    `json:"required"`
+  Approved  int    `json:"approved"`
+  Missing   int    `json:"missing"`
+}
+
+// ApprovalEvaluation is the outcome of checking approvals against a policy.
+type ApprovalEvaluation struct {
+  Satisfied bool             `json:"satisfied"`
+  Missing   []ApprovalGap    `json:"missing"`
+  Rejected  []ApprovalRecord `json:"rejected"`
+}
+
+type approvalDecisionRequest struct {
+  Decision  string `json:"decision"`
+  ModuleKey string `json:"module_key"`
+  Reason    string `json:"reason"`
+}
+
+type approvalPolicyRequest struct {
+  DraftVersionID int64                 `json:"draft_version_id"`
+  Requirements   []ApprovalRequirement `json:"requirements"`
+}
+
+// approvalPendingError reports a sync refused because the approval policy is not met.
+type approvalPendingError struct {
+  Evaluation ApprovalEvaluation
+}
+
+func (e *approvalPendingError) Error() string {
+  missing := 0
+  for _, gap := range e.Evaluation.Missing {
+    missing += gap.Missing
+  }
+  return fmt.Sprintf("approval_required: %d approvals missing, %d rejections", missing, len(e.Evaluation.Rejected))
+}
+
+type sqlReader interface {
+  sqlQueryer
+  sqlRowQueryer
+}
+
+// NewApprovalHandler creates a handler for review approvals.
+// Args:
+//   db: Database connection.
+// Returns:
+//   *ApprovalHandler: Initialized handler.
+func NewApprovalHandler(db *sql.DB) *ApprovalHandler {
+  return &ApprovalHandler{db: db}
+}
+
+// EvaluateApprovals checks reviewer decisions against policy requirements.
+// Args:
+//   requirements: Policy requirements.
+//   records: Decisions of the current review round.
+// Returns:
+//   ApprovalEvaluation: Satisfied flag, unmet requirements and rejections.
+func EvaluateApprovals(requirements []ApprovalRequirement, records []ApprovalRecord) ApprovalEvaluation {
+  evaluation := ApprovalEvaluation{Missing: make([]ApprovalGap, 0), Rejected: make([]ApprovalRecord, 0)}
+  for _, record := range records {
+    if record.Decision == approvalDecisionRejected {
+      evaluation.Rejected = append(evaluation.Rejected, record)
+    }
+  }
+
+  for _, requirement := range requirements {
+    approvers := make(map[int64]struct{})
+    for _, record := range records {
+      if record.Decision != approvalDecisionApproved || record.ModuleKey != requirement.ModuleKey {
+        continue
+      }
+      if requirement.Role != "" && record.ApproverRole != requirement.Role {
+        continue
+      }
+      approvers[record.ApproverID] = struct{}{}
+    }
+    if len(approvers) >= requirement.RequiredCount {
+      continue
+    }
+    evaluation.Missing = append(evaluation.Missing, ApprovalGap{
+      ModuleKey: requirement.ModuleKey,
+      Role:      requirement.Role,
+      Required:  requirement.RequiredCount,
+      Approved:  len(approvers),
+      Missing:   requirement.RequiredCount - len(approvers),
+    })
+  }
+
+  evaluation.Satisfied = len(evaluation.Missing) == 0 && len(evaluation.Rejected) == 0
+  return evaluation
+}
+
+// List returns the effective policy, current round decisions and their evaluation.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *ApprovalHandler) List(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  round, err := loadReviewRound(h.db, id)
+  if err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  requirements, err := loadApprovalRequirements(h.db, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  records, err := loadApprovalRecords(h.db, id, round)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "draft_version_id": id,
+    "review_round":     round,
+    "requirements":     requirements,
+    "approvals":        records,
+    "evaluation":       EvaluateApprovals(requirements, records),
+  })
+}
+
+// Decide records an approve or reject decision for the current review round.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *ApprovalHandler) Decide(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  claims, ok := middleware.GetAuthClaims(c)
+  if !ok {
+    c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+    return
+  }
+
+  var req approvalDecisionRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+  decision := ""
+  switch strings.ToLower(strings.TrimSpace(req.Decision)) {
+  case "approve", approvalDecisionApproved:
+    decision = approvalDecisionApproved
+  case "reject", approvalDecisionRejected:
+    decision = approvalDecisionRejected
+  default:
+    c.JSON(http.StatusBadRequest, gin.H{"error": "decision must be approve or reject"})
+    return
+  }
+  reason := strings.TrimSpace(req.Reason)
+  if decision == approvalDecisionRejected && reason == "" {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+    return
+  }
+  moduleKey := strings.ToLower(strings.TrimSpace(req.ModuleKey))
+  if moduleKey != "" {
+    if _, ok := syncModuleSet[moduleKey]; !ok {
+      c.JSON(http.StatusBadRequest, gin.H{"error": "invalid module_key"})
+      return
+    }
+  }
+  role := strings.ToLower(strings.TrimSpace(claims.Role))
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  var (
+    status sql.NullString
+    round  int64
+  )
+  if err := tx.QueryRow("SELECT draft_status, review_round FROM app_db_version_names WHERE id = ? FOR UPDATE", id).Scan(&status, &round); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if current := NormalizeDraftStatus(status.String); current != DraftStatusInReview {
+    c.JSON(http.StatusConflict, gin.H{"error": "not_in_review", "draft_status": current})
+    return
+  }
+
+  now := time.Now()
+  if _, err := tx.Exec(
+    "INSERT INTO app_db_approvals (draft_version_id, review_round, module_key, approver_id, approver_role, decision, reason, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON DUPLICATE KEY UPDATE approver_role = VALUES(approver_role), decision = VALUES(decision), reason = VALUES(reason), updated_at = VALUES(updated_at)",
+    id,
+    round,
+    moduleKey,
+    claims.UserID,
+    role,
+    decision,
+    nullIfEmpty(reason),
+    now,
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+
+  detail, _ := json.Marshal(map[string]interface{}{
+    "module_key":   moduleKey,
+    "review_round": round,
+    "role":         role,
+    "reason":       reason,
+  })
+  action := "approve"
+  if decision == approvalDecisionRejected {
+    action = "reject"
+  }
+  if err := insertAuditLog(
+    tx,
+    id,
+    "app_db_version_names",
+    id,
+    action,
+    claims.UserID,
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  evaluation, err := evaluateDraftApprovals(tx, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "draft_version_id": id,
+    "review_round":     round,
+    "decision":         decision,
+    "evaluation":       evaluation,
+  })
+}
+
+// ListPolicies returns the global policy and, when requested, a version override.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *ApprovalHandler) ListPolicies(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  global, err := loadApprovalPolicyRows(h.db, 0)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  response := gin.H{"global": global, "effective": global}
+  if draftVersionID := parseInt64Query(c, "draft_version_id"); draftVersionID > 0 {
+    version, err := loadApprovalPolicyRows(h.db, draftVersionID)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    response["draft_version_id"] = draftVersionID
+    response["version"] = version
+    if len(version) > 0 {
+      response["effective"] = version
+    }
+  }
+
+  c.JSON(http.StatusOK, response)
+}
+
+// ReplacePolicies replaces the requirements of the global policy or a version override.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *ApprovalHandler) ReplacePolicies(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  var req approvalPolicyRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+  if req.DraftVersionID < 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid draft_version_id"})
+    return
+  }
+  requirements, err := NormalizeApprovalRequirements(req.Requirements)
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    return
+  }
+
+  operatorID := int64(0)
+  if claims, ok := middleware.GetAuthClaims(c); ok {
+    operatorID = claims.UserID
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  now := time.Now()
+  if _, err := tx.Exec("DELETE FROM app_db_approval_policies WHERE draft_version_id = ?", req.DraftVersionID); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  for _, requirement := range requirements {
+    if _, err := tx.Exec(
+      "INSERT INTO app_db_approval_policies (draft_version_id, module_key, role, required_count, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+      req.DraftVersionID,
+      requirement.ModuleKey,
+      requirement.Role,
+      requirement.RequiredCount,
+      nullableID(operatorID),
+      now,
+    ); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+      return
+    }
+  }
+
+  detail, _ := json.Marshal(map[string]interface{}{"requirements": requirements})
+  if err := insertAuditLog(
+    tx,
+    nullableID(req.DraftVersionID),
+    "app_db_approval_policies",
+    nil,
+    "policy_update",
+    nullableID(operatorID),
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"draft_version_id": req.DraftVersionID, "requirements": requirements})
+}
+
+// NormalizeApprovalRequirements validates policy lines and merges duplicates of the same scope.
+// Args:
+//   requirements: Requested policy lines.
+// Returns:
+//   []ApprovalRequirement: Normalized lines ordered by module and role.
+//   error: Error naming the invalid field.
+func NormalizeApprovalRequirements(requirements []ApprovalRequirement) ([]ApprovalRequirement, error) {
+  merged := make(map[string]ApprovalRequirement, len(requirements))
+  for _, requirement := range requirements {
+    requirement.ModuleKey = strings.ToLower(strings.TrimSpace(requirement.ModuleKey))
+    requirement.Role = strings.ToLower(strings.TrimSpace(requirement.Role))
+    if requirement.ModuleKey != "" {
+      if _, ok := syncModuleSet[requirement.ModuleKey]; !ok {
+        return nil, fmt.Errorf("invalid module_key")
+      }
+    }
+    if requirement.Role != "" && !IsValidUserRole(requirement.Role) {
+      return nil, fmt.Errorf("invalid role")
+    }
+    if requirement.RequiredCount <= 0 {
+      return nil, fmt.Errorf("invalid required_count")
+    }
+    key := requirement.ModuleKey + "|" + requirement.Role
+    if existing, ok := merged[key]; ok && existing.RequiredCount > requirement.RequiredCount {
+      continue
+    }
+    merged[key] = requirement
+  }
+
+  normalized := make([]ApprovalRequirement, 0, len(merged))
+  for _, requirement := range merged {
+    normalized = append(normalized, requirement)
+  }
+  sort.Slice(normalized, func(i, j int) bool {
+    if normalized[i].ModuleKey != normalized[j].ModuleKey {
+      return normalized[i].ModuleKey < normalized[j].ModuleKey
+    }
+    return normalized[i].Role < normalized[j].Role
+  })
+  return normalized, nil
+}
+
+// evaluateDraftApprovals checks the current review round of a draft against its effective policy.
+// Args:
+//   db: Database or transaction.
+//   draftVersionID: Draft version id.
+// Returns:
+//   ApprovalEvaluation: Evaluation result.
+//   error: Error when query fails.
+func evaluateDraftApprovals(db sqlReader, draftVersionID int64) (ApprovalEvaluation, error) {
+  round, err := loadReviewRound(db, draftVersionID)
+  if err != nil {
+    return ApprovalEvaluation{}, err
+  }
+  requirements, err := loadApprovalRequirements(db, draftVersionID)
+  if err != nil {
+    return ApprovalEvaluation{}, err
+  }
+  records, err := loadApprovalRecords(db, draftVersionID, round)
+  if err != nil {
+    return ApprovalEvaluation{}, err
+  }
+  return EvaluateApprovals(requirements, records), nil
+}
+
+func loadReviewRound(db sqlRowQueryer, draftVersionID int64) (int64, error) {
+  var round int64
+  if err := db.QueryRow("SELECT review_round FROM app_db_version_names WHERE id = ?", draftVersionID).Scan(&round); err != nil {
+    return 0, err
+  }
+  return round, nil
+}
+
+// resetDraftApprovalsTx opens a new review round when an in_review draft changes after approvals were recorded,
+// so decisions on the earlier content stop counting.
+// Args:
+//   tx: Transaction of the change.
+//   draftVersionID: Draft version id.
+//   actorID: Operator id, 0 for none.
+//   now: Change time.
+// Returns:
+//   error: Error when update or audit fails.
+func resetDraftApprovalsTx(tx sqlRowExecutor, draftVersionID, actorID int64, now time.Time) error {
+  if draftVersionID <= 0 {
+    return nil
+  }
+  result, err := tx.Exec(
+    "UPDATE app_db_version_names v SET v.review_round = v.review_round + 1 WHERE v.id = ? AND v.draft_status = ? AND EXISTS (SELECT 1 FROM app_db_approvals a WHERE a.draft_version_id = v.id AND a.review_round = v.review_round)",
+    draftVersionID,
+    DraftStatusInReview,
+  )
+  if err != nil {
+    return err
+  }
+  if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+    return err
+  }
+  round, err := loadReviewRound(tx, draftVersionID)
+  if err != nil {
+    return err
+  }
+  detail, err := json.Marshal(map[string]interface{}{"review_round": round})
+  if err != nil {
+    return err
+  }
+  return insertAuditLog(
+    tx,
+    draftVersionID,
+    "app_db_version_names",
+    draftVersionID,
+    "approvals_reset",
+    nullableID(actorID),
+    string(detail),
+    now,
+  )
+}
+
+// loadApprovalRequirements returns the version override, or the global policy when there is none.
+// Args:
+//   db: Database or transaction.
+//   draftVersionID: Draft version id.
+// Returns:
+//   []ApprovalRequirement: Effective requirements.
+//   error: Error when query fails.
+func loadApprovalRequirements(db sqlQueryer, draftVersionID int64) ([]ApprovalRequirement, error) {
+  requirements, err := loadApprovalPolicyRows(db, draftVersionID)
+  if err != nil || len(requirements) > 0 {
+    return requirements, err
+  }
+  return loadApprovalPolicyRows(db, 0)
+}
+
+func loadApprovalPolicyRows(db sqlQueryer, draftVersionID int64) ([]ApprovalRequirement, error) {
+  rows, err := db.Query(
+    "SELECT module_key, role, required_count FROM app_db_approval_policies WHERE draft_version_id = ? ORDER BY module_key ASC, role ASC",
+    draftVersionID,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  requirements := make([]ApprovalRequirement, 0)
+  for rows.Next() {
+    var requirement ApprovalRequirement
+    if err := rows.Scan(&requirement.ModuleKey, &requirement.Role, &requirement.RequiredCount); err != nil {
+      return nil, err
+    }
+    requirements = append(requirements, requirement)
+  }
+  return requirements, rows.Err()
+}
+
+func loadApprovalRecords(db sqlQueryer, draftVersionID, round int64) ([]ApprovalRecord, error) {
+  rows, err := db.Query(
+    "SELECT a.id, a.module_key, a.approver_id, a.approver_role, a.decision, a.reason, a.updated_at, u.display_name, u.username FROM app_db_approvals a LEFT JOIN app_db_users u ON u.id = a.approver_id WHERE a.draft_version_id = ? AND a.review_round = ? ORDER BY a.updated_at ASC, a.id ASC",
+    draftVersionID,
+    round,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  records := make([]ApprovalRecord, 0)
+  for rows.Next() {
+    var (
+      record      ApprovalRecord
+      reason      sql.NullString
+      displayName sql.NullString
+      username    sql.NullString
+    )
+    if err := rows.Scan(&record.ID, &record.ModuleKey, &record.ApproverID, &record.ApproverRole, &record.Decision, &reason, &record.UpdatedAt, &displayName, &username); err != nil {
+      return nil, err
+    }
+    record.Reason = nullableStringValue(reason)
+    record.ApproverName = nullableStringValue(displayName)
+    if record.ApproverName == "" {
+      record.ApproverName = nullableStringValue(username)
+    }
+    records = append(records, record)
+  }
+  return records, rows.Err()
+}
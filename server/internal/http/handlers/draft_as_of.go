@@ -0,0 +1,753 @@
+package handlers
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "net/http"
+  "os"
+  "sort"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/http/middleware"
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+// draftAsOfDeleteActions are audit actions that remove a row from a draft.
+var draftAsOfDeleteActions = []string{"delete", "trash", "purge"}
+
+// draftAsOfImageActions are audit actions whose detail keeps the removed row image.
+var draftAsOfImageActions = []string{"delete", "purge"}
+
+type draftAsOfRequest struct {
+  At             string  `json:"at"`
+  AppVersionName string  `json:"app_version_name"`
+  LocationName   *string `json:"location_name"`
+}
+
+// FieldHistoryChange is one field change replayed backwards by as-of reconstruction.
+type FieldHistoryChange struct {
+  EntityID int64
+  Field    string
+  // OldValue is the JSON encoded value before the change, nil for NULL.
+  OldValue *string
+}
+
+// draftAsOfState is a draft version rebuilt at a point in time.
+type draftAsOfState struct {
+  version       map[string]interface{}
+  modules       map[string][]map[string]interface{}
+  submissions   map[string]map[int64]gin.H
+  rewound       int
+  reconstructed int
+}
+
+// RewindFieldHistory restores old field values onto rows, newest change first.
+// Args:
+//   rows: Rows keyed by entity id; changed in place.
+//   changes: Changes made after the target time, ordered newest first.
+// Returns:
+//   int: Number of applied changes.
+func RewindFieldHistory(rows map[int64]map[string]interface{}, changes []FieldHistoryChange) int {
+  applied := 0
+  for _, change := range changes {
+    row, ok := rows[change.EntityID]
+    if !ok {
+      continue
+    }
+    if _, ok := row[change.Field]; !ok {
+      continue
+    }
+    row[change.Field] = decodeHistoryValue(change.OldValue)
+    applied++
+  }
+  return applied
+}
+
+// SubmissionStatusAt returns the status a submission had at a point in time.
+// Args:
+//   needConfirm: Whether the submission required a second confirmation.
+//   confirmedAt: Confirmation time, nil when never confirmed.
+//   rejectedAt: Rejection time, nil when never rejected.
+//   at: Point in time.
+// Returns:
+//   string: Submission status at that time.
+func SubmissionStatusAt(needConfirm bool, confirmedAt, rejectedAt *time.Time, at time.Time) string {
+  if rejectedAt != nil && !rejectedAt.After(at) {
+    return "rejected"
+  }
+  if confirmedAt != nil && !confirmedAt.After(at) {
+    return "confirmed"
+  }
+  return SubmissionStatus(needConfirm)
+}
+
+// GetAsOf returns every module of a draft version as it was at a point in time.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftHandler) GetAsOf(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  at, err := ParseSyncScheduleTime(c.Query("at"), appLocation(h.cfg))
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid at"})
+    return
+  }
+
+  state, ok := h.loadDraftAsOf(c, id, at)
+  if !ok {
+    return
+  }
+
+  ossService := h.newOSSService()
+  modules := gin.H{}
+  for _, module := range draftCloneModules {
+    items := make([]gin.H, 0, len(state.modules[module.key]))
+    for _, row := range state.modules[module.key] {
+      items = append(items, h.buildDraftAsOfItem(ossService, module, row, state.submissions[module.key]))
+    }
+    if module.key == "app_ui_fields" {
+      if len(items) == 0 {
+        modules[module.key] = nil
+      } else {
+        modules[module.key] = items[0]
+      }
+      continue
+    }
+    modules[module.key] = items
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "draft_version_id":   id,
+    "at":                 at.In(appLocation(h.cfg)),
+    "version":            buildDraftAsOfVersion(state.version),
+    "modules":            modules,
+    "rewound_fields":     state.rewound,
+    "reconstructed_rows": state.reconstructed,
+  })
+}
+
+// MaterializeAsOf creates a new draft version from the state of a draft at a point in time.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftHandler) MaterializeAsOf(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  sourceID := parseInt64Param(c, "id")
+  if sourceID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  var req draftAsOfRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+  at, err := ParseSyncScheduleTime(req.At, appLocation(h.cfg))
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid at"})
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  state, ok := h.loadDraftAsOf(c, sourceID, at)
+  if !ok {
+    return
+  }
+
+  existing, err := loadDraftVersionNames(h.db)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  versionName := NormalizeVersionName(req.AppVersionName)
+  if versionName != "" {
+    for _, name := range existing {
+      if NormalizeVersionName(name) == versionName {
+        c.JSON(http.StatusConflict, gin.H{"error": "app_version_name already exists"})
+        return
+      }
+    }
+  } else {
+    versionName = BuildCloneVersionName(parseStringValue(state.version["app_version_name"]), existing)
+  }
+  locationName := state.version["location_name"]
+  if req.LocationName != nil {
+    locationName = strings.TrimSpace(*req.LocationName)
+  }
+
+  now := time.Now()
+  copied := make([]string, 0)
+  committed := false
+  defer func() {
+    if !committed {
+      removeCopiedDraftMedia(h.cfg, copied)
+    }
+  }()
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  result, err := tx.Exec(
+    "INSERT INTO app_db_version_names (app_version_name, location_name, feishu_field_names, ai_modal, status, draft_status, submit_version, cloned_from_id, cloned_by, cloned_at, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+    versionName,
+    nullIfEmpty(parseStringValue(locationName)),
+    nullIfEmpty(parseStringValue(state.version["feishu_field_names"])),
+    nullIfEmpty(parseStringValue(state.version["ai_modal"])),
+    state.version["status"],
+    DraftStatusDraft,
+    0,
+    sourceID,
+    nullableID(operatorID),
+    now,
+    nullableID(operatorID),
+    nullableID(operatorID),
+    now,
+    now,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+  newID, err := result.LastInsertId()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+
+  counts := make(map[string]int, len(draftCloneModules))
+  missing := make([]string, 0)
+  for _, module := range draftCloneModules {
+    for _, row := range state.modules[module.key] {
+      values, rowCopied, rowMissing, err := buildDraftAsOfValues(h.cfg, module, newID, row)
+      copied = append(copied, rowCopied...)
+      if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "materialize failed", "module": module.key})
+        return
+      }
+      missing = append(missing, rowMissing...)
+      if err := insertDraftModuleRowTx(tx, module, newID, versionName, values, operatorID, now); err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "materialize failed", "module": module.key})
+        return
+      }
+    }
+    counts[module.key] = len(state.modules[module.key])
+  }
+
+  after, err := snapshotDraftTx(tx, newID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if err := recordDraftSnapshotChangesTx(tx, draftSnapshot{}, after, operatorID, map[string]interface{}{
+    "source":            "materialize_as_of",
+    "source_version_id": sourceID,
+  }, now); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  detail, _ := json.Marshal(map[string]interface{}{
+    "source_version_id":  sourceID,
+    "at":                 at,
+    "app_version_name":   versionName,
+    "counts":             counts,
+    "rewound_fields":     state.rewound,
+    "reconstructed_rows": state.reconstructed,
+    "copied_files":       len(copied),
+    "missing_files":      missing,
+  })
+  if err := insertAuditLog(
+    tx,
+    newID,
+    "app_db_version_names",
+    newID,
+    "materialize_as_of",
+    nullableID(operatorID),
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+  committed = true
+
+  c.JSON(http.StatusOK, gin.H{
+    "id":               newID,
+    "app_version_name": versionName,
+    "cloned_from_id":   sourceID,
+    "at":               at.In(appLocation(h.cfg)),
+    "modules":          counts,
+    "copied_files":     len(copied),
+    "missing_files":    missing,
+  })
+}
+
+// loadDraftAsOf rebuilds a draft at a point in time and answers the error itself.
+// Args:
+//   c: Gin context.
+//   draftVersionID: Draft version id.
+//   at: Point in time.
+// Returns:
+//   *draftAsOfState: Rebuilt state.
+//   bool: False when a response was already written.
+func (h *DraftHandler) loadDraftAsOf(c *gin.Context, draftVersionID int64, at time.Time) (*draftAsOfState, bool) {
+  var createdAt sql.NullTime
+  if err := h.db.QueryRow("SELECT created_at FROM app_db_version_names WHERE id = ?", draftVersionID).Scan(&createdAt); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+      return nil, false
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return nil, false
+  }
+  if createdAt.Valid && createdAt.Time.After(at) {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "draft did not exist at that time", "created_at": createdAt.Time})
+    return nil, false
+  }
+
+  state, err := reconstructDraftAsOf(h.db, draftVersionID, at)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return nil, false
+  }
+  return state, true
+}
+
+// reconstructDraftAsOf rebuilds the version row and module rows of a draft at a point in time.
+// Current rows that existed at that time have later field history replayed backwards; rows
+// removed since then are rebuilt from their latest submission at that time.
+// Args:
+//   db: Database or transaction.
+//   draftVersionID: Draft version id.
+//   at: Point in time.
+// Returns:
+//   *draftAsOfState: Rebuilt state.
+//   error: Error when query fails.
+func reconstructDraftAsOf(db sqlQueryer, draftVersionID int64, at time.Time) (*draftAsOfState, error) {
+  state := &draftAsOfState{
+    modules:     make(map[string][]map[string]interface{}, len(draftCloneModules)),
+    submissions: make(map[string]map[int64]gin.H, len(draftCloneModules)),
+  }
+
+  versions, err := queryDraftEntityRows(db, "SELECT * FROM app_db_version_names WHERE id = ?", draftVersionID)
+  if err != nil {
+    return nil, err
+  }
+  if len(versions) == 0 {
+    return nil, sql.ErrNoRows
+  }
+  state.version = versions[0]
+  changes, err := loadFieldHistoryAfter(db, draftVersionID, "app_db_version_names", at)
+  if err != nil {
+    return nil, err
+  }
+  state.rewound += RewindFieldHistory(map[int64]map[string]interface{}{draftVersionID: state.version}, changes)
+
+  for _, module := range draftCloneModules {
+    query := "SELECT * FROM " + module.table + " WHERE draft_version_id = ? AND (created_at IS NULL OR created_at <= ?)"
+    args := []interface{}{draftVersionID, at}
+    if draftTrashFilter(module.table) != "" {
+      query += " AND (deleted_at IS NULL OR deleted_at > ?)"
+      args = append(args, at)
+    }
+    current, err := queryDraftEntityRows(db, query+" ORDER BY id ASC", args...)
+    if err != nil {
+      return nil, err
+    }
+    rows := make(map[int64]map[string]interface{}, len(current))
+    for _, row := range current {
+      rows[parseID(row["id"])] = row
+    }
+
+    images, err := loadDeletedDraftRowImages(db, draftVersionID, module.table, at)
+    if err != nil {
+      return nil, err
+    }
+    for entityID, image := range images {
+      if _, ok := rows[entityID]; !ok {
+        rows[entityID] = image
+        state.reconstructed++
+      }
+    }
+
+    changes, err := loadFieldHistoryAfter(db, draftVersionID, module.table, at)
+    if err != nil {
+      return nil, err
+    }
+    state.rewound += RewindFieldHistory(rows, changes)
+
+    submissions, payloads, err := loadSubmissionsAsOf(db, draftVersionID, module.key, module.table, at)
+    if err != nil {
+      return nil, err
+    }
+    state.submissions[module.key] = submissions
+
+    removed, err := loadRemovedDraftRowIDs(db, draftVersionID, module.table, at)
+    if err != nil {
+      return nil, err
+    }
+    for entityID, payload := range payloads {
+      if _, ok := rows[entityID]; ok {
+        continue
+      }
+      if _, ok := removed[entityID]; ok {
+        continue
+      }
+      row := map[string]interface{}{"id": entityID, "row_version": nil}
+      for _, column := range module.columns {
+        name := strings.Trim(column, "`")
+        row[name] = payload[name]
+      }
+      rows[entityID] = row
+      state.reconstructed++
+    }
+
+    items := make([]map[string]interface{}, 0, len(rows))
+    for _, row := range rows {
+      items = append(items, row)
+    }
+    sortDraftAsOfRows(module, items)
+    state.modules[module.key] = items
+  }
+  return state, nil
+}
+
+// loadFieldHistoryAfter returns field changes of a table made after a point in time, newest first.
+// Rows with direct edit history only replay that history; submission diffs are replayed for rows
+// last edited before direct edits were recorded.
+func loadFieldHistoryAfter(db sqlQueryer, draftVersionID int64, table string, at time.Time) ([]FieldHistoryChange, error) {
+  tracked := make(map[int64]struct{})
+  trackedRows, err := db.Query(
+    "SELECT DISTINCT entity_id FROM app_db_field_history WHERE draft_version_id = ? AND entity_table = ? AND submit_id IS NULL",
+    draftVersionID,
+    table,
+  )
+  if err != nil {
+    return nil, err
+  }
+  for trackedRows.Next() {
+    var entityID int64
+    if err := trackedRows.Scan(&entityID); err != nil {
+      _ = trackedRows.Close()
+      return nil, err
+    }
+    tracked[entityID] = struct{}{}
+  }
+  _ = trackedRows.Close()
+  if err := trackedRows.Err(); err != nil {
+    return nil, err
+  }
+
+  rows, err := db.Query(
+    "SELECT entity_id, field_name, old_value, submit_id FROM app_db_field_history WHERE draft_version_id = ? AND entity_table = ? AND created_at > ? ORDER BY created_at DESC, id DESC",
+    draftVersionID,
+    table,
+    at,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  changes := make([]FieldHistoryChange, 0)
+  for rows.Next() {
+    var (
+      entityID int64
+      field    sql.NullString
+      oldValue sql.NullString
+      submitID sql.NullInt64
+    )
+    if err := rows.Scan(&entityID, &field, &oldValue, &submitID); err != nil {
+      return nil, err
+    }
+    if _, ok := tracked[entityID]; ok && submitID.Valid {
+      continue
+    }
+    change := FieldHistoryChange{EntityID: entityID, Field: field.String}
+    if oldValue.Valid {
+      value := oldValue.String
+      change.OldValue = &value
+    }
+    changes = append(changes, change)
+  }
+  return changes, rows.Err()
+}
+
+// loadDeletedDraftRowImages returns the image of rows hard deleted after a point in time that
+// existed, outside the trash, at that time.
+func loadDeletedDraftRowImages(db sqlQueryer, draftVersionID int64, table string, at time.Time) (map[int64]map[string]interface{}, error) {
+  placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(draftAsOfImageActions)), ", ")
+  args := []interface{}{draftVersionID, table, at}
+  for _, action := range draftAsOfImageActions {
+    args = append(args, action)
+  }
+  rows, err := db.Query(
+    "SELECT entity_id, detail_json FROM app_db_audit_logs WHERE draft_version_id = ? AND entity_table = ? AND created_at > ? AND entity_id IS NOT NULL AND action IN ("+placeholders+") ORDER BY created_at ASC, id ASC",
+    args...,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  images := make(map[int64]map[string]interface{})
+  for rows.Next() {
+    var (
+      entityID int64
+      detail   sql.NullString
+    )
+    if err := rows.Scan(&entityID, &detail); err != nil {
+      return nil, err
+    }
+    if _, ok := images[entityID]; ok {
+      continue
+    }
+    var parsed struct {
+      Before map[string]interface{} `json:"before"`
+    }
+    if err := json.Unmarshal([]byte(detail.String), &parsed); err != nil || parsed.Before == nil {
+      continue
+    }
+    if !draftRowImageVisibleAt(parsed.Before, at) {
+      images[entityID] = nil
+      continue
+    }
+    images[entityID] = parsed.Before
+  }
+  for entityID, image := range images {
+    if image == nil {
+      delete(images, entityID)
+    }
+  }
+  return images, rows.Err()
+}
+
+// draftRowImageVisibleAt reports whether a row image was created and not trashed at a point in time.
+func draftRowImageVisibleAt(image map[string]interface{}, at time.Time) bool {
+  if createdAt, ok := parseDraftImageTime(image["created_at"]); ok && createdAt.After(at) {
+    return false
+  }
+  if deletedAt, ok := parseDraftImageTime(image["deleted_at"]); ok && !deletedAt.After(at) {
+    return false
+  }
+  return true
+}
+
+func parseDraftImageTime(value interface{}) (time.Time, bool) {
+  raw, ok := value.(string)
+  if !ok || raw == "" {
+    return time.Time{}, false
+  }
+  parsed, err := time.Parse(time.RFC3339Nano, raw)
+  if err != nil {
+    return time.Time{}, false
+  }
+  return parsed, true
+}
+
+// loadSubmissionsAsOf returns the latest submission summary and payload per entity at a point in time.
+func loadSubmissionsAsOf(db sqlQueryer, draftVersionID int64, moduleKey, table string, at time.Time) (map[int64]gin.H, map[int64]map[string]interface{}, error) {
+  rows, err := db.Query(
+    "SELECT entity_id, need_confirm, confirmed_at, rejected_at, created_at, payload_json FROM app_db_submissions WHERE draft_version_id = ? AND module_key = ? AND entity_table = ? AND created_at <= ? ORDER BY submit_version DESC, id DESC",
+    draftVersionID,
+    moduleKey,
+    table,
+    at,
+  )
+  if err != nil {
+    return nil, nil, err
+  }
+  defer rows.Close()
+
+  summaries := make(map[int64]gin.H)
+  payloads := make(map[int64]map[string]interface{})
+  for rows.Next() {
+    var (
+      entityID    int64
+      needConfirm sql.NullBool
+      confirmedAt sql.NullTime
+      rejectedAt  sql.NullTime
+      createdAt   sql.NullTime
+      payloadJSON sql.NullString
+    )
+    if err := rows.Scan(&entityID, &needConfirm, &confirmedAt, &rejectedAt, &createdAt, &payloadJSON); err != nil {
+      return nil, nil, err
+    }
+    if _, ok := summaries[entityID]; ok {
+      continue
+    }
+    summaries[entityID] = gin.H{
+      "submit_status":  SubmissionStatusAt(needConfirm.Bool, nullableTimePointer(confirmedAt), nullableTimePointer(rejectedAt), at),
+      "last_submit_at": nullableTimePointer(createdAt),
+    }
+    if payload, err := decodePayload([]byte(payloadJSON.String)); err == nil {
+      payloads[entityID] = payload
+    }
+  }
+  return summaries, payloads, rows.Err()
+}
+
+// loadRemovedDraftRowIDs returns rows of a table that were deleted at or before a point in time
+// according to the audit log.
+func loadRemovedDraftRowIDs(db sqlQueryer, draftVersionID int64, table string, at time.Time) (map[int64]struct{}, error) {
+  placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(draftAsOfDeleteActions)), ", ")
+  args := []interface{}{draftVersionID, table, at}
+  for _, action := range draftAsOfDeleteActions {
+    args = append(args, action)
+  }
+  rows, err := db.Query(
+    "SELECT DISTINCT entity_id FROM app_db_audit_logs WHERE draft_version_id = ? AND entity_table = ? AND created_at <= ? AND entity_id IS NOT NULL AND action IN ("+placeholders+")",
+    args...,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  removed := make(map[int64]struct{})
+  for rows.Next() {
+    var entityID int64
+    if err := rows.Scan(&entityID); err != nil {
+      return nil, err
+    }
+    removed[entityID] = struct{}{}
+  }
+  return removed, rows.Err()
+}
+
+// sortDraftAsOfRows orders rows the same way as the module list endpoints.
+func sortDraftAsOfRows(module draftCloneModule, items []map[string]interface{}) {
+  orderField := "sort"
+  if module.key == "config_extra_steps" {
+    orderField = "step_index"
+  }
+  sort.SliceStable(items, func(i, j int) bool {
+    left, right := parseID(items[i][orderField]), parseID(items[j][orderField])
+    if left != right {
+      return left < right
+    }
+    return parseID(items[i]["id"]) < parseID(items[j]["id"])
+  })
+}
+
+// buildDraftAsOfItem shapes a rebuilt row like the module list endpoints.
+func (h *DraftHandler) buildDraftAsOfItem(ossService *services.OSSService, module draftCloneModule, row map[string]interface{}, submissions map[int64]gin.H) gin.H {
+  id := parseID(row["id"])
+  item := gin.H{"id": id, "row_version": row["row_version"]}
+  for _, column := range module.columns {
+    name := strings.Trim(column, "`")
+    item[name] = row[name]
+  }
+  if module.byNameID {
+    item["app_version_name_id"] = row["app_version_name_id"]
+  } else {
+    item["app_version_name"] = row["app_version_name"]
+  }
+  for _, media := range module.media {
+    path := parseStringValue(row[media])
+    if path == "" {
+      item[media+"_url"] = nil
+      continue
+    }
+    item[media+"_url"] = signPath(h.cfg, ossService, &path, "")
+  }
+  if module.key == "app_ui_fields" {
+    if path := parseStringValue(row["print_wait"]); path != "" {
+      item["print_wait_url"] = signPath(h.cfg, ossService, &path, "")
+    }
+  }
+  item["submit_status"] = ""
+  item["last_submit_at"] = nil
+  if summary, ok := submissions[id]; ok {
+    item["submit_status"] = summary["submit_status"]
+    item["last_submit_at"] = summary["last_submit_at"]
+  }
+  return item
+}
+
+func buildDraftAsOfVersion(row map[string]interface{}) gin.H {
+  version := gin.H{}
+  for _, field := range []string{"id", "app_version_name", "location_name", "feishu_field_names", "ai_modal", "status"} {
+    version[field] = row[field]
+  }
+  version["feishu_field_list"] = parseFeishuFieldList(sql.NullString{
+    String: parseStringValue(row["feishu_field_names"]),
+    Valid:  row["feishu_field_names"] != nil,
+  })
+  return version
+}
+
+// buildDraftAsOfValues orders a rebuilt row for insertDraftModuleRowTx, copying local media for the new draft.
+func buildDraftAsOfValues(cfg *config.Config, module draftCloneModule, draftVersionID int64, row map[string]interface{}) ([]interface{}, []string, []string, error) {
+  copied := make([]string, 0)
+  missing := make([]string, 0)
+  mediaIndex := draftModuleMediaIndex(module)
+  values := make([]interface{}, 0, len(module.columns))
+  for i, column := range module.columns {
+    value := row[strings.Trim(column, "`")]
+    path, isString := value.(string)
+    if _, ok := mediaIndex[i]; ok && isString && isLocalPath(path) {
+      copiedPath, err := copyLocalMedia(cfg, module.key, draftVersionID, path)
+      if err != nil {
+        if !errors.Is(err, os.ErrNotExist) {
+          return nil, copied, missing, err
+        }
+        missing = append(missing, path)
+        copiedPath = path
+      } else {
+        copied = append(copied, copiedPath)
+      }
+      values = append(values, copiedPath)
+      continue
+    }
+    values = append(values, value)
+  }
+  return values, copied, missing, nil
+}
+
+func decodeHistoryValue(raw *string) interface{} {
+  if raw == nil {
+    return nil
+  }
+  var value interface{}
+  if err := json.Unmarshal([]byte(*raw), &value); err != nil {
+    return *raw
+  }
+  return value
+}
@@ -0,0 +1,650 @@
+package handlers
+
+import (
+  "archive/zip"
+  "crypto/sha256"
+  "database/sql"
+  "encoding/hex"
+  "encoding/json"
+  "fmt"
+  "io"
+  "net/http"
+  "os"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+  "github.com/redis/go-redis/v9"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/http/middleware"
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+const (
+  // DraftBundleSchemaVersion is the manifest schema version written by export and accepted by import.
+  DraftBundleSchemaVersion = 1
+
+  draftBundleManifestName = "manifest.json"
+  draftBundleMediaDir     = "media/"
+  draftBundleMaxMediaSize = 512 << 20
+)
+
+var draftBundleKeyColumns = map[string]string{
+  "banners":            "title",
+  "identities":         "name",
+  "scenes":             "name",
+  "clothes_categories": "name",
+  "photo_hobbies":      "name",
+  "config_extra_steps": "step_index",
+}
+
+type DraftBundleHandler struct {
+  cfg   *config.Config
+  db    *sql.DB
+  redis *redis.Client
+}
+
+// DraftBundleVersion is the version meta stored in a bundle manifest.
+type DraftBundleVersion struct {
+  AppVersionName   string `json:"app_version_name"`
+  LocationName     string `json:"location_name"`
+  FeishuFieldNames string `json:"feishu_field_names"`
+  AiModal          string `json:"ai_modal"`
+  Status           *int64 `json:"status"`
+}
+
+// DraftBundleMedia maps a stored media path to its file inside the bundle.
+type DraftBundleMedia struct {
+  Path   string `json:"path"`
+  File   string `json:"file"`
+  Size   int64  `json:"size"`
+  SHA256 string `json:"sha256"`
+}
+
+// DraftBundleManifest is the JSON manifest of a draft bundle.
+type DraftBundleManifest struct {
+  SchemaVersion   int                                 `json:"schema_version"`
+  ExportedAt      string                              `json:"exported_at"`
+  SourceVersionID int64                               `json:"source_version_id"`
+  Version         DraftBundleVersion                  `json:"version"`
+  Modules         map[string][]map[string]interface{} `json:"modules"`
+  Media           []DraftBundleMedia                  `json:"media"`
+  MissingMedia    []string                            `json:"missing_media"`
+}
+
+// DraftBundleRowError is a validation error of one manifest row (row is 1-based).
+type DraftBundleRowError struct {
+  ModuleKey string `json:"module_key,omitempty"`
+  Row       int    `json:"row,omitempty"`
+  Field     string `json:"field,omitempty"`
+  Error     string `json:"error"`
+}
+
+// NewDraftBundleHandler creates a handler for draft bundle export and import.
+// Args:
+//   cfg: App config instance.
+//   db: Database connection.
+//   redis: Redis client for OSS signing cache.
+// Returns:
+//   *DraftBundleHandler: Initialized handler.
+func NewDraftBundleHandler(cfg *config.Config, db *sql.DB, redis *redis.Client) *DraftBundleHandler {
+  return &DraftBundleHandler{cfg: cfg, db: db, redis: redis}
+}
+
+// ValidateDraftBundleManifest checks the schema version and every module row of a manifest.
+// Args:
+//   manifest: Decoded manifest.
+// Returns:
+//   []DraftBundleRowError: Validation errors, empty when the manifest is valid.
+func ValidateDraftBundleManifest(manifest DraftBundleManifest) []DraftBundleRowError {
+  errs := make([]DraftBundleRowError, 0)
+  if manifest.SchemaVersion != DraftBundleSchemaVersion {
+    errs = append(errs, DraftBundleRowError{Field: "schema_version", Error: fmt.Sprintf("unsupported schema_version %d, expected %d", manifest.SchemaVersion, DraftBundleSchemaVersion)})
+    return errs
+  }
+  if strings.TrimSpace(manifest.Version.AppVersionName) == "" {
+    errs = append(errs, DraftBundleRowError{Field: "version.app_version_name", Error: "app_version_name is required"})
+  }
+  if _, err := NormalizeAiModal(manifest.Version.AiModal); err != nil {
+    errs = append(errs, DraftBundleRowError{Field: "version.ai_modal", Error: "invalid ai_modal"})
+  }
+
+  known := make(map[string]draftCloneModule, len(draftCloneModules))
+  for _, module := range draftCloneModules {
+    known[module.key] = module
+  }
+  for moduleKey, rows := range manifest.Modules {
+    module, ok := known[moduleKey]
+    if !ok {
+      errs = append(errs, DraftBundleRowError{ModuleKey: moduleKey, Error: "unknown module"})
+      continue
+    }
+    if moduleKey == "app_ui_fields" && len(rows) > 1 {
+      errs = append(errs, DraftBundleRowError{ModuleKey: moduleKey, Error: "at most one row is allowed"})
+    }
+    columns := make(map[string]struct{}, len(module.columns))
+    for _, column := range module.columns {
+      columns[strings.Trim(column, "`")] = struct{}{}
+    }
+    for index, row := range rows {
+      for field, value := range row {
+        if _, ok := columns[field]; !ok {
+          errs = append(errs, DraftBundleRowError{ModuleKey: moduleKey, Row: index + 1, Field: field, Error: "unknown field"})
+          continue
+        }
+        if _, err := draftBundleColumnValue(field, value); err != nil {
+          errs = append(errs, DraftBundleRowError{ModuleKey: moduleKey, Row: index + 1, Field: field, Error: err.Error()})
+        }
+      }
+      if keyColumn, ok := draftBundleKeyColumns[moduleKey]; ok {
+        value, _ := draftBundleColumnValue(keyColumn, row[keyColumn])
+        if value == nil || strings.TrimSpace(fmt.Sprint(value)) == "" {
+          errs = append(errs, DraftBundleRowError{ModuleKey: moduleKey, Row: index + 1, Field: keyColumn, Error: keyColumn + " is required"})
+        }
+      }
+    }
+  }
+  return errs
+}
+
+// draftBundleColumnValue converts a manifest value into a column value.
+func draftBundleColumnValue(field string, value interface{}) (interface{}, error) {
+  if value == nil {
+    return nil, nil
+  }
+  if _, numeric := syncMergeNumericColumns[field]; numeric {
+    switch typed := value.(type) {
+    case json.Number:
+      parsed, err := strconv.ParseInt(typed.String(), 10, 64)
+      if err != nil {
+        return nil, fmt.Errorf("%s must be an integer", field)
+      }
+      return parsed, nil
+    case float64:
+      if typed != float64(int64(typed)) {
+        return nil, fmt.Errorf("%s must be an integer", field)
+      }
+      return int64(typed), nil
+    case string:
+      trimmed := strings.TrimSpace(typed)
+      if trimmed == "" {
+        return nil, nil
+      }
+      parsed, err := strconv.ParseInt(trimmed, 10, 64)
+      if err != nil {
+        return nil, fmt.Errorf("%s must be an integer", field)
+      }
+      return parsed, nil
+    case bool:
+      if typed {
+        return int64(1), nil
+      }
+      return int64(0), nil
+    }
+    return nil, fmt.Errorf("%s must be an integer", field)
+  }
+  switch typed := value.(type) {
+  case string:
+    return typed, nil
+  case json.Number:
+    return typed.String(), nil
+  }
+  return nil, fmt.Errorf("%s must be a string", field)
+}
+
+// Export writes a zip bundle with the manifest and media files of a draft version.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftBundleHandler) Export(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+  draftVersionID := parseInt64Param(c, "id")
+  if draftVersionID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+
+  version, err := loadDraftVersion(h.db, draftVersionID)
+  if err == sql.ErrNoRows {
+    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  manifest := DraftBundleManifest{
+    SchemaVersion:   DraftBundleSchemaVersion,
+    ExportedAt:      time.Now().Format(time.RFC3339),
+    SourceVersionID: draftVersionID,
+    Version: DraftBundleVersion{
+      AppVersionName:   nullableStringValue(version.AppVersionName),
+      LocationName:     nullableStringValue(version.LocationName),
+      FeishuFieldNames: nullableStringValue(version.FeishuFieldNames),
+      AiModal:          nullableStringValue(version.AiModal),
+      Status:           nullableInt64Pointer(version.Status),
+    },
+    Modules:      make(map[string][]map[string]interface{}, len(draftCloneModules)),
+    Media:        make([]DraftBundleMedia, 0),
+    MissingMedia: make([]string, 0),
+  }
+
+  tempFile, err := os.CreateTemp("", "draft-bundle-*.zip")
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "create bundle failed"})
+    return
+  }
+  tempPath := tempFile.Name()
+  defer func() {
+    _ = tempFile.Close()
+    _ = os.Remove(tempPath)
+  }()
+  archive := zip.NewWriter(tempFile)
+
+  var ossService *services.OSSService
+  mediaFiles := make(map[string]string)
+  for _, module := range draftCloneModules {
+    rows, err := loadDraftModuleRows(h.db, module, draftVersionID)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    mediaIndex := draftModuleMediaIndex(module)
+    items := make([]map[string]interface{}, 0, len(rows))
+    for _, values := range rows {
+      item := make(map[string]interface{}, len(values))
+      for i, value := range values {
+        field := strings.Trim(module.columns[i], "`")
+        item[field] = draftBundleExportValue(field, value)
+        if _, ok := mediaIndex[i]; !ok || !value.Valid || strings.TrimSpace(value.String) == "" {
+          continue
+        }
+        storedPath := strings.TrimSpace(value.String)
+        if _, seen := mediaFiles[storedPath]; seen || strings.Contains(strings.TrimPrefix(storedPath, localPathPrefix), "://") {
+          continue
+        }
+        if !isLocalPath(storedPath) && ossService == nil {
+          ossService, _ = services.NewOSSService(h.cfg, h.redis)
+        }
+        entry := fmt.Sprintf("%s%04d_%s", draftBundleMediaDir, len(manifest.Media)+1, filepath.Base(strings.TrimPrefix(storedPath, localPathPrefix)))
+        media, err := h.writeBundleMedia(archive, ossService, storedPath, entry)
+        if err != nil {
+          mediaFiles[storedPath] = ""
+          manifest.MissingMedia = append(manifest.MissingMedia, storedPath)
+          continue
+        }
+        mediaFiles[storedPath] = entry
+        manifest.Media = append(manifest.Media, media)
+      }
+      items = append(items, item)
+    }
+    manifest.Modules[module.key] = items
+  }
+
+  manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "encode manifest failed"})
+    return
+  }
+  writer, err := archive.Create(draftBundleManifestName)
+  if err == nil {
+    _, err = writer.Write(manifestBytes)
+  }
+  if err == nil {
+    err = archive.Close()
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "write bundle failed"})
+    return
+  }
+
+  name := manifest.Version.AppVersionName
+  if name == "" {
+    name = fmt.Sprintf("draft_%d", draftVersionID)
+  }
+  c.FileAttachment(tempPath, fmt.Sprintf("%s_%s.zip", name, time.Now().Format("20060102150405")))
+}
+
+// writeBundleMedia copies one local or OSS media file into the bundle archive.
+func (h *DraftBundleHandler) writeBundleMedia(archive *zip.Writer, ossService *services.OSSService, storedPath, entry string) (DraftBundleMedia, error) {
+  media := DraftBundleMedia{Path: storedPath, File: entry}
+  sourcePath := ""
+  if isLocalPath(storedPath) {
+    localPath, err := resolveLocalFilePath(h.cfg, storedPath)
+    if err != nil {
+      return media, err
+    }
+    sourcePath = localPath
+  } else {
+    if ossService == nil {
+      return media, fmt.Errorf("oss not configured")
+    }
+    tempFile, err := os.CreateTemp("", "draft-bundle-media-*")
+    if err != nil {
+      return media, err
+    }
+    sourcePath = tempFile.Name()
+    _ = tempFile.Close()
+    defer func() {
+      _ = os.Remove(sourcePath)
+    }()
+    if err := ossService.DownloadToFile(storedPath, sourcePath); err != nil {
+      return media, err
+    }
+  }
+
+  source, err := os.Open(sourcePath)
+  if err != nil {
+    return media, err
+  }
+  defer func() {
+    _ = source.Close()
+  }()
+  writer, err := archive.Create(entry)
+  if err != nil {
+    return media, err
+  }
+  hasher := sha256.New()
+  size, err := io.Copy(io.MultiWriter(writer, hasher), source)
+  if err != nil {
+    return media, err
+  }
+  media.Size = size
+  media.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+  return media, nil
+}
+
+func draftBundleExportValue(field string, value sql.NullString) interface{} {
+  if !value.Valid {
+    return nil
+  }
+  if _, ok := syncMergeNumericColumns[field]; ok {
+    if parsed, err := strconv.ParseInt(strings.TrimSpace(value.String), 10, 64); err == nil {
+      return parsed
+    }
+  }
+  return value.String
+}
+
+// Import recreates a draft version from an uploaded bundle with new ids.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftBundleHandler) Import(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  header, err := c.FormFile("file")
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+    return
+  }
+  file, err := header.Open()
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file"})
+    return
+  }
+  defer func() {
+    _ = file.Close()
+  }()
+  archive, err := zip.NewReader(file, header.Size)
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid bundle"})
+    return
+  }
+  entries := make(map[string]*zip.File, len(archive.File))
+  for _, item := range archive.File {
+    entries[item.Name] = item
+  }
+
+  manifestEntry, ok := entries[draftBundleManifestName]
+  if !ok {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "manifest.json is missing"})
+    return
+  }
+  manifest, err := readDraftBundleManifest(manifestEntry)
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid manifest", "detail": err.Error()})
+    return
+  }
+  if errs := ValidateDraftBundleManifest(manifest); len(errs) > 0 {
+    c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "invalid_bundle", "errors": errs})
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  existing, err := loadDraftVersionNames(h.db)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  versionName := NormalizeVersionName(c.PostForm("app_version_name"))
+  if versionName == "" {
+    versionName = NormalizeVersionName(manifest.Version.AppVersionName)
+  }
+  for _, name := range existing {
+    if NormalizeVersionName(name) == versionName {
+      versionName = BuildCloneVersionName(versionName, existing)
+      break
+    }
+  }
+  aiModal, _ := NormalizeAiModal(manifest.Version.AiModal)
+
+  mediaByPath := make(map[string]DraftBundleMedia, len(manifest.Media))
+  for _, media := range manifest.Media {
+    mediaByPath[media.Path] = media
+  }
+
+  now := time.Now()
+  written := make([]string, 0)
+  committed := false
+  defer func() {
+    if committed {
+      return
+    }
+    for _, path := range written {
+      if absPath, err := buildLocalFilePath(h.cfg, trimLocalPrefix(path)); err == nil {
+        _ = os.Remove(absPath)
+      }
+    }
+  }()
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  result, err := tx.Exec(
+    "INSERT INTO app_db_version_names (app_version_name, location_name, feishu_field_names, ai_modal, status, draft_status, submit_version, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+    versionName,
+    nullIfEmpty(manifest.Version.LocationName),
+    nullIfEmpty(manifest.Version.FeishuFieldNames),
+    aiModal,
+    manifest.Version.Status,
+    "draft",
+    0,
+    nullableID(operatorID),
+    nullableID(operatorID),
+    now,
+    now,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+  draftVersionID, err := result.LastInsertId()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+
+  counts := make(map[string]int, len(draftCloneModules))
+  rowErrors := make([]DraftBundleRowError, 0)
+  missing := make([]string, 0)
+  imported := make(map[string]string)
+  for _, module := range draftCloneModules {
+    rows := manifest.Modules[module.key]
+    mediaIndex := draftModuleMediaIndex(module)
+    for index, row := range rows {
+      values := make([]interface{}, len(module.columns))
+      for i, column := range module.columns {
+        field := strings.Trim(column, "`")
+        value, _ := draftBundleColumnValue(field, row[field])
+        values[i] = value
+        text, isText := value.(string)
+        if _, ok := mediaIndex[i]; !ok || !isText || strings.TrimSpace(text) == "" {
+          continue
+        }
+        if path, ok := imported[text]; ok {
+          values[i] = path
+          continue
+        }
+        media, ok := mediaByPath[text]
+        if !ok {
+          // External URLs and media missing from the bundle keep the original reference.
+          missing = append(missing, text)
+          imported[text] = text
+          continue
+        }
+        path, err := h.extractBundleMedia(entries[media.File], media, module.key, draftVersionID)
+        if err != nil {
+          rowErrors = append(rowErrors, DraftBundleRowError{ModuleKey: module.key, Row: index + 1, Field: field, Error: err.Error()})
+          continue
+        }
+        written = append(written, path)
+        imported[text] = path
+        values[i] = path
+      }
+      if err := insertDraftModuleRowTx(tx, module, draftVersionID, versionName, values, operatorID, now); err != nil {
+        rowErrors = append(rowErrors, DraftBundleRowError{ModuleKey: module.key, Row: index + 1, Error: err.Error()})
+        continue
+      }
+      counts[module.key]++
+    }
+  }
+  if len(rowErrors) > 0 {
+    c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "import_failed", "errors": rowErrors})
+    return
+  }
+
+  detail, _ := json.Marshal(map[string]interface{}{
+    "source_version_id": manifest.SourceVersionID,
+    "schema_version":    manifest.SchemaVersion,
+    "exported_at":       manifest.ExportedAt,
+    "file_name":         header.Filename,
+    "counts":            counts,
+    "media_files":       len(written),
+    "missing_media":     missing,
+  })
+  if err := insertAuditLog(
+    tx,
+    draftVersionID,
+    "app_db_version_names",
+    draftVersionID,
+    "import_bundle",
+    nullableID(operatorID),
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+  committed = true
+
+  c.JSON(http.StatusOK, gin.H{
+    "id":               draftVersionID,
+    "app_version_name": versionName,
+    "modules":          counts,
+    "media_files":      len(written),
+    "missing_media":    missing,
+  })
+}
+
+func readDraftBundleManifest(entry *zip.File) (DraftBundleManifest, error) {
+  var manifest DraftBundleManifest
+  reader, err := entry.Open()
+  if err != nil {
+    return manifest, err
+  }
+  defer func() {
+    _ = reader.Close()
+  }()
+  decoder := json.NewDecoder(reader)
+  decoder.UseNumber()
+  if err := decoder.Decode(&manifest); err != nil {
+    return manifest, err
+  }
+  return manifest, nil
+}
+
+// extractBundleMedia writes one bundled media file into local storage and verifies its checksum.
+func (h *DraftBundleHandler) extractBundleMedia(entry *zip.File, media DraftBundleMedia, moduleKey string, draftVersionID int64) (string, error) {
+  if entry == nil || !strings.HasPrefix(media.File, draftBundleMediaDir) {
+    return "", fmt.Errorf("media file %s is missing from bundle", media.File)
+  }
+  if entry.UncompressedSize64 > draftBundleMaxMediaSize {
+    return "", fmt.Errorf("media file %s is too large", media.File)
+  }
+  relative, err := buildLocalUploadPath(moduleKey, draftVersionID, filepath.Base(media.File))
+  if err != nil {
+    return "", err
+  }
+  targetPath, err := buildLocalFilePath(h.cfg, relative)
+  if err != nil {
+    return "", err
+  }
+  if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+    return "", err
+  }
+
+  reader, err := entry.Open()
+  if err != nil {
+    return "", err
+  }
+  defer func() {
+    _ = reader.Close()
+  }()
+  target, err := os.Create(targetPath)
+  if err != nil {
+    return "", err
+  }
+  hasher := sha256.New()
+  _, copyErr := io.Copy(io.MultiWriter(target, hasher), io.LimitReader(reader, draftBundleMaxMediaSize))
+  closeErr := target.Close()
+  if copyErr == nil {
+    copyErr = closeErr
+  }
+  if copyErr == nil && media.SHA256 != "" && hex.EncodeToString(hasher.Sum(nil)) != media.SHA256 {
+    copyErr = fmt.Errorf("media file %s checksum mismatch", media.File)
+  }
+  if copyErr != nil {
+    _ = os.Remove(targetPath)
+    return "", copyErr
+  }
+  return localPathPrefix + relative, nil
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+  "encoding/json"
+  "sort"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+// draftChangeSkipColumns are bookkeeping columns left out of field history.
+var draftChangeSkipColumns = map[string]struct{}{
+  "id":                {},
+  draftRevisionColumn: {},
+  "created_at":        {},
+  "created_by":        {},
+  "updated_at":        {},
+  "updated_by":        {},
+  "deleted_at":        {},
+  "deleted_by":        {},
+}
+
+// draftChangeImageActions keep the removed row image in the audit detail so it can be rebuilt later.
+var draftChangeImageActions = map[string]struct{}{
+  "delete": {},
+  "purge":  {},
+}
+
+// draftSnapshot holds row images of one draft keyed by table and row id.
+type draftSnapshot map[string]map[int64]map[string]interface{}
+
+// BuildRowChangeDiff compares two row images, ignoring bookkeeping columns.
+// Args:
+//   before: Row before the change, nil for a created row.
+//   after: Row after the change, nil for a removed row.
+// Returns:
+//   []DiffItem: Field-level differences; empty values of created or removed rows are omitted.
+func BuildRowChangeDiff(before, after map[string]interface{}) []DiffItem {
+  diff := BuildPayloadDiff(stripDraftChangeColumns(before), stripDraftChangeColumns(after))
+  if before != nil && after != nil {
+    return diff
+  }
+  filtered := make([]DiffItem, 0, len(diff))
+  for _, item := range diff {
+    if item.Old == nil && item.New == nil {
+      continue
+    }
+    filtered = append(filtered, item)
+  }
+  return filtered
+}
+
+// draftActorID returns the JWT user id of the request, 0 when anonymous.
+func draftActorID(c *gin.Context) int64 {
+  claims, _ := middleware.GetAuthClaims(c)
+  if claims == nil {
+    return 0
+  }
+  return claims.UserID
+}
+
+// recordDraftChangeTx writes field history and an audit row for a direct draft edit.
+// Args:
+//   tx: Transaction of the edit.
+//   table: Table name.
+//   entityID: Row id.
+//   action: Audit action such as create, update, delete, trash, restore or purge.
+//   actorID: Operator id.
+//   before: Row image before the change, nil for create.
+//   after: Row image after the change, nil for delete and purge.
+//   detail: Extra audit detail, may be nil.
+//   now: Change time.
+// Returns:
+//   error: Error when insert fails.
+func recordDraftChangeTx(tx sqlRowExecutor, table string, entityID int64, action string, actorID int64, before, after map[string]interface{}, detail map[string]interface{}, now time.Time) error {
+  draftVersionID := draftChangeVersionID(table, entityID, before, after)
+  diff := BuildRowChangeDiff(before, after)
+  fields := make([]string, 0, len(diff))
+  for _, item := range diff {
+    if _, err := tx.Exec(
+      "INSERT INTO app_db_field_history (draft_version_id, entity_table, entity_id, field_name, old_value, new_value, action, submit_id, changed_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+      draftVersionID,
+      table,
+      entityID,
+      item.Field,
+      jsonValue(item.Old),
+      jsonValue(item.New),
+      action,
+      nil,
+      nullableID(actorID),
+      now,
+    ); err != nil {
+      return err
+    }
+    fields = append(fields, item.Field)
+  }
+
+  payload := map[string]interface{}{}
+  for key, value := range detail {
+    payload[key] = value
+  }
+  payload["fields"] = fields
+  if after != nil {
+    payload["row_version"] = after[draftRevisionColumn]
+  }
+  if _, ok := draftChangeImageActions[action]; ok && before != nil {
+    payload["before"] = before
+  }
+  raw, err := json.Marshal(payload)
+  if err != nil {
+    return err
+  }
+  if err := insertAuditLog(
+    tx,
+    nullableID(draftVersionID),
+    table,
+    entityID,
+    action,
+    nullableID(actorID),
+    string(raw),
+    now,
+  ); err != nil {
+    return err
+  }
+  // Purging an already trashed row or an update without field changes leaves the reviewed content as it was.
+  if action == "purge" || (action == "update" && len(fields) == 0) {
+    return nil
+  }
+  return resetDraftApprovalsTx(tx, draftVersionID, actorID, now)
+}
+
+// lockDraftEntityRow reads a row image inside a transaction, locking it for the edit.
+// Args:
+//   tx: Transaction.
+//   table: Table name.
+//   id: Row id.
+// Returns:
+//   map[string]interface{}: Row image, nil when missing.
+//   error: Error when query fails.
+func lockDraftEntityRow(tx sqlQueryer, table string, id int64) (map[string]interface{}, error) {
+  items, err := queryDraftEntityRows(tx, "SELECT * FROM "+quoteSQLIdent(table)+" WHERE id = ? FOR UPDATE", id)
+  if err != nil || len(items) == 0 {
+    return nil, err
+  }
+  return items[0], nil
+}
+
+// snapshotDraftTx reads the version row and every module row of a draft, including trashed rows.
+// Args:
+//   tx: Transaction.
+//   draftVersionID: Draft version id.
+// Returns:
+//   draftSnapshot: Row images.
+//   error: Error when query fails.
+func snapshotDraftTx(tx sqlQueryer, draftVersionID int64) (draftSnapshot, error) {
+  snapshot := make(draftSnapshot, len(draftCloneModules)+1)
+  queries := map[string]string{
+    "app_db_version_names": "SELECT * FROM app_db_version_names WHERE id = ? FOR UPDATE",
+  }
+  for _, module := range draftCloneModules {
+    queries[module.table] = "SELECT * FROM " + module.table + " WHERE draft_version_id = ? FOR UPDATE"
+  }
+  for table, query := range queries {
+    rows, err := queryDraftEntityRows(tx, query, draftVersionID)
+    if err != nil {
+      return nil, err
+    }
+    images := make(map[int64]map[string]interface{}, len(rows))
+    for _, row := range rows {
+      images[parseID(row["id"])] = row
+    }
+    snapshot[table] = images
+  }
+  return snapshot, nil
+}
+
+// recordDraftSnapshotChangesTx records every row that differs between two snapshots of a draft.
+// Args:
+//   tx: Transaction.
+//   before: Snapshot taken before the change.
+//   after: Snapshot taken after the change.
+//   actorID: Operator id.
+//   detail: Audit detail naming the operation that caused the change.
+//   now: Change time.
+// Returns:
+//   error: Error when insert fails.
+func recordDraftSnapshotChangesTx(tx sqlRowExecutor, before, after draftSnapshot, actorID int64, detail map[string]interface{}, now time.Time) error {
+  for _, table := range draftSnapshotTables() {
+    previous, current := before[table], after[table]
+    for _, id := range sortedDraftSnapshotIDs(previous, current) {
+      oldRow, hadRow := previous[id]
+      newRow, hasRow := current[id]
+      action := "update"
+      switch {
+      case !hadRow:
+        action = "create"
+      case !hasRow:
+        action = "delete"
+      case oldRow["deleted_at"] == nil && newRow["deleted_at"] != nil:
+        action = "trash"
+      case oldRow["deleted_at"] != nil && newRow["deleted_at"] == nil:
+        action = "restore"
+      case len(BuildRowChangeDiff(oldRow, newRow)) == 0:
+        continue
+      }
+      if err := recordDraftChangeTx(tx, table, id, action, actorID, oldRow, newRow, detail, now); err != nil {
+        return err
+      }
+    }
+  }
+  return nil
+}
+
+// omitDraftSnapshotRows drops rows from both snapshots when another path, such as a submission, already recorded them.
+// Args:
+//   before: Snapshot taken before the change.
+//   after: Snapshot taken after the change.
+//   table: Table name.
+//   ids: Row ids to drop.
+// Returns:
+//   None.
+func omitDraftSnapshotRows(before, after draftSnapshot, table string, ids []int64) {
+  for _, id := range ids {
+    delete(before[table], id)
+    delete(after[table], id)
+  }
+}
+
+func draftSnapshotTables() []string {
+  tables := []string{"app_db_version_names"}
+  for _, module := range draftCloneModules {
+    tables = append(tables, module.table)
+  }
+  return tables
+}
+
+func sortedDraftSnapshotIDs(before, after map[int64]map[string]interface{}) []int64 {
+  seen := make(map[int64]struct{}, len(before)+len(after))
+  ids := make([]int64, 0, len(before)+len(after))
+  for _, rows := range []map[int64]map[string]interface{}{before, after} {
+    for id := range rows {
+      if _, ok := seen[id]; ok {
+        continue
+      }
+      seen[id] = struct{}{}
+      ids = append(ids, id)
+    }
+  }
+  sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+  return ids
+}
+
+func draftChangeVersionID(table string, entityID int64, before, after map[string]interface{}) int64 {
+  if table == "app_db_version_names" {
+    return entityID
+  }
+  for _, row := range []map[string]interface{}{after, before} {
+    if row == nil {
+      continue
+    }
+    if id := parseID(row["draft_version_id"]); id > 0 {
+      return id
+    }
+  }
+  return 0
+}
+
+func stripDraftChangeColumns(row map[string]interface{}) map[string]interface{} {
+  if row == nil {
+    return nil
+  }
+  stripped := make(map[string]interface{}, len(row))
+  for key, value := range row {
+    if _, ok := draftChangeSkipColumns[key]; ok {
+      continue
+    }
+    stripped[key] = value
+  }
+  return stripped
+}
@@ -0,0 +1,484 @@
+package handlers
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "net/http"
+  "os"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+type DraftCloneHandler struct {
+  cfg *config.Config
+  db  *sql.DB
+}
+
+type cloneVersionRequest struct {
+  AppVersionName string   `json:"app_version_name"`
+  LocationName   *string  `json:"location_name"`
+  Modules        []string `json:"modules"`
+}
+
+type draftCloneModule struct {
+  key     string
+  table   string
+  columns []string
+  media   []string
+  // byNameID marks tables keyed by app_version_name_id instead of app_version_name.
+  byNameID bool
+}
+
+var draftCloneModules = []draftCloneModule{
+  {
+    key:      "app_ui_fields",
+    table:    "app_db_app_ui_fields",
+    columns:  []string{"home_title_left", "home_title_right", "home_subtitle", "start_experience", "step1_music", "step1_music_text", "step1_title", "step2_music", "step2_music_text", "step2_title", "status", "print_wait"},
+    media:    []string{"step1_music", "step2_music"},
+    byNameID: true,
+  },
+  {
+    key:     "banners",
+    table:   "app_db_banners",
+    columns: []string{"title", "image", "sort", "is_active", "type"},
+    media:   []string{"image"},
+  },
+  {
+    key:     "identities",
+    table:   "app_db_identities",
+    columns: []string{"name", "image", "sort", "status"},
+    media:   []string{"image"},
+  },
+  {
+    key:     "scenes",
+    table:   "app_db_scenes",
+    columns: []string{"name", "image", "`desc`", "music", "watermark_path", "need_watermark", "sort", "status", "oss_style"},
+    media:   []string{"image", "music", "watermark_path"},
+  },
+  {
+    key:     "clothes_categories",
+    table:   "app_db_clothes_categories",
+    columns: []string{"name", "image", "sort", "status", "music", "`desc`", "music_text"},
+    media:   []string{"image", "music"},
+  },
+  {
+    key:     "photo_hobbies",
+    table:   "app_db_photo_hobbies",
+    columns: []string{"name", "image", "sort", "status", "music", "music_text", "`desc`"},
+    media:   []string{"image", "music"},
+  },
+  {
+    key:      "config_extra_steps",
+    table:    "app_db_config_extra_steps",
+    columns:  []string{"step_index", "field_name", "label", "music", "music_text", "status"},
+    media:    []string{"music"},
+    byNameID: true,
+  },
+}
+
+// NewDraftCloneHandler creates a handler for draft version cloning.
+// Args:
+//   cfg: App config instance.
+//   db: Database connection.
+// Returns:
+//   *DraftCloneHandler: Initialized handler.
+func NewDraftCloneHandler(cfg *config.Config, db *sql.DB) *DraftCloneHandler {
+  return &DraftCloneHandler{cfg: cfg, db: db}
+}
+
+// BuildCloneVersionName picks a free version name for a clone of source.
+// Args:
+//   source: Source version name.
+//   existing: Version names already in use.
+// Returns:
+//   string: Normalized clone name such as SOURCE_COPY or SOURCE_COPY2.
+func BuildCloneVersionName(source string, existing []string) string {
+  used := make(map[string]struct{}, len(existing))
+  for _, name := range existing {
+    used[NormalizeVersionName(name)] = struct{}{}
+  }
+  base := NormalizeVersionName(source)
+  if base == "" {
+    base = "DRAFT"
+  }
+  candidate := base + "_COPY"
+  for index := 2; ; index++ {
+    if _, ok := used[candidate]; !ok {
+      return candidate
+    }
+    candidate = fmt.Sprintf("%s_COPY%d", base, index)
+  }
+}
+
+// Clone copies a draft version and its modules into a new draft version.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCloneHandler) Clone(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  sourceID := parseInt64Param(c, "id")
+  if sourceID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+
+  var req cloneVersionRequest
+  if c.Request.ContentLength != 0 {
+    if err := c.ShouldBindJSON(&req); err != nil {
+      c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+      return
+    }
+  }
+  modules, invalid := resolveCloneModules(req.Modules)
+  if len(invalid) > 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_modules", "modules": invalid})
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  var (
+    sourceName   sql.NullString
+    locationName sql.NullString
+    feishuFields sql.NullString
+    aiModal      sql.NullString
+    status       sql.NullInt64
+  )
+  err := h.db.QueryRow(
+    "SELECT app_version_name, location_name, feishu_field_names, ai_modal, status FROM app_db_version_names WHERE id = ?",
+    sourceID,
+  ).Scan(&sourceName, &locationName, &feishuFields, &aiModal, &status)
+  if err == sql.ErrNoRows {
+    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if req.LocationName != nil {
+    locationName = sql.NullString{String: strings.TrimSpace(*req.LocationName), Valid: true}
+  }
+
+  existing, err := loadDraftVersionNames(h.db)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  versionName := NormalizeVersionName(req.AppVersionName)
+  if versionName != "" {
+    for _, name := range existing {
+      if NormalizeVersionName(name) == versionName {
+        c.JSON(http.StatusConflict, gin.H{"error": "app_version_name already exists"})
+        return
+      }
+    }
+  } else {
+    versionName = BuildCloneVersionName(nullableStringValue(sourceName), existing)
+  }
+
+  now := time.Now()
+  copied := make([]string, 0)
+  committed := false
+  defer func() {
+    if !committed {
+      removeCopiedDraftMedia(h.cfg, copied)
+    }
+  }()
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  result, err := tx.Exec(
+    "INSERT INTO app_db_version_names (app_version_name, location_name, feishu_field_names, ai_modal, status, draft_status, submit_version, cloned_from_id, cloned_by, cloned_at, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+    versionName,
+    nullIfEmpty(nullableStringValue(locationName)),
+    nullIfEmpty(nullableStringValue(feishuFields)),
+    nullIfEmpty(nullableStringValue(aiModal)),
+    nullableInt(status),
+    "draft",
+    0,
+    sourceID,
+    nullableID(operatorID),
+    now,
+    nullableID(operatorID),
+    nullableID(operatorID),
+    now,
+    now,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+  cloneID, err := result.LastInsertId()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+
+  counts := make(map[string]int, len(modules))
+  missing := make([]string, 0)
+  for _, module := range modules {
+    count, moduleCopied, moduleMissing, err := h.cloneModuleTx(tx, module, sourceID, cloneID, versionName, operatorID, now)
+    copied = append(copied, moduleCopied...)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "clone failed", "module": module.key})
+      return
+    }
+    counts[module.key] = count
+    missing = append(missing, moduleMissing...)
+  }
+
+  moduleKeys := make([]string, 0, len(modules))
+  for _, module := range modules {
+    moduleKeys = append(moduleKeys, module.key)
+  }
+  detail, _ := json.Marshal(map[string]interface{}{
+    "source_version_id": sourceID,
+    "app_version_name":  versionName,
+    "modules":           moduleKeys,
+    "counts":            counts,
+    "copied_files":      len(copied),
+    "missing_files":     missing,
+  })
+  if err := insertAuditLog(
+    tx,
+    cloneID,
+    "app_db_version_names",
+    cloneID,
+    "clone",
+    nullableID(operatorID),
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+  committed = true
+
+  c.JSON(http.StatusOK, gin.H{
+    "id":               cloneID,
+    "app_version_name": versionName,
+    "cloned_from_id":   sourceID,
+    "modules":          counts,
+    "copied_files":     len(copied),
+    "missing_files":    missing,
+  })
+}
+
+// cloneModuleTx copies one module's rows and duplicates their local media files.
+// Args:
+//   tx: Active transaction.
+//   module: Module definition.
+//   sourceID: Source draft version id.
+//   cloneID: New draft version id.
+//   versionName: New version name.
+//   operatorID: Operator user id.
+//   now: Current time.
+// Returns:
+//   int: Copied row count.
+//   []string: Stored paths of copied files.
+//   []string: Local paths whose source file is missing and kept as-is.
+//   error: Error when copy fails.
+func (h *DraftCloneHandler) cloneModuleTx(tx *sql.Tx, module draftCloneModule, sourceID, cloneID int64, versionName string, operatorID int64, now time.Time) (int, []string, []string, error) {
+  copied := make([]string, 0)
+  missing := make([]string, 0)
+
+  items, err := loadDraftModuleRows(tx, module, sourceID)
+  if err != nil {
+    return 0, copied, missing, err
+  }
+  mediaIndex := draftModuleMediaIndex(module)
+
+  for _, values := range items {
+    args := make([]interface{}, 0, len(values))
+    for i, value := range values {
+      if !value.Valid {
+        args = append(args, nil)
+        continue
+      }
+      if _, ok := mediaIndex[i]; ok && isLocalPath(value.String) {
+        path, err := copyLocalMedia(h.cfg, module.key, cloneID, value.String)
+        if err != nil {
+          if !errors.Is(err, os.ErrNotExist) {
+            return 0, copied, missing, err
+          }
+          missing = append(missing, value.String)
+          path = value.String
+        } else {
+          copied = append(copied, path)
+        }
+        args = append(args, path)
+        continue
+      }
+      args = append(args, value.String)
+    }
+    if err := insertDraftModuleRowTx(tx, module, cloneID, versionName, args, operatorID, now); err != nil {
+      return 0, copied, missing, err
+    }
+  }
+  return len(items), copied, missing, nil
+}
+
+type sqlQueryer interface {
+  Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// loadDraftModuleRows reads the content columns of one module of a draft.
+// Args:
+//   db: Database or transaction.
+//   module: Module definition.
+//   draftVersionID: Draft version id.
+// Returns:
+//   [][]sql.NullString: Row values in module column order.
+//   error: Error when query fails.
+func loadDraftModuleRows(db sqlQueryer, module draftCloneModule, draftVersionID int64) ([][]sql.NullString, error) {
+  rows, err := db.Query(
+    "SELECT "+strings.Join(module.columns, ", ")+" FROM "+module.table+" WHERE draft_version_id = ?"+draftTrashFilter(module.table)+" ORDER BY id ASC",
+    draftVersionID,
+  )
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+  items := make([][]sql.NullString, 0)
+  for rows.Next() {
+    values := make([]sql.NullString, len(module.columns))
+    targets := make([]interface{}, len(values))
+    for i := range values {
+      targets[i] = &values[i]
+    }
+    if err := rows.Scan(targets...); err != nil {
+      return nil, err
+    }
+    items = append(items, values)
+  }
+  return items, rows.Err()
+}
+
+// insertDraftModuleRowTx inserts one module row into a draft.
+// Args:
+//   tx: Active transaction.
+//   module: Module definition.
+//   draftVersionID: Draft version id.
+//   versionName: Draft version name.
+//   values: Values in module column order.
+//   operatorID: Operator user id.
+//   now: Current time.
+// Returns:
+//   error: Error when insert fails.
+func insertDraftModuleRowTx(tx *sql.Tx, module draftCloneModule, draftVersionID int64, versionName string, values []interface{}, operatorID int64, now time.Time) error {
+  columns := append([]string{"draft_version_id"}, module.columns...)
+  args := append([]interface{}{draftVersionID}, values...)
+  if module.byNameID {
+    columns = append(columns, "app_version_name_id")
+    args = append(args, draftVersionID)
+  } else {
+    columns = append(columns, "app_version_name")
+    args = append(args, versionName)
+  }
+  columns = append(columns, "created_by", "updated_by", "created_at", "updated_at")
+  args = append(args, nullableID(operatorID), nullableID(operatorID), now, now)
+  placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+  _, err := tx.Exec("INSERT INTO "+module.table+" ("+strings.Join(columns, ", ")+") VALUES ("+placeholders+")", args...)
+  return err
+}
+
+func draftModuleMediaIndex(module draftCloneModule) map[int]struct{} {
+  index := make(map[int]struct{}, len(module.media))
+  for i, column := range module.columns {
+    for _, media := range module.media {
+      if column == media {
+        index[i] = struct{}{}
+      }
+    }
+  }
+  return index
+}
+
+func loadDraftVersionNames(db *sql.DB) ([]string, error) {
+  rows, err := db.Query("SELECT app_version_name FROM app_db_version_names WHERE app_version_name IS NOT NULL")
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+  names := make([]string, 0)
+  for rows.Next() {
+    var name string
+    if err := rows.Scan(&name); err != nil {
+      return nil, err
+    }
+    names = append(names, name)
+  }
+  return names, rows.Err()
+}
+
+func removeCopiedDraftMedia(cfg *config.Config, paths []string) {
+  for _, path := range paths {
+    absPath, err := buildLocalFilePath(cfg, trimLocalPrefix(path))
+    if err != nil {
+      continue
+    }
+    _ = os.Remove(absPath)
+  }
+}
+
+func resolveCloneModules(requested []string) ([]draftCloneModule, []string) {
+  selected := make(map[string]struct{}, len(requested))
+  invalid := make([]string, 0)
+  for _, raw := range requested {
+    key := strings.ToLower(strings.TrimSpace(raw))
+    if key == "" {
+      continue
+    }
+    known := false
+    for _, module := range draftCloneModules {
+      if module.key == key {
+        known = true
+        break
+      }
+    }
+    if !known {
+      invalid = append(invalid, key)
+      continue
+    }
+    selected[key] = struct{}{}
+  }
+  if len(selected) == 0 {
+    return draftCloneModules, invalid
+  }
+  modules := make([]draftCloneModule, 0, len(selected))
+  for _, module := range draftCloneModules {
+    if _, ok := selected[module.key]; ok {
+      modules = append(modules, module)
+    }
+  }
+  return modules, invalid
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+  "database/sql"
+  "net/http"
+  "strings"
+
+  "github.com/gin-gonic/gin"
+)
+
+var draftCompareMediaColumns = map[string]struct{}{
+  "image":          {},
+  "music":          {},
+  "watermark_path": {},
+  "step1_music":    {},
+  "step2_music":    {},
+}
+
+// DraftCompareRow is one added, removed or changed row between two drafts.
+type DraftCompareRow struct {
+  Key     string     `json:"key"`
+  Action  string     `json:"action"`
+  LeftID  int64      `json:"left_id,omitempty"`
+  RightID int64      `json:"right_id,omitempty"`
+  Diff    []DiffItem `json:"diff"`
+}
+
+// DraftCompareModule is the comparison result of one module.
+type DraftCompareModule struct {
+  ModuleKey string            `json:"module_key"`
+  Added     int               `json:"added"`
+  Removed   int               `json:"removed"`
+  Changed   int               `json:"changed"`
+  Unchanged int               `json:"unchanged"`
+  Rows      []DraftCompareRow `json:"rows"`
+}
+
+// CompareDraftModule matches rows of two drafts by key and reports field-level changes.
+// Args:
+//   moduleKey: Module key.
+//   columns: Column names matching the row values.
+//   left: Rows of the left draft.
+//   right: Rows of the right draft.
+//   mediaHashes: Known file hashes keyed by stored path; media fields with equal hashes are treated as equal.
+// Returns:
+//   DraftCompareModule: Module comparison result.
+func CompareDraftModule(moduleKey string, columns []string, left, right []SyncDiffRow, mediaHashes map[string]string) DraftCompareModule {
+  names := make([]string, len(columns))
+  for i, column := range columns {
+    names[i] = strings.Trim(column, "`")
+  }
+  toPayload := func(row SyncDiffRow) map[string]interface{} {
+    payload := make(map[string]interface{}, len(names))
+    for i, name := range names {
+      var value sql.NullString
+      if i < len(row.Values) {
+        value = row.Values[i]
+      }
+      payload[name] = syncMergeDraftValue(moduleKey, name, value)
+    }
+    return payload
+  }
+
+  result := DraftCompareModule{ModuleKey: moduleKey, Rows: make([]DraftCompareRow, 0)}
+  byKey := make(map[string][]int)
+  for i, row := range right {
+    byKey[row.Key] = append(byKey[row.Key], i)
+  }
+  claimed := make([]bool, len(right))
+
+  for _, row := range left {
+    match := -1
+    for _, idx := range byKey[row.Key] {
+      if !claimed[idx] {
+        match = idx
+        break
+      }
+    }
+    if match < 0 {
+      result.Removed++
+      result.Rows = append(result.Rows, DraftCompareRow{Key: row.Key, Action: "removed", LeftID: row.DraftID, Diff: BuildPayloadDiff(toPayload(row), nil)})
+      continue
+    }
+    claimed[match] = true
+    prev := toPayload(row)
+    curr := toPayload(right[match])
+    for i, name := range names {
+      if _, ok := draftCompareMediaColumns[name]; !ok {
+        continue
+      }
+      if i >= len(row.Values) || i >= len(right[match].Values) {
+        continue
+      }
+      leftHash := mediaHashes[row.Values[i].String]
+      rightHash := mediaHashes[right[match].Values[i].String]
+      if leftHash != "" && leftHash == rightHash {
+        curr[name] = prev[name]
+      }
+    }
+    diff := BuildPayloadDiff(prev, curr)
+    if len(diff) == 0 {
+      result.Unchanged++
+      continue
+    }
+    result.Changed++
+    result.Rows = append(result.Rows, DraftCompareRow{Key: row.Key, Action: "changed", LeftID: row.DraftID, RightID: right[match].DraftID, Diff: diff})
+  }
+
+  for i, row := range right {
+    if claimed[i] {
+      continue
+    }
+    result.Added++
+    result.Rows = append(result.Rows, DraftCompareRow{Key: row.Key, Action: "added", RightID: row.DraftID, Diff: BuildPayloadDiff(nil, toPayload(row))})
+  }
+  return result
+}
+
+// Compare returns a module-by-module diff between two draft versions.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) Compare(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  leftID := parseInt64Query(c, "left")
+  rightID := parseInt64Query(c, "right")
+  if leftID <= 0 || rightID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "left and right are required"})
+    return
+  }
+
+  versions := make(map[int64]draftVersionRow, 2)
+  for _, id := range []int64{leftID, rightID} {
+    version, err := loadDraftVersion(h.db, id)
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found", "draft_version_id": id})
+      return
+    }
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    versions[id] = version
+  }
+
+  leftData, err := loadDraftData(h.db, leftID, 0)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  rightData, err := loadDraftData(h.db, rightID, 0)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  mediaHashes, err := h.loadMediaHashes(leftID, rightID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  leftVersion := versions[leftID]
+  rightVersion := versions[rightID]
+  versionDiff := BuildPayloadDiff(
+    map[string]interface{}{
+      "location_name":      nullableString(leftVersion.LocationName),
+      "feishu_field_names": nullableString(leftVersion.FeishuFieldNames),
+      "ai_modal":           nullableString(leftVersion.AiModal),
+      "status":             nullableInt(leftVersion.Status),
+    },
+    map[string]interface{}{
+      "location_name":      nullableString(rightVersion.LocationName),
+      "feishu_field_names": nullableString(rightVersion.FeishuFieldNames),
+      "ai_modal":           nullableString(rightVersion.AiModal),
+      "status":             nullableInt(rightVersion.Status),
+    },
+  )
+
+  modules := make([]DraftCompareModule, 0, len(syncRowModules))
+  changed := false
+  for _, moduleKey := range syncRowModules {
+    module := CompareDraftModule(
+      moduleKey,
+      syncTableSpecs[moduleKey].Columns,
+      buildSyncDesiredRows(moduleKey, leftData),
+      buildSyncDesiredRows(moduleKey, rightData),
+      mediaHashes,
+    )
+    if module.Added > 0 || module.Removed > 0 || module.Changed > 0 {
+      changed = true
+    }
+    modules = append(modules, module)
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "left":    gin.H{"id": leftID, "app_version_name": nullableString(leftVersion.AppVersionName)},
+    "right":   gin.H{"id": rightID, "app_version_name": nullableString(rightVersion.AppVersionName)},
+    "changed": changed || len(versionDiff) > 0,
+    "version": versionDiff,
+    "modules": modules,
+  })
+}
+
+// loadMediaHashes returns known file hashes of media assets in the given drafts.
+// Args:
+//   draftVersionIDs: Draft version ids.
+// Returns:
+//   map[string]string: Hash keyed by stored path.
+//   error: Error when query fails.
+func (h *DraftCRUDHandler) loadMediaHashes(draftVersionIDs ...int64) (map[string]string, error) {
+  hashes := make(map[string]string)
+  for _, draftVersionID := range draftVersionIDs {
+    rows, err := h.db.Query(
+      "SELECT file_url, hash FROM app_db_media_assets WHERE draft_version_id = ? AND hash IS NOT NULL AND hash <> ''",
+      draftVersionID,
+    )
+    if err != nil {
+      return nil, err
+    }
+    for rows.Next() {
+      var fileURL, hash sql.NullString
+      if err := rows.Scan(&fileURL, &hash); err != nil {
+        _ = rows.Close()
+        return nil, err
+      }
+      if fileURL.Valid && fileURL.String != "" {
+        hashes[fileURL.String] = hash.String
+      }
+    }
+    _ = rows.Close()
+    if err := rows.Err(); err != nil {
+      return nil, err
+    }
+  }
+  return hashes, nil
+}
@@ -12,6 +12,8 @@ import (
   "time"
 
   "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
 )
 
 type DraftCRUDHandler struct {
@@ -32,7 +34,6 @@ var (
     "feishu_field_names",
     "ai_modal",
     "status",
-    "draft_status",
     "submit_version",
     "last_submit_by",
     "last_submit_at",
@@ -176,7 +177,7 @@ func (h *DraftCRUDHandler) ListVersionNames(c *gin.Context) {
   }
 
   rows, err := h.db.Query(
-    "SELECT id, app_version_name, location_name, feishu_field_names, ai_modal, status, draft_status, submit_version, last_submit_by, last_submit_at, confirmed_by, confirmed_at FROM app_db_version_names ORDER BY id DESC",
+    "SELECT v.id, v.app_version_name, v.location_name, v.feishu_field_names, v.ai_modal, v.status, v.draft_status, v.draft_status_changed_by, v.draft_status_changed_at, v.submit_version, v.last_submit_by, v.last_submit_at, v.confirmed_by, v.confirmed_at, v.cloned_from_id, v.row_version, (SELECT COUNT(DISTINCT d.module_key) FROM app_db_sync_drift_reports d WHERE d.draft_version_id = v.id AND d.drifted = 1) AS drifted_modules FROM app_db_version_names v ORDER BY v.id DESC",
   )
   if err != nil {
     c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
@@ -184,6 +185,11 @@ func (h *DraftCRUDHandler) ListVersionNames(c *gin.Context) {
   }
   defer rows.Close()
 
+  role := ""
+  if claims, ok := middleware.GetAuthClaims(c); ok {
+    role = claims.Role
+  }
+
   items := make([]gin.H, 0)
   for rows.Next() {
     var (
@@ -194,19 +200,25 @@ func (h *DraftCRUDHandler) ListVersionNames(c *gin.Context) {
       aiModal        sql.NullString
       status         sql.NullInt64
       draftStatus    sql.NullString
+      changedBy      sql.NullInt64
+      changedAt      sql.NullTime
       submitVersion  sql.NullInt64
       lastSubmitBy   sql.NullInt64
       lastSubmitAt   sql.NullTime
       confirmedBy    sql.NullInt64
       confirmedAt    sql.NullTime
+      clonedFromID   sql.NullInt64
+      rowVersion     int64
+      driftedModules int64
     )
 
-    if err := rows.Scan(&id, &versionName, &locationName, &feishuFields, &aiModal, &status, &draftStatus, &submitVersion, &lastSubmitBy, &lastSubmitAt, &confirmedBy, &confirmedAt); err != nil {
+    if err := rows.Scan(&id, &versionName, &locationName, &feishuFields, &aiModal, &status, &draftStatus, &changedBy, &changedAt, &submitVersion, &lastSubmitBy, &lastSubmitAt, &confirmedBy, &confirmedAt, &clonedFromID, &rowVersion, &driftedModules); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
 
     feishuList := parseFeishuFieldList(feishuFields)
+    lifecycle := NormalizeDraftStatus(draftStatus.String)
 
     items = append(items, gin.H{
       "id":               id,
@@ -216,12 +228,19 @@ func (h *DraftCRUDHandler) ListVersionNames(c *gin.Context) {
       "feishu_field_list": feishuList,
       "ai_modal":         nullableString(aiModal),
       "status":           nullableInt(status),
-      "draft_status":     nullableString(draftStatus),
+      "draft_status":     lifecycle,
+      "draft_status_changed_by": nullableInt(changedBy),
+      "draft_status_changed_at": nullableTimePointer(changedAt),
+      "draft_transitions": DraftTransitionTargets(lifecycle, role),
       "submit_version":   nullableInt(submitVersion),
       "last_submit_by":   nullableInt(lastSubmitBy),
       "last_submit_at":   nullableTimePointer(lastSubmitAt),
       "confirmed_by":     nullableInt(confirmedBy),
       "confirmed_at":     nullableTimePointer(confirmedAt),
+      "cloned_from_id":   nullableInt64Pointer(clonedFromID),
+      "row_version":      rowVersion,
+      "drifted":          driftedModules > 0,
+      "drifted_modules":  driftedModules,
     })
   }
 
@@ -277,20 +296,7 @@ func (h *DraftCRUDHandler) CreateVersionName(c *gin.Context) {
 
   applyTimestamps(filtered, true)
 
-  sqlText, args, err := BuildInsertSQL("app_db_version_names", filtered)
-  if err != nil {
-    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-    return
-  }
-
-  result, err := h.db.Exec(sqlText, args...)
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
-    return
-  }
-
-  id, _ := result.LastInsertId()
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  h.insertEntity(c, "app_db_version_names", 0, filtered)
 }
 
 // UpdateVersionName updates a draft version name.
@@ -347,27 +353,13 @@ func (h *DraftCRUDHandler) UpdateVersionName(c *gin.Context) {
     }
   }
 
-  applyTimestamps(filtered, false)
-
-  sqlText, args, err := BuildUpdateSQL("app_db_version_names", "id", id, filtered)
-  if err != nil {
-    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+  revision, ok := requireRevision(c, payload)
+  if !ok {
     return
   }
 
-  result, err := h.db.Exec(sqlText, args...)
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
-    return
-  }
-
-  rows, err := result.RowsAffected()
-  if err != nil || rows == 0 {
-    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-    return
-  }
-
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  applyTimestamps(filtered, false)
+  h.updateRevisioned(c, "app_db_version_names", "id", id, revision, filtered)
 }
 
 // DeleteVersionName deletes a draft version name.
@@ -376,6 +368,13 @@ func (h *DraftCRUDHandler) UpdateVersionName(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteVersionName(c *gin.Context) {
+  if h.db != nil {
+    status, err := loadDraftStatus(h.db, parseInt64Param(c, "id"))
+    if err == nil && status == DraftStatusSyncing {
+      c.JSON(http.StatusConflict, gin.H{"error": "draft_locked", "draft_status": status})
+      return
+    }
+  }
   h.deleteEntity(c, "app_db_version_names", "id")
 }
 
@@ -403,7 +402,7 @@ func (h *DraftCRUDHandler) UpdateBanner(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteBanner(c *gin.Context) {
-  h.deleteEntity(c, "app_db_banners", "id")
+  h.trashEntity(c, "app_db_banners")
 }
 
 // CreateIdentity creates a new identity.
@@ -430,7 +429,7 @@ func (h *DraftCRUDHandler) UpdateIdentity(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteIdentity(c *gin.Context) {
-  h.deleteEntity(c, "app_db_identities", "id")
+  h.trashEntity(c, "app_db_identities")
 }
 
 // CreateScene creates a new scene.
@@ -457,7 +456,7 @@ func (h *DraftCRUDHandler) UpdateScene(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteScene(c *gin.Context) {
-  h.deleteEntity(c, "app_db_scenes", "id")
+  h.trashEntity(c, "app_db_scenes")
 }
 
 // CreateClothesCategory creates a new clothes category.
@@ -484,7 +483,7 @@ func (h *DraftCRUDHandler) UpdateClothesCategory(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteClothesCategory(c *gin.Context) {
-  h.deleteEntity(c, "app_db_clothes_categories", "id")
+  h.trashEntity(c, "app_db_clothes_categories")
 }
 
 // CreatePhotoHobby creates a new photo hobby.
@@ -511,7 +510,7 @@ func (h *DraftCRUDHandler) UpdatePhotoHobby(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeletePhotoHobby(c *gin.Context) {
-  h.deleteEntity(c, "app_db_photo_hobbies", "id")
+  h.trashEntity(c, "app_db_photo_hobbies")
 }
 
 // CreateConfigExtraStep creates a new config extra step.
@@ -538,7 +537,7 @@ func (h *DraftCRUDHandler) UpdateConfigExtraStep(c *gin.Context) {
 // Returns:
 //   None.
 func (h *DraftCRUDHandler) DeleteConfigExtraStep(c *gin.Context) {
-  h.deleteEntity(c, "app_db_config_extra_steps", "id")
+  h.trashEntity(c, "app_db_config_extra_steps")
 }
 
 // UpsertAppUIFields creates or updates app ui fields by draft key.
@@ -582,32 +581,16 @@ func (h *DraftCRUDHandler) UpsertAppUIFields(c *gin.Context) {
   }
 
   if existingID > 0 {
-    filtered["updated_at"] = time.Now()
-    sqlText, args, err := BuildUpdateSQL("app_db_app_ui_fields", "id", existingID, filtered)
-    if err != nil {
-      c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-      return
-    }
-    if _, err := h.db.Exec(sqlText, args...); err != nil {
-      c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    revision, ok := requireRevision(c, payload)
+    if !ok {
       return
     }
-    c.JSON(http.StatusOK, gin.H{"id": existingID})
+    filtered["updated_at"] = time.Now()
+    h.updateRevisioned(c, "app_db_app_ui_fields", "id", existingID, revision, filtered)
     return
   }
 
-  sqlText, args, err := BuildInsertSQL("app_db_app_ui_fields", filtered)
-  if err != nil {
-    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-    return
-  }
-  result, err := h.db.Exec(sqlText, args...)
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
-    return
-  }
-  id, _ := result.LastInsertId()
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  h.insertEntity(c, "app_db_app_ui_fields", draftVersionID, filtered)
 }
 
 func (h *DraftCRUDHandler) createEntity(c *gin.Context, table string, allowed []string, mode DraftKeyMode) {
@@ -628,24 +611,41 @@ func (h *DraftCRUDHandler) createEntity(c *gin.Context, table string, allowed []
   }
 
   applyTimestamps(filtered, true)
+  h.insertEntity(c, table, resolvePayloadDraftVersionID(h.db, filtered), filtered)
+}
 
-  sqlText, args, err := BuildInsertSQL(table, filtered)
-  if err != nil {
-    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+func (h *DraftCRUDHandler) updateEntity(c *gin.Context, table string, allowed []string, idColumn string) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
     return
   }
 
-  result, err := h.db.Exec(sqlText, args...)
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+
+  payload, err := readPayload(c)
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
     return
   }
 
-  id, _ := result.LastInsertId()
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  filtered := FilterPayload(payload, allowed)
+  if len(filtered) == 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "empty payload"})
+    return
+  }
+  revision, ok := requireRevision(c, payload)
+  if !ok {
+    return
+  }
+
+  applyTimestamps(filtered, false)
+  h.updateRevisioned(c, table, idColumn, id, revision, filtered)
 }
 
-func (h *DraftCRUDHandler) updateEntity(c *gin.Context, table string, allowed []string, idColumn string) {
+func (h *DraftCRUDHandler) deleteEntity(c *gin.Context, table string, idColumn string) {
   if h.db == nil {
     c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
     return
@@ -657,65 +657,180 @@ func (h *DraftCRUDHandler) updateEntity(c *gin.Context, table string, allowed []
     return
   }
 
-  payload, err := readPayload(c)
+  revision, ok := requireRevision(c, nil)
+  if !ok {
+    return
+  }
+
+  tx, err := h.db.Begin()
   if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
     return
   }
+  defer func() {
+    _ = tx.Rollback()
+  }()
 
-  filtered := FilterPayload(payload, allowed)
-  if len(filtered) == 0 {
-    c.JSON(http.StatusBadRequest, gin.H{"error": "empty payload"})
+  before, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
     return
   }
 
-  applyTimestamps(filtered, false)
+  result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ? AND %s = ?", table, idColumn, draftRevisionColumn), id, revision)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
 
-  sqlText, args, err := BuildUpdateSQL(table, idColumn, id, filtered)
+  rows, err := result.RowsAffected()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  if rows == 0 {
+    _ = tx.Rollback()
+    respondRevisionConflict(c, h.db, table, idColumn, id, nil)
+    return
+  }
+  if err := recordDraftChangeTx(tx, table, id, "delete", draftActorID(c), before, nil, nil, time.Now()); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"id": id})
+}
+
+// insertEntity inserts a row and records its creation in one transaction, holding the draft row lock.
+// Args:
+//   c: Gin context.
+//   table: Table name.
+//   draftVersionID: Draft owning the new row, 0 when none.
+//   filtered: Column values.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) insertEntity(c *gin.Context, table string, draftVersionID int64, filtered map[string]interface{}) {
+  sqlText, args, err := BuildInsertSQL(table, filtered)
   if err != nil {
     c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
     return
   }
 
-  result, err := h.db.Exec(sqlText, args...)
+  tx, err := h.db.Begin()
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
     return
   }
+  defer func() {
+    _ = tx.Rollback()
+  }()
 
-  rows, err := result.RowsAffected()
-  if err != nil || rows == 0 {
-    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+  if !requireDraftEditableTx(c, tx, draftVersionID) {
     return
   }
 
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  result, err := tx.Exec(sqlText, args...)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+  id, _ := result.LastInsertId()
+
+  after, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    return
+  }
+  if err := recordDraftChangeTx(tx, table, id, "create", draftActorID(c), nil, after, nil, time.Now()); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  setRevisionHeader(c, 1)
+  c.JSON(http.StatusOK, gin.H{"id": id, "row_version": 1})
 }
 
-func (h *DraftCRUDHandler) deleteEntity(c *gin.Context, table string, idColumn string) {
-  if h.db == nil {
-    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+// updateRevisioned applies a revision-checked update and records the changed fields in one transaction,
+// holding the draft row lock. Rows in the trash must be restored before they can be edited.
+// Args:
+//   c: Gin context.
+//   table: Table name.
+//   idColumn: ID column name.
+//   id: Row id.
+//   revision: Expected row_version.
+//   filtered: Column values.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) updateRevisioned(c *gin.Context, table, idColumn string, id, revision int64, filtered map[string]interface{}) {
+  sqlText, args, err := BuildRevisionedUpdateSQL(table, idColumn, id, revision, filtered)
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
     return
   }
 
-  id := parseInt64Param(c, "id")
-  if id <= 0 {
-    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  if !requireDraftRowEditable(c, tx, table, id) {
+    return
+  }
+  before, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  if DraftRowTrashed(table, before) {
+    c.JSON(http.StatusConflict, gin.H{"error": "entity is in trash"})
     return
   }
 
-  result, err := h.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, idColumn), id)
+  result, err := tx.Exec(sqlText, args...)
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
     return
   }
 
   rows, err := result.RowsAffected()
-  if err != nil || rows == 0 {
-    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  if rows == 0 {
+    _ = tx.Rollback()
+    respondRevisionConflict(c, h.db, table, idColumn, id, filtered)
     return
   }
 
-  c.JSON(http.StatusOK, gin.H{"id": id})
+  after, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  if err := recordDraftChangeTx(tx, table, id, "update", draftActorID(c), before, after, nil, time.Now()); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  setRevisionHeader(c, revision+1)
+  c.JSON(http.StatusOK, gin.H{"id": id, "row_version": revision + 1})
 }
 
 // FilterPayload keeps only allowed fields from payload.
@@ -805,22 +920,27 @@ func BuildUpdateSQL(table, idColumn string, id int64, payload map[string]interfa
     return "", nil, errors.New("empty payload")
   }
 
+  setParts, args := buildUpdateAssignments(payload)
+  args = append(args, id)
+
+  sqlText := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", quoteSQLIdent(table), strings.Join(setParts, ","), quoteSQLIdent(idColumn))
+  return sqlText, args, nil
+}
+
+func buildUpdateAssignments(payload map[string]interface{}) ([]string, []any) {
   keys := make([]string, 0, len(payload))
   for key := range payload {
     keys = append(keys, key)
   }
   sort.Strings(keys)
 
-  setParts := make([]string, 0, len(keys))
-  args := make([]any, 0, len(keys)+1)
+  setParts := make([]string, 0, len(keys)+1)
+  args := make([]any, 0, len(keys)+2)
   for _, key := range keys {
     setParts = append(setParts, quoteSQLIdent(key)+" = ?")
     args = append(args, payload[key])
   }
-  args = append(args, id)
-
-  sqlText := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?", quoteSQLIdent(table), strings.Join(setParts, ","), quoteSQLIdent(idColumn))
-  return sqlText, args, nil
+  return setParts, args
 }
 
 func quoteSQLIdent(value string) string {
@@ -0,0 +1,472 @@
+package handlers
+
+import (
+  "database/sql"
+  "encoding/json"
+  "errors"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const (
+  DraftStatusDraft     = "draft"
+  DraftStatusInReview  = "in_review"
+  DraftStatusApproved  = "approved"
+  DraftStatusSyncing   = "syncing"
+  DraftStatusPublished = "published"
+  DraftStatusArchived  = "archived"
+)
+
+var (
+  ErrDraftTransitionInvalid   = errors.New("transition not allowed")
+  ErrDraftTransitionForbidden = errors.New("admin role required")
+)
+
+type draftTransition struct {
+  from   string
+  to     string
+  action string
+  // adminOnly restricts the transition endpoint to admins.
+  adminOnly bool
+  // manual marks transitions reachable from the transition endpoint; the rest are driven by sync.
+  manual bool
+}
+
+var draftTransitions = []draftTransition{
+  {from: DraftStatusDraft, to: DraftStatusInReview, action: "submit_review", manual: true},
+  {from: DraftStatusInReview, to: DraftStatusDraft, action: "withdraw", manual: true},
+  {from: DraftStatusInReview, to: DraftStatusApproved, action: "approve", adminOnly: true, manual: true},
+  {from: DraftStatusApproved, to: DraftStatusDraft, action: "reopen", adminOnly: true, manual: true},
+  {from: DraftStatusApproved, to: DraftStatusSyncing, action: "sync"},
+  {from: DraftStatusSyncing, to: DraftStatusPublished, action: "publish"},
+  {from: DraftStatusSyncing, to: DraftStatusApproved, action: "abort_sync", adminOnly: true, manual: true},
+  {from: DraftStatusPublished, to: DraftStatusArchived, action: "archive", adminOnly: true, manual: true},
+  {from: DraftStatusPublished, to: DraftStatusDraft, action: "reopen", adminOnly: true, manual: true},
+  {from: DraftStatusArchived, to: DraftStatusDraft, action: "unarchive", adminOnly: true, manual: true},
+}
+
+type draftTransitionRequest struct {
+  To      string `json:"to"`
+  Comment string `json:"comment"`
+}
+
+// draftLifecycleError reports a write refused because of the draft status.
+type draftLifecycleError struct {
+  Status string
+}
+
+func (e *draftLifecycleError) Error() string {
+  return "draft_not_approved: draft_status is " + e.Status
+}
+
+type sqlRowQueryer interface {
+  QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// NormalizeDraftStatus maps stored values, including legacy submission statuses, onto lifecycle states.
+// Args:
+//   raw: Stored draft_status value.
+// Returns:
+//   string: Lifecycle state.
+func NormalizeDraftStatus(raw string) string {
+  switch strings.ToLower(strings.TrimSpace(raw)) {
+  case "", DraftStatusDraft:
+    return DraftStatusDraft
+  case DraftStatusInReview, "submitted", "pending_confirm":
+    return DraftStatusInReview
+  case DraftStatusApproved, "confirmed":
+    return DraftStatusApproved
+  case DraftStatusSyncing:
+    return DraftStatusSyncing
+  case DraftStatusPublished:
+    return DraftStatusPublished
+  case DraftStatusArchived:
+    return DraftStatusArchived
+  }
+  return strings.ToLower(strings.TrimSpace(raw))
+}
+
+// DraftStatusEditable reports whether draft content may be changed in the given state.
+// Args:
+//   status: Lifecycle state.
+// Returns:
+//   bool: True for draft and in_review.
+func DraftStatusEditable(status string) bool {
+  status = NormalizeDraftStatus(status)
+  return status == DraftStatusDraft || status == DraftStatusInReview
+}
+
+// CheckDraftTransition validates a transition requested through the transition endpoint.
+// Args:
+//   from: Current state.
+//   to: Requested state.
+//   role: Caller role.
+// Returns:
+//   string: Transition action name.
+//   error: ErrDraftTransitionInvalid or ErrDraftTransitionForbidden.
+func CheckDraftTransition(from, to, role string) (string, error) {
+  from = NormalizeDraftStatus(from)
+  to = NormalizeDraftStatus(to)
+  for _, transition := range draftTransitions {
+    if transition.from != from || transition.to != to || !transition.manual {
+      continue
+    }
+    if transition.adminOnly && !strings.EqualFold(strings.TrimSpace(role), "admin") {
+      return transition.action, ErrDraftTransitionForbidden
+    }
+    return transition.action, nil
+  }
+  return "", ErrDraftTransitionInvalid
+}
+
+// DraftTransitionTargets lists the states a caller can move a draft to.
+// Args:
+//   from: Current state.
+//   role: Caller role.
+// Returns:
+//   []gin.H: Allowed targets with their action names.
+func DraftTransitionTargets(from, role string) []gin.H {
+  targets := make([]gin.H, 0)
+  for _, transition := range draftTransitions {
+    if transition.from != NormalizeDraftStatus(from) || !transition.manual {
+      continue
+    }
+    if _, err := CheckDraftTransition(transition.from, transition.to, role); err != nil {
+      continue
+    }
+    targets = append(targets, gin.H{"to": transition.to, "action": transition.action})
+  }
+  return targets
+}
+
+// TransitionVersionName moves a draft version through its lifecycle.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) TransitionVersionName(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  var req draftTransitionRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+  if strings.TrimSpace(req.To) == "" {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "to is required"})
+    return
+  }
+  to := NormalizeDraftStatus(req.To)
+
+  claims, ok := middleware.GetAuthClaims(c)
+  if !ok {
+    c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+    return
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  var current sql.NullString
+  if err := tx.QueryRow("SELECT draft_status FROM app_db_version_names WHERE id = ? FOR UPDATE", id).Scan(&current); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  from := NormalizeDraftStatus(current.String)
+
+  action, err := CheckDraftTransition(from, to, claims.Role)
+  if errors.Is(err, ErrDraftTransitionForbidden) {
+    c.JSON(http.StatusForbidden, gin.H{"error": "forbidden", "draft_status": from, "to": to})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusConflict, gin.H{
+      "error":        "invalid_transition",
+      "draft_status": from,
+      "to":           to,
+      "allowed":      DraftTransitionTargets(from, claims.Role),
+    })
+    return
+  }
+
+  if to == DraftStatusApproved && from == DraftStatusInReview {
+    pending, err := countPendingConfirm(tx, id)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    if pending > 0 {
+      c.JSON(http.StatusConflict, gin.H{"error": "pending_confirm", "draft_status": from, "pending": pending})
+      return
+    }
+    evaluation, err := evaluateDraftApprovals(tx, id)
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    if !evaluation.Satisfied {
+      c.JSON(http.StatusConflict, gin.H{
+        "error":        "approval_required",
+        "draft_status": from,
+        "missing":      evaluation.Missing,
+        "rejected":     evaluation.Rejected,
+      })
+      return
+    }
+  }
+  if to == DraftStatusApproved && from == DraftStatusSyncing {
+    var active int64
+    if err := tx.QueryRow(
+      "SELECT COUNT(1) FROM app_db_sync_jobs WHERE draft_version_id = ? AND status IN (?, ?, ?)",
+      id,
+      syncJobQueued,
+      syncJobRunning,
+      syncJobRetrying,
+    ).Scan(&active); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    if active > 0 {
+      c.JSON(http.StatusConflict, gin.H{"error": "sync_in_progress", "draft_status": from})
+      return
+    }
+  }
+
+  now := time.Now()
+  detail := map[string]interface{}{}
+  if comment := strings.TrimSpace(req.Comment); comment != "" {
+    detail["comment"] = comment
+  }
+  changed, err := transitionDraftStatus(tx, id, from, to, action, claims.UserID, detail, now)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  if !changed {
+    c.JSON(http.StatusConflict, gin.H{"error": "invalid_transition", "draft_status": from, "to": to})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "id":           id,
+    "from":         from,
+    "draft_status": to,
+    "action":       action,
+    "transitions":  DraftTransitionTargets(to, claims.Role),
+  })
+}
+
+// transitionDraftStatus moves a draft from one state to another and audits the change.
+// Args:
+//   db: Transaction; the audit row joins the hash chain inside it.
+//   draftVersionID: Draft version id.
+//   from: Expected current state.
+//   to: New state.
+//   action: Transition action name.
+//   actorID: Actor user id, 0 for none.
+//   detail: Extra audit detail, may be nil.
+//   now: Transition time.
+// Returns:
+//   bool: False when the draft was no longer in the expected state.
+//   error: Error when update or audit fails.
+func transitionDraftStatus(db sqlRowExecutor, draftVersionID int64, from, to, action string, actorID int64, detail map[string]interface{}, now time.Time) (bool, error) {
+  // Entering review opens a new round so approvals of earlier rounds stop counting.
+  roundIncrement := 0
+  if to == DraftStatusInReview {
+    roundIncrement = 1
+  }
+  result, err := db.Exec(
+    "UPDATE app_db_version_names SET draft_status = ?, draft_status_changed_by = ?, draft_status_changed_at = ?, review_round = review_round + ?, row_version = row_version + 1 WHERE id = ? AND draft_status = ?",
+    to,
+    nullableID(actorID),
+    now,
+    roundIncrement,
+    draftVersionID,
+    from,
+  )
+  if err != nil {
+    return false, err
+  }
+  if rows, err := result.RowsAffected(); err != nil || rows == 0 {
+    return false, err
+  }
+
+  payload := map[string]interface{}{}
+  for key, value := range detail {
+    payload[key] = value
+  }
+  payload["from"] = from
+  payload["to"] = to
+  payload["action"] = action
+  raw, err := json.Marshal(payload)
+  if err != nil {
+    return false, err
+  }
+  if err := insertAuditLog(
+    db,
+    draftVersionID,
+    "app_db_version_names",
+    draftVersionID,
+    "transition",
+    nullableID(actorID),
+    string(raw),
+    now,
+  ); err != nil {
+    return false, err
+  }
+  return true, nil
+}
+
+// loadDraftStatus reads the lifecycle state of a draft version.
+// Args:
+//   db: Database or transaction.
+//   draftVersionID: Draft version id.
+// Returns:
+//   string: Lifecycle state.
+//   error: sql.ErrNoRows when missing.
+func loadDraftStatus(db sqlRowQueryer, draftVersionID int64) (string, error) {
+  var status sql.NullString
+  if err := db.QueryRow("SELECT draft_status FROM app_db_version_names WHERE id = ?", draftVersionID).Scan(&status); err != nil {
+    return "", err
+  }
+  return NormalizeDraftStatus(status.String), nil
+}
+
+// requireDraftEditable answers 409 when the draft is not in an editable state.
+// Args:
+//   c: Gin context.
+//   db: Database or transaction.
+//   draftVersionID: Draft version id, 0 when unknown.
+// Returns:
+//   bool: False when the response has been written.
+func requireDraftEditable(c *gin.Context, db sqlRowQueryer, draftVersionID int64) bool {
+  if draftVersionID <= 0 {
+    return true
+  }
+  status, err := loadDraftStatus(db, draftVersionID)
+  return respondDraftEditable(c, status, err)
+}
+
+// requireDraftEditableTx answers 409 when the draft is not editable, locking the draft version row
+// so a lifecycle transition cannot commit before the write of tx does.
+// Args:
+//   c: Gin context.
+//   tx: Write transaction.
+//   draftVersionID: Draft version id, 0 when unknown.
+// Returns:
+//   bool: False when the response has been written.
+func requireDraftEditableTx(c *gin.Context, tx *sql.Tx, draftVersionID int64) bool {
+  if draftVersionID <= 0 {
+    return true
+  }
+  var status sql.NullString
+  err := tx.QueryRow("SELECT draft_status FROM app_db_version_names WHERE id = ? FOR UPDATE", draftVersionID).Scan(&status)
+  return respondDraftEditable(c, NormalizeDraftStatus(status.String), err)
+}
+
+// requireDraftRowEditable checks the draft owning a row inside a write transaction.
+// Args:
+//   c: Gin context.
+//   tx: Write transaction; the draft version row stays locked until it ends.
+//   table: Module table, or app_db_version_names for the draft itself.
+//   id: Row id.
+// Returns:
+//   bool: False when the response has been written.
+func requireDraftRowEditable(c *gin.Context, tx *sql.Tx, table string, id int64) bool {
+  if table == "app_db_version_names" {
+    return requireDraftEditableTx(c, tx, id)
+  }
+  var draftVersionID sql.NullInt64
+  if err := tx.QueryRow("SELECT draft_version_id FROM "+table+" WHERE id = ?", id).Scan(&draftVersionID); err != nil {
+    if err == sql.ErrNoRows {
+      return true
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return false
+  }
+  return requireDraftEditableTx(c, tx, draftVersionID.Int64)
+}
+
+func respondDraftEditable(c *gin.Context, status string, err error) bool {
+  if err == sql.ErrNoRows {
+    return true
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return false
+  }
+  if !DraftStatusEditable(status) {
+    c.JSON(http.StatusConflict, gin.H{"error": "draft_locked", "draft_status": status})
+    return false
+  }
+  return true
+}
+
+// resolvePayloadDraftVersionID finds the draft a create payload belongs to.
+// Args:
+//   db: Database connection.
+//   payload: Filtered payload.
+// Returns:
+//   int64: Draft version id, 0 when it cannot be resolved.
+func resolvePayloadDraftVersionID(db *sql.DB, payload map[string]interface{}) int64 {
+  if id := parseID(payload["draft_version_id"]); id > 0 {
+    return id
+  }
+  name := strings.TrimSpace(parseStringValue(payload["app_version_name"]))
+  if name == "" {
+    return 0
+  }
+  var id int64
+  if err := db.QueryRow("SELECT id FROM app_db_version_names WHERE app_version_name = ? ORDER BY id DESC LIMIT 1", name).Scan(&id); err != nil {
+    return 0
+  }
+  return id
+}
+
+// loadDraftStatusByFilter returns the lifecycle state for list responses.
+// Args:
+//   db: Database connection.
+//   draftVersionID: Draft version id filter.
+//   appVersionName: App version name filter.
+// Returns:
+//   *string: Lifecycle state, nil when the draft is unknown.
+func loadDraftStatusByFilter(db *sql.DB, draftVersionID int64, appVersionName string) *string {
+  if draftVersionID <= 0 {
+    draftVersionID = resolvePayloadDraftVersionID(db, map[string]interface{}{"app_version_name": appVersionName})
+  }
+  if draftVersionID <= 0 {
+    return nil
+  }
+  status, err := loadDraftStatus(db, draftVersionID)
+  if err != nil {
+    return nil
+  }
+  return &status
+}
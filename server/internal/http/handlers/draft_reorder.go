@@ -0,0 +1,270 @@
+package handlers
+
+import (
+  "encoding/json"
+  "net/http"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+// draftReorderTables maps sortable module keys to their tables.
+var draftReorderTables = map[string]string{
+  "banners":            "app_db_banners",
+  "identities":         "app_db_identities",
+  "scenes":             "app_db_scenes",
+  "clothes_categories": "app_db_clothes_categories",
+  "photo_hobbies":      "app_db_photo_hobbies",
+}
+
+type reorderRequest struct {
+  DraftVersionID int64   `json:"draft_version_id"`
+  IDs            []int64 `json:"ids"`
+}
+
+// ReorderIssues lists why a requested order does not match the module rows.
+type ReorderIssues struct {
+  Missing   []int64 `json:"missing"`
+  Foreign   []int64 `json:"foreign"`
+  Duplicate []int64 `json:"duplicate"`
+}
+
+// Empty reports whether the requested order is a permutation of the current rows.
+// Returns:
+//   bool: True when there is no issue.
+func (r ReorderIssues) Empty() bool {
+  return len(r.Missing) == 0 && len(r.Foreign) == 0 && len(r.Duplicate) == 0
+}
+
+// ValidateReorderIDs checks that requested contains every current id exactly once.
+// Args:
+//   current: Row ids of the module in the draft version.
+//   requested: Ordered ids from the request.
+// Returns:
+//   ReorderIssues: Missing, foreign and duplicate ids.
+func ValidateReorderIDs(current, requested []int64) ReorderIssues {
+  issues := ReorderIssues{Missing: make([]int64, 0), Foreign: make([]int64, 0), Duplicate: make([]int64, 0)}
+  known := make(map[int64]struct{}, len(current))
+  for _, id := range current {
+    known[id] = struct{}{}
+  }
+  seen := make(map[int64]struct{}, len(requested))
+  for _, id := range requested {
+    if _, ok := seen[id]; ok {
+      issues.Duplicate = append(issues.Duplicate, id)
+      continue
+    }
+    seen[id] = struct{}{}
+    if _, ok := known[id]; !ok {
+      issues.Foreign = append(issues.Foreign, id)
+    }
+  }
+  for _, id := range current {
+    if _, ok := seen[id]; !ok {
+      issues.Missing = append(issues.Missing, id)
+    }
+  }
+  return issues
+}
+
+// BuildReorderSort assigns sort values so the first id ranks highest, matching the list order.
+// Args:
+//   ids: Ordered ids.
+// Returns:
+//   map[int64]int: Sort value keyed by id.
+func BuildReorderSort(ids []int64) map[int64]int {
+  sorts := make(map[int64]int, len(ids))
+  for index, id := range ids {
+    sorts[id] = len(ids) - index
+  }
+  return sorts
+}
+
+// ReorderBanners rewrites banner sort values.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ReorderBanners(c *gin.Context) {
+  h.reorderEntity(c, "banners")
+}
+
+// ReorderIdentities rewrites identity sort values.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ReorderIdentities(c *gin.Context) {
+  h.reorderEntity(c, "identities")
+}
+
+// ReorderScenes rewrites scene sort values.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ReorderScenes(c *gin.Context) {
+  h.reorderEntity(c, "scenes")
+}
+
+// ReorderClothesCategories rewrites clothes category sort values.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ReorderClothesCategories(c *gin.Context) {
+  h.reorderEntity(c, "clothes_categories")
+}
+
+// ReorderPhotoHobbies rewrites photo hobby sort values.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ReorderPhotoHobbies(c *gin.Context) {
+  h.reorderEntity(c, "photo_hobbies")
+}
+
+func (h *DraftCRUDHandler) reorderEntity(c *gin.Context, moduleKey string) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  table := draftReorderTables[moduleKey]
+  var req reorderRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+  if req.DraftVersionID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+    return
+  }
+  if len(req.IDs) == 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "ids is required"})
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  if !requireDraftEditable(c, tx, req.DraftVersionID) {
+    return
+  }
+
+  // Lock the module rows so concurrent reorders and edits serialize.
+  rows, err := tx.Query(
+    "SELECT id, COALESCE(sort, 0) FROM "+table+" WHERE draft_version_id = ? AND deleted_at IS NULL ORDER BY sort DESC, id ASC FOR UPDATE",
+    req.DraftVersionID,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  before := make([]int64, 0)
+  currentSort := make(map[int64]int)
+  for rows.Next() {
+    var (
+      id   int64
+      sort int
+    )
+    if err := rows.Scan(&id, &sort); err != nil {
+      _ = rows.Close()
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+      return
+    }
+    before = append(before, id)
+    currentSort[id] = sort
+  }
+  _ = rows.Close()
+  if err := rows.Err(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  if issues := ValidateReorderIDs(before, req.IDs); !issues.Empty() {
+    c.JSON(http.StatusBadRequest, gin.H{
+      "error":     "invalid_order",
+      "missing":   issues.Missing,
+      "foreign":   issues.Foreign,
+      "duplicate": issues.Duplicate,
+    })
+    return
+  }
+
+  now := time.Now()
+  updated := 0
+  for id, sort := range BuildReorderSort(req.IDs) {
+    if currentSort[id] == sort {
+      continue
+    }
+    if _, err := tx.Exec(
+      "UPDATE "+table+" SET sort = ?, updated_by = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
+      sort,
+      nullableID(operatorID),
+      now,
+      id,
+    ); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+      return
+    }
+    if _, err := tx.Exec(
+      "INSERT INTO app_db_field_history (draft_version_id, entity_table, entity_id, field_name, old_value, new_value, action, changed_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+      req.DraftVersionID,
+      table,
+      id,
+      "sort",
+      jsonValue(currentSort[id]),
+      jsonValue(sort),
+      "reorder",
+      nullableID(operatorID),
+      now,
+    ); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "history insert failed"})
+      return
+    }
+    updated++
+  }
+
+  detail, _ := json.Marshal(map[string]interface{}{
+    "module_key": moduleKey,
+    "before":     before,
+    "after":      req.IDs,
+    "updated":    updated,
+  })
+  if err := insertAuditLog(
+    tx,
+    req.DraftVersionID,
+    table,
+    nil,
+    "reorder",
+    nullableID(operatorID),
+    string(detail),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"module_key": moduleKey, "ids": req.IDs, "updated": updated})
+}
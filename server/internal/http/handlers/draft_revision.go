@@ -0,0 +1,220 @@
+package handlers
+
+import (
+  "database/sql"
+  "errors"
+  "fmt"
+  "net/http"
+  "strconv"
+  "strings"
+
+  "github.com/gin-gonic/gin"
+)
+
+const draftRevisionColumn = "row_version"
+
+// draftRevisionIgnoredFields are bookkeeping fields left out of conflict diffs.
+var draftRevisionIgnoredFields = map[string]struct{}{
+  "created_by": {},
+  "updated_by": {},
+  "created_at": {},
+  "updated_at": {},
+}
+
+// ParseRevisionToken reads a row revision from an If-Match header or a body field.
+// Args:
+//   header: If-Match header value, such as "3", W/"3" or 3.
+//   body: row_version value from the request body.
+// Returns:
+//   int64: Revision, header wins over body.
+//   bool: Whether a valid revision was provided.
+func ParseRevisionToken(header string, body interface{}) (int64, bool) {
+  raw := strings.TrimSpace(header)
+  if raw != "" {
+    raw = strings.TrimPrefix(raw, "W/")
+    raw = strings.Trim(raw, "\"")
+    parsed, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+    if err != nil || parsed <= 0 {
+      return 0, false
+    }
+    return parsed, true
+  }
+  if revision := parseID(body); revision > 0 {
+    return revision, true
+  }
+  return 0, false
+}
+
+// BuildRevisionedUpdateSQL builds an UPDATE that only applies at the given revision and bumps it.
+// Args:
+//   table: Table name.
+//   idColumn: ID column name.
+//   id: ID value.
+//   revision: Expected row_version.
+//   payload: Filtered payload.
+// Returns:
+//   string: SQL string.
+//   []any: SQL args.
+//   error: Error when payload is empty.
+func BuildRevisionedUpdateSQL(table, idColumn string, id, revision int64, payload map[string]interface{}) (string, []any, error) {
+  if len(payload) == 0 {
+    return "", nil, errors.New("empty payload")
+  }
+
+  setParts, args := buildUpdateAssignments(payload)
+  column := quoteSQLIdent(draftRevisionColumn)
+  setParts = append(setParts, column+" = "+column+" + 1")
+  args = append(args, id, revision)
+
+  sqlText := fmt.Sprintf(
+    "UPDATE %s SET %s WHERE %s = ? AND %s = ?",
+    quoteSQLIdent(table),
+    strings.Join(setParts, ","),
+    quoteSQLIdent(idColumn),
+    column,
+  )
+  return sqlText, args, nil
+}
+
+// requireRevision reads the expected revision and answers 428 when it is missing.
+// Args:
+//   c: Gin context.
+//   payload: Request payload, may be nil.
+// Returns:
+//   int64: Expected revision.
+//   bool: False when the response has been written.
+func requireRevision(c *gin.Context, payload map[string]interface{}) (int64, bool) {
+  var body interface{}
+  if payload != nil {
+    body = payload[draftRevisionColumn]
+  }
+  revision, ok := ParseRevisionToken(c.GetHeader("If-Match"), body)
+  if !ok {
+    c.JSON(http.StatusPreconditionRequired, gin.H{"error": "row_version is required"})
+    return 0, false
+  }
+  return revision, true
+}
+
+// setRevisionHeader exposes a row revision as ETag.
+// Args:
+//   c: Gin context.
+//   revision: Row revision.
+// Returns:
+//   None.
+func setRevisionHeader(c *gin.Context, revision int64) {
+  c.Header("ETag", strconv.Quote(strconv.FormatInt(revision, 10)))
+}
+
+// respondRevisionConflict answers a failed revisioned write with 404 or 409.
+// Args:
+//   c: Gin context.
+//   db: Database connection.
+//   table: Table name.
+//   idColumn: ID column name.
+//   id: Row id.
+//   submitted: Fields the client tried to write, nil for deletes.
+// Returns:
+//   None.
+func respondRevisionConflict(c *gin.Context, db *sql.DB, table, idColumn string, id int64, submitted map[string]interface{}) {
+  current, revision, err := loadDraftEntityRow(db, table, idColumn, id)
+  if err == sql.ErrNoRows {
+    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  server := make(map[string]interface{}, len(submitted))
+  client := make(map[string]interface{}, len(submitted))
+  for field, value := range submitted {
+    if _, skip := draftRevisionIgnoredFields[field]; skip {
+      continue
+    }
+    server[field] = current[field]
+    client[field] = value
+  }
+
+  setRevisionHeader(c, revision)
+  c.JSON(http.StatusConflict, gin.H{
+    "error":       "revision_conflict",
+    "row_version": revision,
+    "current":     current,
+    "diff":        BuildPayloadDiff(server, client),
+  })
+}
+
+// loadDraftEntityRow reads a full row, converting integer columns to numbers.
+// Args:
+//   db: Database or transaction.
+//   table: Table name.
+//   idColumn: ID column name.
+//   id: Row id.
+// Returns:
+//   map[string]interface{}: Row values keyed by column.
+//   int64: Current row_version.
+//   error: sql.ErrNoRows when missing.
+func loadDraftEntityRow(db sqlQueryer, table, idColumn string, id int64) (map[string]interface{}, int64, error) {
+  items, err := queryDraftEntityRows(db, fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", quoteSQLIdent(table), quoteSQLIdent(idColumn)), id)
+  if err != nil {
+    return nil, 0, err
+  }
+  if len(items) == 0 {
+    return nil, 0, sql.ErrNoRows
+  }
+  row := items[0]
+  revision, _ := row[draftRevisionColumn].(int64)
+  return row, revision, nil
+}
+
+// queryDraftEntityRows runs a SELECT and returns every row keyed by column, converting integer columns to numbers.
+// Args:
+//   db: Database or transaction.
+//   query: SQL query.
+//   args: Query args.
+// Returns:
+//   []map[string]interface{}: Rows in query order.
+//   error: Error when query fails.
+func queryDraftEntityRows(db sqlQueryer, query string, args ...interface{}) ([]map[string]interface{}, error) {
+  rows, err := db.Query(query, args...)
+  if err != nil {
+    return nil, err
+  }
+  defer rows.Close()
+
+  columnTypes, err := rows.ColumnTypes()
+  if err != nil {
+    return nil, err
+  }
+  items := make([]map[string]interface{}, 0)
+  for rows.Next() {
+    values := make([]sql.NullString, len(columnTypes))
+    targets := make([]interface{}, len(values))
+    for i := range values {
+      targets[i] = &values[i]
+    }
+    if err := rows.Scan(targets...); err != nil {
+      return nil, err
+    }
+
+    row := make(map[string]interface{}, len(columnTypes))
+    for i, columnType := range columnTypes {
+      name := columnType.Name()
+      if !values[i].Valid {
+        row[name] = nil
+        continue
+      }
+      if strings.Contains(strings.ToUpper(columnType.DatabaseTypeName()), "INT") {
+        if parsed, err := strconv.ParseInt(values[i].String, 10, 64); err == nil {
+          row[name] = parsed
+          continue
+        }
+      }
+      row[name] = values[i].String
+    }
+    items = append(items, row)
+  }
+  return items, rows.Err()
+}
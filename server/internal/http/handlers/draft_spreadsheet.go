@@ -0,0 +1,450 @@
+package handlers
+
+import (
+  "bytes"
+  "database/sql"
+  "encoding/json"
+  "fmt"
+  "net/http"
+  "path/filepath"
+  "strconv"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/http/middleware"
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+const maxSpreadsheetRows = 5000
+
+var spreadsheetModules = []string{"identities", "scenes", "clothes_categories", "photo_hobbies"}
+
+// spreadsheetFlagColumns only accept 0 or 1.
+var spreadsheetFlagColumns = map[string]struct{}{
+  "status":         {},
+  "need_watermark": {},
+}
+
+// SpreadsheetRow is one validated spreadsheet row (Row is the 1-based sheet row number).
+type SpreadsheetRow struct {
+  Row    int                    `json:"row"`
+  Name   string                 `json:"name"`
+  Values map[string]interface{} `json:"-"`
+}
+
+// SpreadsheetRowError is a validation error of one spreadsheet row.
+type SpreadsheetRowError struct {
+  Row   int    `json:"row"`
+  Field string `json:"field,omitempty"`
+  Error string `json:"error"`
+}
+
+// ParseSpreadsheetRows maps spreadsheet columns to a module's fields and validates every row.
+// Args:
+//   moduleKey: List module key.
+//   rows: Sheet rows, the first row is the header.
+// Returns:
+//   []SpreadsheetRow: Valid rows.
+//   []string: Header columns that do not map to a field.
+//   []SpreadsheetRowError: Row-numbered errors.
+func ParseSpreadsheetRows(moduleKey string, rows [][]string) ([]SpreadsheetRow, []string, []SpreadsheetRowError) {
+  accepted := make([]SpreadsheetRow, 0)
+  ignored := make([]string, 0)
+  errs := make([]SpreadsheetRowError, 0)
+
+  module, ok := findSpreadsheetModule(moduleKey)
+  if !ok {
+    errs = append(errs, SpreadsheetRowError{Error: "unsupported module"})
+    return accepted, ignored, errs
+  }
+  if len(rows) == 0 {
+    errs = append(errs, SpreadsheetRowError{Row: 1, Error: "header row is required"})
+    return accepted, ignored, errs
+  }
+  if len(rows)-1 > maxSpreadsheetRows {
+    errs = append(errs, SpreadsheetRowError{Error: fmt.Sprintf("at most %d rows are allowed", maxSpreadsheetRows)})
+    return accepted, ignored, errs
+  }
+
+  allowed := make(map[string]struct{}, len(module.columns))
+  for _, column := range module.columns {
+    allowed[strings.Trim(column, "`")] = struct{}{}
+  }
+  fields := make([]string, len(rows[0]))
+  seenFields := make(map[string]struct{}, len(rows[0]))
+  for i, raw := range rows[0] {
+    field := strings.ToLower(strings.TrimSpace(raw))
+    if _, ok := allowed[field]; !ok {
+      if field != "" {
+        ignored = append(ignored, strings.TrimSpace(raw))
+      }
+      continue
+    }
+    if _, dup := seenFields[field]; dup {
+      errs = append(errs, SpreadsheetRowError{Row: 1, Field: field, Error: "duplicate column"})
+      continue
+    }
+    seenFields[field] = struct{}{}
+    fields[i] = field
+  }
+  if _, ok := seenFields["name"]; !ok {
+    errs = append(errs, SpreadsheetRowError{Row: 1, Field: "name", Error: "name column is required"})
+    return accepted, ignored, errs
+  }
+
+  names := make(map[string]int)
+  for index, cells := range rows[1:] {
+    rowNumber := index + 2
+    values := make(map[string]interface{})
+    empty := true
+    rowValid := true
+    for i, cell := range cells {
+      if i >= len(fields) || fields[i] == "" {
+        continue
+      }
+      field := fields[i]
+      text := strings.TrimSpace(cell)
+      if text != "" {
+        empty = false
+      }
+      if text == "" {
+        values[field] = nil
+        continue
+      }
+      if _, numeric := syncMergeNumericColumns[field]; numeric {
+        parsed, err := strconv.ParseInt(text, 10, 64)
+        if err != nil {
+          errs = append(errs, SpreadsheetRowError{Row: rowNumber, Field: field, Error: field + " must be an integer"})
+          rowValid = false
+          continue
+        }
+        if _, flag := spreadsheetFlagColumns[field]; flag && parsed != 0 && parsed != 1 {
+          errs = append(errs, SpreadsheetRowError{Row: rowNumber, Field: field, Error: field + " must be 0 or 1"})
+          rowValid = false
+          continue
+        }
+        values[field] = parsed
+        continue
+      }
+      values[field] = text
+    }
+    if empty {
+      continue
+    }
+    name, _ := values["name"].(string)
+    if name == "" {
+      errs = append(errs, SpreadsheetRowError{Row: rowNumber, Field: "name", Error: "name is required"})
+      continue
+    }
+    if previous, dup := names[name]; dup {
+      errs = append(errs, SpreadsheetRowError{Row: rowNumber, Field: "name", Error: fmt.Sprintf("duplicate name, first seen in row %d", previous)})
+      continue
+    }
+    names[name] = rowNumber
+    if rowValid {
+      accepted = append(accepted, SpreadsheetRow{Row: rowNumber, Name: name, Values: values})
+    }
+  }
+  return accepted, ignored, errs
+}
+
+func findSpreadsheetModule(moduleKey string) (draftCloneModule, bool) {
+  for _, key := range spreadsheetModules {
+    if key != moduleKey {
+      continue
+    }
+    for _, module := range draftCloneModules {
+      if module.key == key {
+        return module, true
+      }
+    }
+  }
+  return draftCloneModule{}, false
+}
+
+// ExportSpreadsheet returns the rows of a list module as XLSX or CSV.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ExportSpreadsheet(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  draftVersionID := parseInt64Query(c, "draft_version_id")
+  if draftVersionID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+    return
+  }
+  moduleKey := strings.TrimSpace(c.Query("module"))
+  module, ok := findSpreadsheetModule(moduleKey)
+  if !ok {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported module", "modules": spreadsheetModules})
+    return
+  }
+  format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "xlsx")))
+  if format != "xlsx" && format != "csv" {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "format must be xlsx or csv"})
+    return
+  }
+
+  version, err := loadDraftVersion(h.db, draftVersionID)
+  if err == sql.ErrNoRows {
+    c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  items, err := loadDraftModuleRows(h.db, module, draftVersionID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  rows := make([][]string, 0, len(items)+1)
+  header := make([]string, len(module.columns))
+  for i, column := range module.columns {
+    header[i] = strings.Trim(column, "`")
+  }
+  rows = append(rows, header)
+  for _, values := range items {
+    row := make([]string, len(values))
+    for i, value := range values {
+      row[i] = nullableStringValue(value)
+    }
+    rows = append(rows, row)
+  }
+
+  var buffer bytes.Buffer
+  contentType := "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+  if format == "csv" {
+    contentType = "text/csv; charset=utf-8"
+    err = services.WriteCSV(&buffer, rows)
+  } else {
+    err = services.WriteXLSX(&buffer, moduleKey, rows)
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "export failed"})
+    return
+  }
+
+  name := nullableStringValue(version.AppVersionName)
+  if name == "" {
+    name = fmt.Sprintf("draft_%d", draftVersionID)
+  }
+  c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("%s_%s.%s", name, moduleKey, format)))
+  c.Data(http.StatusOK, contentType, buffer.Bytes())
+}
+
+// ImportSpreadsheet validates an XLSX or CSV file and applies all rows in one transaction.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ImportSpreadsheet(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  draftVersionID := parseInt64Value(c.PostForm("draft_version_id"))
+  if draftVersionID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+    return
+  }
+  if !requireDraftEditable(c, h.db, draftVersionID) {
+    return
+  }
+  moduleKey := strings.TrimSpace(c.PostForm("module"))
+  module, ok := findSpreadsheetModule(moduleKey)
+  if !ok {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported module", "modules": spreadsheetModules})
+    return
+  }
+  upsert := parseBoolValue(c.PostForm("upsert"))
+
+  header, err := c.FormFile("file")
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+    return
+  }
+  file, err := header.Open()
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file"})
+    return
+  }
+  defer func() {
+    _ = file.Close()
+  }()
+
+  format := strings.ToLower(strings.TrimSpace(c.PostForm("format")))
+  if format == "" {
+    format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+  }
+  var sheetRows [][]string
+  switch format {
+  case "xlsx":
+    sheetRows, err = services.ReadXLSX(file, header.Size)
+  case "csv":
+    sheetRows, err = services.ReadCSV(file)
+  default:
+    c.JSON(http.StatusBadRequest, gin.H{"error": "format must be xlsx or csv"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid spreadsheet", "detail": err.Error()})
+    return
+  }
+
+  accepted, ignored, rowErrors := ParseSpreadsheetRows(moduleKey, sheetRows)
+  if len(rowErrors) > 0 {
+    c.JSON(http.StatusUnprocessableEntity, gin.H{
+      "error":           "invalid_rows",
+      "accepted":        accepted,
+      "errors":          rowErrors,
+      "ignored_columns": ignored,
+    })
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  version, err := loadDraftVersion(h.db, draftVersionID)
+  if err == sql.ErrNoRows {
+    c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+    return
+  }
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  now := time.Now()
+  results := make([]gin.H, 0, len(accepted))
+  inserted, updated := 0, 0
+  for _, row := range accepted {
+    existingID := int64(0)
+    revision := int64(0)
+    if upsert {
+      err := tx.QueryRow(
+        "SELECT id, row_version FROM "+module.table+" WHERE draft_version_id = ? AND name = ?"+draftTrashFilter(module.table)+" ORDER BY id ASC LIMIT 1",
+        draftVersionID,
+        row.Name,
+      ).Scan(&existingID, &revision)
+      if err != nil && err != sql.ErrNoRows {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed", "row": row.Row})
+        return
+      }
+    }
+
+    action := "insert"
+    payload := make(map[string]interface{}, len(row.Values)+4)
+    if existingID > 0 {
+      action = "update"
+      for field, value := range row.Values {
+        // Empty cells keep the current value on update.
+        if value != nil {
+          payload[field] = value
+        }
+      }
+      payload["updated_by"] = nullableID(operatorID)
+      applyTimestamps(payload, false)
+      sqlText, args, err := BuildRevisionedUpdateSQL(module.table, "id", existingID, revision, payload)
+      var result sql.Result
+      if err == nil {
+        result, err = tx.Exec(sqlText, args...)
+      }
+      if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed", "row": row.Row})
+        return
+      }
+      if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+        c.JSON(http.StatusConflict, gin.H{"error": "revision_conflict", "row": row.Row})
+        return
+      }
+      updated++
+    } else {
+      for field, value := range row.Values {
+        payload[field] = value
+      }
+      payload["draft_version_id"] = draftVersionID
+      payload["app_version_name"] = nullableStringValue(version.AppVersionName)
+      payload["created_by"] = nullableID(operatorID)
+      payload["updated_by"] = nullableID(operatorID)
+      applyTimestamps(payload, true)
+      sqlText, args, err := BuildInsertSQL(module.table, payload)
+      var result sql.Result
+      if err == nil {
+        result, err = tx.Exec(sqlText, args...)
+      }
+      if err != nil {
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed", "row": row.Row})
+        return
+      }
+      existingID, _ = result.LastInsertId()
+      inserted++
+    }
+
+    detail, _ := json.Marshal(map[string]interface{}{
+      "source": "spreadsheet",
+      "file":   header.Filename,
+      "row":    row.Row,
+      "mode":   action,
+      "values": row.Values,
+    })
+    if err := insertAuditLog(
+      tx,
+      draftVersionID,
+      module.table,
+      existingID,
+      "spreadsheet_"+action,
+      nullableID(operatorID),
+      string(detail),
+      now,
+    ); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+      return
+    }
+    results = append(results, gin.H{"row": row.Row, "name": row.Name, "id": existingID, "action": action})
+  }
+
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{
+    "accepted":        results,
+    "errors":          rowErrors,
+    "inserted":        inserted,
+    "updated":         updated,
+    "ignored_columns": ignored,
+  })
+}
+
+func parseBoolValue(raw string) bool {
+  switch strings.ToLower(strings.TrimSpace(raw)) {
+  case "1", "true", "yes", "on":
+    return true
+  }
+  return false
+}
@@ -0,0 +1,481 @@
+package handlers
+
+import (
+  "context"
+  "database/sql"
+  "log"
+  "net/http"
+  "strings"
+  "time"
+
+  "github.com/gin-gonic/gin"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const draftTrashPurgeInterval = time.Hour
+
+type draftTrashModule struct {
+  key   string
+  table string
+  // label is the column shown in the trash list.
+  label string
+}
+
+var draftTrashModules = []draftTrashModule{
+  {key: "banners", table: "app_db_banners", label: "title"},
+  {key: "identities", table: "app_db_identities", label: "name"},
+  {key: "scenes", table: "app_db_scenes", label: "name"},
+  {key: "clothes_categories", table: "app_db_clothes_categories", label: "name"},
+  {key: "photo_hobbies", table: "app_db_photo_hobbies", label: "name"},
+  {key: "config_extra_steps", table: "app_db_config_extra_steps", label: "label"},
+}
+
+// DraftTrashPurger permanently removes trashed draft rows after the retention period.
+type DraftTrashPurger struct {
+  db        *sql.DB
+  retention int
+}
+
+// NewDraftTrashPurger creates the trash retention job.
+// Args:
+//   cfg: App config instance.
+//   db: Database connection.
+// Returns:
+//   *DraftTrashPurger: Initialized purger.
+func NewDraftTrashPurger(cfg *config.Config, db *sql.DB) *DraftTrashPurger {
+  return &DraftTrashPurger{db: db, retention: cfg.DraftTrashRetentionDays}
+}
+
+// Start launches the purge goroutine; a non-positive retention disables it.
+// Args:
+//   ctx: Lifecycle context; the goroutine stops when it is cancelled.
+// Returns:
+//   None.
+func (p *DraftTrashPurger) Start(ctx context.Context) {
+  if p == nil || p.db == nil || p.retention <= 0 {
+    return
+  }
+  go func() {
+    ticker := time.NewTicker(draftTrashPurgeInterval)
+    defer ticker.Stop()
+    for {
+      if purged, err := p.PurgeExpired(time.Now()); err != nil {
+        log.Printf("draft trash purge failed: %v", err)
+      } else if purged > 0 {
+        log.Printf("draft trash purged %d rows", purged)
+      }
+      select {
+      case <-ctx.Done():
+        return
+      case <-ticker.C:
+      }
+    }
+  }()
+}
+
+// PurgeExpired deletes rows trashed before the retention cutoff.
+// Args:
+//   now: Current time.
+// Returns:
+//   int64: Purged row count.
+//   error: Error when delete fails.
+func (p *DraftTrashPurger) PurgeExpired(now time.Time) (int64, error) {
+  cutoff, ok := DraftTrashCutoff(now, p.retention)
+  if !ok {
+    return 0, nil
+  }
+  total := int64(0)
+  for _, module := range draftTrashModules {
+    tx, err := p.db.Begin()
+    if err != nil {
+      return total, err
+    }
+    expired, err := queryDraftEntityRows(tx, "SELECT * FROM "+module.table+" WHERE deleted_at IS NOT NULL AND deleted_at < ? FOR UPDATE", cutoff)
+    if err != nil {
+      _ = tx.Rollback()
+      return total, err
+    }
+    if _, err := tx.Exec(
+      "DELETE FROM app_db_sync_id_map WHERE module_key = ? AND draft_row_id IN (SELECT id FROM "+module.table+" WHERE deleted_at IS NOT NULL AND deleted_at < ?)",
+      module.key,
+      cutoff,
+    ); err != nil {
+      _ = tx.Rollback()
+      return total, err
+    }
+    result, err := tx.Exec("DELETE FROM "+module.table+" WHERE deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+    if err != nil {
+      _ = tx.Rollback()
+      return total, err
+    }
+    for _, row := range expired {
+      if err := recordDraftChangeTx(tx, module.table, parseID(row["id"]), "purge", 0, row, nil, map[string]interface{}{"source": "retention"}, now); err != nil {
+        _ = tx.Rollback()
+        return total, err
+      }
+    }
+    if err := tx.Commit(); err != nil {
+      return total, err
+    }
+    count, _ := result.RowsAffected()
+    total += count
+  }
+  return total, nil
+}
+
+// DraftTrashCutoff returns the time before which trashed rows are purged.
+// Args:
+//   now: Current time.
+//   retentionDays: Retention period in days.
+// Returns:
+//   time.Time: Purge cutoff.
+//   bool: False when retention is disabled.
+func DraftTrashCutoff(now time.Time, retentionDays int) (time.Time, bool) {
+  if retentionDays <= 0 {
+    return time.Time{}, false
+  }
+  return now.AddDate(0, 0, -retentionDays), true
+}
+
+func findDraftTrashModule(moduleKey string) (draftTrashModule, bool) {
+  for _, module := range draftTrashModules {
+    if module.key == moduleKey {
+      return module, true
+    }
+  }
+  return draftTrashModule{}, false
+}
+
+// draftTrashFilter returns the condition hiding trashed rows of a table, if it supports trash.
+// Args:
+//   table: Table name.
+// Returns:
+//   string: SQL condition starting with AND, or empty.
+func draftTrashFilter(table string) string {
+  for _, module := range draftTrashModules {
+    if module.table == table {
+      return " AND deleted_at IS NULL"
+    }
+  }
+  return ""
+}
+
+// DraftRowTrashed reports whether a row image of a trash-enabled table sits in the trash.
+// Args:
+//   table: Table name.
+//   row: Row image, nil when missing.
+// Returns:
+//   bool: True when the table supports trash and deleted_at is set.
+func DraftRowTrashed(table string, row map[string]interface{}) bool {
+  return draftTrashFilter(table) != "" && row != nil && row["deleted_at"] != nil
+}
+
+// trashEntity moves a draft row into the trash.
+// Args:
+//   c: Gin context.
+//   table: Table name.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) trashEntity(c *gin.Context, table string) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+  revision, ok := requireRevision(c, nil)
+  if !ok {
+    return
+  }
+
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  if !requireDraftRowEditable(c, tx, table, id) {
+    return
+  }
+  before, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+
+  now := time.Now()
+  result, err := tx.Exec(
+    "UPDATE "+table+" SET deleted_at = ?, deleted_by = ?, row_version = row_version + 1 WHERE id = ? AND row_version = ? AND deleted_at IS NULL",
+    now,
+    nullableID(operatorID),
+    id,
+    revision,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  rows, err := result.RowsAffected()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  if rows == 0 {
+    _ = tx.Rollback()
+    respondRevisionConflict(c, h.db, table, "id", id, nil)
+    return
+  }
+
+  after, err := lockDraftEntityRow(tx, table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  if err := recordDraftChangeTx(tx, table, id, "trash", operatorID, before, after, nil, now); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"id": id, "trashed": true})
+}
+
+// ListTrash returns trashed rows of a draft version.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) ListTrash(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  draftVersionID := parseInt64Query(c, "draft_version_id")
+  if draftVersionID <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+    return
+  }
+  modules := draftTrashModules
+  if moduleKey := strings.TrimSpace(c.Query("module")); moduleKey != "" {
+    module, ok := findDraftTrashModule(moduleKey)
+    if !ok {
+      c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported module"})
+      return
+    }
+    modules = []draftTrashModule{module}
+  }
+
+  items := make([]gin.H, 0)
+  for _, module := range modules {
+    rows, err := h.db.Query(
+      "SELECT t.id, t."+module.label+", t.deleted_at, t.deleted_by, u.display_name, u.username, t.row_version FROM "+module.table+" t LEFT JOIN app_db_users u ON u.id = t.deleted_by WHERE t.draft_version_id = ? AND t.deleted_at IS NOT NULL ORDER BY t.deleted_at DESC, t.id DESC",
+      draftVersionID,
+    )
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    for rows.Next() {
+      var (
+        id          int64
+        label       sql.NullString
+        deletedAt   sql.NullTime
+        deletedBy   sql.NullInt64
+        displayName sql.NullString
+        username    sql.NullString
+        rowVersion  int64
+      )
+      if err := rows.Scan(&id, &label, &deletedAt, &deletedBy, &displayName, &username, &rowVersion); err != nil {
+        _ = rows.Close()
+        c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+        return
+      }
+      deletedByName := nullableStringValue(displayName)
+      if deletedByName == "" {
+        deletedByName = nullableStringValue(username)
+      }
+      items = append(items, gin.H{
+        "module_key":      module.key,
+        "id":              id,
+        "label":           nullableString(label),
+        "deleted_at":      nullableTimePointer(deletedAt),
+        "deleted_by":      nullableInt(deletedBy),
+        "deleted_by_name": deletedByName,
+        "row_version":     rowVersion,
+      })
+    }
+    _ = rows.Close()
+    if err := rows.Err(); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+  }
+
+  c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// RestoreTrash moves a trashed row back into its module.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) RestoreTrash(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  module, id, ok := parseDraftTrashTarget(c)
+  if !ok {
+    return
+  }
+  claims, _ := middleware.GetAuthClaims(c)
+  operatorID := int64(0)
+  if claims != nil {
+    operatorID = claims.UserID
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  if !requireDraftRowEditable(c, tx, module.table, id) {
+    return
+  }
+  before, err := lockDraftEntityRow(tx, module.table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+
+  now := time.Now()
+  result, err := tx.Exec(
+    "UPDATE "+module.table+" SET deleted_at = NULL, deleted_by = NULL, updated_by = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ? AND deleted_at IS NOT NULL",
+    nullableID(operatorID),
+    now,
+    id,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  rows, err := result.RowsAffected()
+  if err != nil || rows == 0 {
+    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+    return
+  }
+
+  after, err := lockDraftEntityRow(tx, module.table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
+  }
+  if err := recordDraftChangeTx(tx, module.table, id, "restore", operatorID, before, after, nil, now); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"id": id, "module_key": module.key})
+}
+
+// PurgeTrash permanently deletes a trashed row.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *DraftCRUDHandler) PurgeTrash(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  module, id, ok := parseDraftTrashTarget(c)
+  if !ok {
+    return
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  if !requireDraftRowEditable(c, tx, module.table, id) {
+    return
+  }
+  before, err := lockDraftEntityRow(tx, module.table, id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+
+  result, err := tx.Exec("DELETE FROM "+module.table+" WHERE id = ? AND deleted_at IS NOT NULL", id)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  rows, err := result.RowsAffected()
+  if err != nil || rows == 0 {
+    c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+    return
+  }
+  if _, err := tx.Exec("DELETE FROM app_db_sync_id_map WHERE module_key = ? AND draft_row_id = ?", module.key, id); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
+    return
+  }
+  if err := recordDraftChangeTx(tx, module.table, id, "purge", draftActorID(c), before, nil, nil, time.Now()); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
+  }
+
+  c.JSON(http.StatusOK, gin.H{"id": id, "module_key": module.key})
+}
+
+func parseDraftTrashTarget(c *gin.Context) (draftTrashModule, int64, bool) {
+  module, ok := findDraftTrashModule(strings.TrimSpace(c.Param("module")))
+  if !ok {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported module"})
+    return draftTrashModule{}, 0, false
+  }
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return draftTrashModule{}, 0, false
+  }
+  return module, id, true
+}
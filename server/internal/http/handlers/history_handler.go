@@ -30,7 +30,7 @@ func NewHistoryHandler(cfg *config.Config, db *sql.DB, redis *redis.Client) *His
   return &HistoryHandler{cfg: cfg, db: db, redis: redis}
 }
 
-// ListAuditLogs returns audit log entries.
+// ListAuditLogs searches audit log entries.
 // Args:
 //   c: Gin context.
 // Returns:
@@ -41,38 +41,19 @@ func (h *HistoryHandler) ListAuditLogs(c *gin.Context) {
     return
   }
 
-  draftID := parseInt64Query(c, "draft_version_id")
-  entityTable := strings.TrimSpace(c.Query("entity_table"))
-  entityID := parseInt64Query(c, "entity_id")
-  action := strings.TrimSpace(c.Query("action"))
-  limit, offset := parsePagination(c)
-
-  query := `SELECT l.id, l.draft_version_id, l.entity_table, l.entity_id, l.action, l.actor_id, l.detail_json, l.created_at,
-    u.display_name, u.username
-    FROM app_db_audit_logs l
-    LEFT JOIN app_db_users u ON u.id = l.actor_id
-    WHERE 1=1`
-  args := make([]interface{}, 0)
-
-  if draftID > 0 {
-    query += " AND l.draft_version_id = ?"
-    args = append(args, draftID)
-  }
-  if entityTable != "" {
-    query += " AND l.entity_table = ?"
-    args = append(args, entityTable)
-  }
-  if entityID > 0 {
-    query += " AND l.entity_id = ?"
-    args = append(args, entityID)
+  filter, err := ParseAuditLogFilter(c.Request.URL.Query(), appLocation(h.cfg))
+  if err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    return
   }
-  if action != "" {
-    query += " AND l.action = ?"
-    args = append(args, action)
+  limit, offset := parsePagination(c)
+  if filter.Cursor != nil {
+    offset = 0
   }
 
-  query += " ORDER BY l.id DESC LIMIT ? OFFSET ?"
-  args = append(args, limit, offset)
+  where, orderBy, args := BuildAuditLogQuery(filter)
+  query := auditLogSelect + where + orderBy + " LIMIT ? OFFSET ?"
+  args = append(args, limit+1, offset)
 
   rows, err := h.db.Query(query, args...)
   if err != nil {
@@ -81,52 +62,31 @@ func (h *HistoryHandler) ListAuditLogs(c *gin.Context) {
   }
   defer rows.Close()
 
-  items := make([]gin.H, 0)
+  records := make([]auditLogRecord, 0, limit)
   for rows.Next() {
-    var (
-      id            int64
-      draftVersion  sql.NullInt64
-      entityTableV  sql.NullString
-      entityIDV     sql.NullInt64
-      actionV       sql.NullString
-      actorID       sql.NullInt64
-      detailJSON    sql.NullString
-      createdAt     sql.NullTime
-      displayName   sql.NullString
-      username      sql.NullString
-    )
-
-    if err := rows.Scan(
-      &id,
-      &draftVersion,
-      &entityTableV,
-      &entityIDV,
-      &actionV,
-      &actorID,
-      &detailJSON,
-      &createdAt,
-      &displayName,
-      &username,
-    ); err != nil {
+    record, err := scanAuditLogRecord(rows)
+    if err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
+    records = append(records, record)
+  }
+  if err := rows.Err(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
 
-    items = append(items, gin.H{
-      "id":               id,
-      "draft_version_id": nullableInt64Pointer(draftVersion),
-      "entity_table":     nullableStringValue(entityTableV),
-      "entity_id":        nullableInt64Pointer(entityIDV),
-      "action":           nullableStringValue(actionV),
-      "actor_id":         nullableInt64Pointer(actorID),
-      "actor_name":       nullableStringValue(displayName),
-      "actor_username":   nullableStringValue(username),
-      "detail":           decodeJSON(detailJSON),
-      "created_at":       nullableTimePointer(createdAt),
-    })
+  var nextCursor interface{}
+  if len(records) > limit {
+    records = records[:limit]
+    nextCursor = EncodeAuditLogCursor(records[len(records)-1].cursor())
+  }
+  items := make([]gin.H, 0, len(records))
+  for _, record := range records {
+    items = append(items, record.item())
   }
 
-  c.JSON(http.StatusOK, gin.H{"data": items})
+  c.JSON(http.StatusOK, gin.H{"data": items, "next_cursor": nextCursor})
 }
 
 // ListFieldHistory returns field-level history records.
@@ -152,7 +112,7 @@ func (h *HistoryHandler) ListFieldHistory(c *gin.Context) {
   limit, offset := parsePagination(c)
 
   query := `SELECT h.id, h.draft_version_id, h.entity_table, h.entity_id, h.field_name, h.old_value, h.new_value,
-    h.submit_id, h.changed_by, h.created_at, u.display_name, u.username
+    h.action, h.submit_id, h.changed_by, h.created_at, u.display_name, u.username
     FROM app_db_field_history h
     LEFT JOIN app_db_users u ON u.id = h.changed_by
     WHERE h.draft_version_id = ? AND h.entity_table = ?`
@@ -187,6 +147,7 @@ func (h *HistoryHandler) ListFieldHistory(c *gin.Context) {
       fieldNameV    sql.NullString
       oldValue      sql.NullString
       newValue      sql.NullString
+      action        sql.NullString
       submitID      sql.NullInt64
       changedBy     sql.NullInt64
       createdAt     sql.NullTime
@@ -202,6 +163,7 @@ func (h *HistoryHandler) ListFieldHistory(c *gin.Context) {
       &fieldNameV,
       &oldValue,
       &newValue,
+      &action,
       &submitID,
       &changedBy,
       &createdAt,
@@ -220,6 +182,7 @@ func (h *HistoryHandler) ListFieldHistory(c *gin.Context) {
       "field_name":       nullableStringValue(fieldNameV),
       "old_value":        nullableStringValue(oldValue),
       "new_value":        nullableStringValue(newValue),
+      "action":           nullableStringValue(action),
       "submit_id":        nullableInt64Pointer(submitID),
       "changed_by":       nullableInt64Pointer(changedBy),
       "changed_name":     nullableStringValue(displayName),
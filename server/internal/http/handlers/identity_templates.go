@@ -516,6 +516,9 @@ func (h *IdentityTemplateHandler) ApplyTemplate(c *gin.Context) {
     c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
     return
   }
+  if !requireDraftEditable(c, h.db, req.DraftVersionID) {
+    return
+  }
   replace := true
   if req.Replace != nil {
     replace = *req.Replace
@@ -541,8 +544,20 @@ func (h *IdentityTemplateHandler) ApplyTemplate(c *gin.Context) {
     _ = tx.Rollback()
   }()
 
+  before, err := snapshotDraftTx(tx, req.DraftVersionID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
   if replace {
-    if _, err := tx.Exec("DELETE FROM app_db_identities WHERE draft_version_id = ?", req.DraftVersionID); err != nil {
+    // Replaced identities go to the trash so they can still be restored.
+    if _, err := tx.Exec(
+      "UPDATE app_db_identities SET deleted_at = ?, deleted_by = ?, row_version = row_version + 1 WHERE draft_version_id = ? AND deleted_at IS NULL",
+      time.Now(),
+      claims.UserID,
+      req.DraftVersionID,
+    ); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "delete failed"})
       return
     }
@@ -613,6 +628,20 @@ func (h *IdentityTemplateHandler) ApplyTemplate(c *gin.Context) {
     inserted++
   }
 
+  after, err := snapshotDraftTx(tx, req.DraftVersionID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if err := recordDraftSnapshotChangesTx(tx, before, after, claims.UserID, map[string]interface{}{
+    "source":      "apply_template",
+    "template_id": req.TemplateID,
+    "replace":     replace,
+  }, now); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+
   if err := tx.Commit(); err != nil {
     c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
     return
@@ -632,23 +661,36 @@ func nullableIntValue(value sql.NullInt64) int64 {
 }
 
 func (h *IdentityTemplateHandler) copyTemplateImage(draftVersionID int64, imagePath string) (string, error) {
-  if !isLocalPath(imagePath) {
-    return imagePath, nil
+  return copyLocalMedia(h.cfg, "identities", draftVersionID, imagePath)
+}
+
+// copyLocalMedia duplicates a local stored file into the upload folder of a draft.
+// Args:
+//   cfg: App config instance.
+//   moduleKey: Upload module folder.
+//   draftVersionID: Target draft version id.
+//   storedPath: Stored path with local prefix; other paths are returned unchanged.
+// Returns:
+//   string: Stored path of the copy.
+//   error: Error when copy fails.
+func copyLocalMedia(cfg *config.Config, moduleKey string, draftVersionID int64, storedPath string) (string, error) {
+  if !isLocalPath(storedPath) {
+    return storedPath, nil
   }
-  relative := trimLocalPrefix(imagePath)
+  relative := trimLocalPrefix(storedPath)
   if strings.TrimSpace(relative) == "" {
-    return imagePath, nil
+    return storedPath, nil
   }
-  sourcePath, err := buildLocalFilePath(h.cfg, relative)
+  sourcePath, err := buildLocalFilePath(cfg, relative)
   if err != nil {
     return "", err
   }
   filename := filepath.Base(relative)
-  targetRelative, err := buildLocalUploadPath("identities", draftVersionID, filename)
+  targetRelative, err := buildLocalUploadPath(moduleKey, draftVersionID, filename)
   if err != nil {
     return "", err
   }
-  targetPath, err := buildLocalFilePath(h.cfg, targetRelative)
+  targetPath, err := buildLocalFilePath(cfg, targetRelative)
   if err != nil {
     return "", err
   }
@@ -3,6 +3,8 @@ package handlers
 import (
   "database/sql"
   "encoding/json"
+  "errors"
+  "fmt"
   "net/http"
   "sort"
   "strings"
@@ -29,6 +31,54 @@ type confirmRequest struct {
   ConfirmedBy  int64 `json:"confirmed_by"`
 }
 
+type restoreRequest struct {
+  SubmitBy int64 `json:"submit_by"`
+}
+
+type rejectRequest struct {
+  SubmissionID int64  `json:"submission_id"`
+  RejectedBy   int64  `json:"rejected_by"`
+  Reason       string `json:"reason"`
+  Revert       bool   `json:"revert"`
+}
+
+var (
+  // ErrSubmissionRejected reports a restore of a rejected submission.
+  ErrSubmissionRejected = errors.New("submission_rejected")
+  // ErrSubmissionPayloadInvalid reports a submission whose snapshot cannot be decoded.
+  ErrSubmissionPayloadInvalid = errors.New("submission payload is invalid")
+  // ErrSubmissionEntityTrashed reports a restore onto a row in the trash.
+  ErrSubmissionEntityTrashed = errors.New("entity is in trash")
+)
+
+// submissionStatusFilters are the statuses accepted by the submission list filter.
+var submissionStatusFilters = map[string]struct{}{
+  "submitted":       {},
+  "pending_confirm": {},
+  "confirmed":       {},
+  "rejected":        {},
+}
+
+// submissionEntityColumns maps submitted entity tables to their writable columns.
+var submissionEntityColumns = map[string][]string{
+  "app_db_banners":            bannerColumns,
+  "app_db_identities":         identityColumns,
+  "app_db_scenes":             sceneColumns,
+  "app_db_clothes_categories": clothesColumns,
+  "app_db_photo_hobbies":      photoHobbyColumns,
+  "app_db_config_extra_steps": extraStepColumns,
+}
+
+// submissionRevertSkipColumns keep row ownership and linkage when writing a snapshot back.
+var submissionRevertSkipColumns = map[string]struct{}{
+  "draft_version_id":    {},
+  "app_version_name_id": {},
+  "created_by":          {},
+  "created_at":          {},
+  "updated_by":          {},
+  "updated_at":          {},
+}
+
 type DiffItem struct {
   Field string      `json:"field"`
   Old   interface{} `json:"old"`
@@ -88,150 +138,331 @@ func (h *SubmissionHandler) Submit(c *gin.Context) {
     _ = tx.Rollback()
   }()
 
-  var (
-    prevID       sql.NullInt64
-    prevBy       sql.NullInt64
-    prevVersion  sql.NullInt64
-    prevPayload  sql.NullString
-  )
+  if !requireDraftEditable(c, tx, req.DraftVersionID) {
+    return
+  }
 
-  prevRow := tx.QueryRow(
-    "SELECT id, submit_by, submit_version, payload_json FROM app_db_submissions WHERE draft_version_id = ? AND module_key = ? AND entity_table = ? AND entity_id = ? ORDER BY submit_version DESC LIMIT 1",
-    req.DraftVersionID,
-    req.ModuleKey,
-    req.EntityTable,
-    req.EntityID,
-  )
-  if err := prevRow.Scan(&prevID, &prevBy, &prevVersion, &prevPayload); err != nil {
-    if err != sql.ErrNoRows {
-      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-      return
-    }
+  result, err := submitEntityTx(tx, req, payloadMap, nil, "submit", time.Now())
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    return
   }
 
-  submitVersion := int64(1)
-  if prevVersion.Valid {
-    submitVersion = prevVersion.Int64 + 1
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
   }
 
-  diffItems := make([]DiffItem, 0)
-  if prevPayload.Valid {
-    prevMap, err := decodePayload([]byte(prevPayload.String))
-    if err == nil {
-      diffItems = BuildPayloadDiff(prevMap, payloadMap)
-    }
+  c.JSON(http.StatusOK, gin.H{
+    "submission_id": result.SubmissionID,
+    "need_confirm":  result.NeedConfirm,
+    "diff":          result.Diff,
+  })
+}
+
+// Confirm marks a submission as confirmed.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *SubmissionHandler) Confirm(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
   }
 
-  needConfirm := false
-  if prevBy.Valid && prevBy.Int64 != req.SubmitBy {
-    needConfirm = true
+  var req confirmRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
   }
 
-  diffJSON := interface{}(nil)
-  if len(diffItems) > 0 {
-    if raw, err := json.Marshal(diffItems); err == nil {
-      diffJSON = string(raw)
-    }
+  if req.SubmissionID <= 0 || req.ConfirmedBy <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
+    return
   }
 
-  status := SubmissionStatus(needConfirm)
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
 
-  result, err := tx.Exec(
-    "INSERT INTO app_db_submissions (draft_version_id, module_key, entity_table, entity_id, submit_version, submit_by, payload_json, diff_json, need_confirm, status, prev_submission_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
-    req.DraftVersionID,
-    req.ModuleKey,
-    req.EntityTable,
-    req.EntityID,
-    submitVersion,
-    req.SubmitBy,
-    string(req.Payload),
-    diffJSON,
-    needConfirm,
-    status,
-    nullableInt64Value(prevID),
-    time.Now(),
+  var (
+    draftVersionID int64
+    moduleKey      string
+    entityTable    string
+    entityID       int64
+    status         sql.NullString
   )
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+
+  row := tx.QueryRow(
+    "SELECT draft_version_id, module_key, entity_table, entity_id, status FROM app_db_submissions WHERE id = ?",
+    req.SubmissionID,
+  )
+  if err := row.Scan(&draftVersionID, &moduleKey, &entityTable, &entityID, &status); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if status.String == "rejected" {
+    c.JSON(http.StatusConflict, gin.H{"error": "submission_rejected"})
     return
   }
 
-  submissionID, err := result.LastInsertId()
+  result, err := tx.Exec(
+    "UPDATE app_db_submissions SET status = ?, confirmed_by = ?, confirmed_at = ? WHERE id = ?",
+    "confirmed",
+    req.ConfirmedBy,
+    time.Now(),
+    req.SubmissionID,
+  )
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "confirm failed"})
     return
   }
 
-  if len(diffItems) > 0 {
-    for _, diff := range diffItems {
-      _, err := tx.Exec(
-        "INSERT INTO app_db_field_history (draft_version_id, entity_table, entity_id, field_name, old_value, new_value, submit_id, changed_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
-        req.DraftVersionID,
-        req.EntityTable,
-        req.EntityID,
-        diff.Field,
-        jsonValue(diff.Old),
-        jsonValue(diff.New),
-        submissionID,
-        req.SubmitBy,
-        time.Now(),
-      )
-      if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "history insert failed"})
-        return
-      }
-    }
+  rows, err := result.RowsAffected()
+  if err != nil || rows == 0 {
+    c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+    return
   }
 
   _, err = tx.Exec(
     "UPDATE app_db_tasks SET status = ?, updated_by = ?, updated_at = ? WHERE draft_version_id = ? AND module_key = ?",
-    status,
-    req.SubmitBy,
+    "confirmed",
+    req.ConfirmedBy,
     time.Now(),
-    req.DraftVersionID,
-    req.ModuleKey,
+    draftVersionID,
+    moduleKey,
   )
   if err != nil {
     c.JSON(http.StatusInternalServerError, gin.H{"error": "update tasks failed"})
     return
   }
 
-  if err := insertTaskActions(tx, req.DraftVersionID, req.ModuleKey, "submit", req.SubmitBy, submissionID); err != nil {
+  if err := insertTaskActions(tx, draftVersionID, moduleKey, "confirm", req.ConfirmedBy, req.SubmissionID); err != nil {
     c.JSON(http.StatusInternalServerError, gin.H{"error": "update task actions failed"})
     return
   }
 
-  if _, err := tx.Exec(
-    "UPDATE app_db_version_names SET last_submit_by = ?, last_submit_at = ?, submit_version = ?, draft_status = ? WHERE id = ?",
-    req.SubmitBy,
+  pendingCount, err := countPendingConfirm(tx, draftVersionID)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query pending failed"})
+    return
+  }
+
+  if pendingCount == 0 {
+    _, err := tx.Exec(
+      "UPDATE app_db_version_names SET confirmed_by = ?, confirmed_at = ? WHERE id = ?",
+      req.ConfirmedBy,
+      time.Now(),
+      draftVersionID,
+    )
+    if err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "update version failed"})
+      return
+    }
+  }
+
+  auditPayload := map[string]interface{}{
+    "module_key":   moduleKey,
+    "entity_table": entityTable,
+    "entity_id":    entityID,
+  }
+
+  raw, err := json.Marshal(auditPayload)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := insertAuditLog(
+    tx,
+    draftVersionID,
+    entityTable,
+    entityID,
+    "confirm",
+    req.ConfirmedBy,
+    string(raw),
     time.Now(),
-    submitVersion,
-    status,
-    req.DraftVersionID,
   ); err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "update version failed"})
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
     return
   }
 
-  auditPayload := map[string]interface{}{
-    "module_key":     req.ModuleKey,
-    "entity_table":   req.EntityTable,
-    "entity_id":      req.EntityID,
-    "submit_version": submitVersion,
-    "need_confirm":   needConfirm,
+  if err := tx.Commit(); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+    return
   }
 
-  if raw, err := json.Marshal(auditPayload); err == nil {
-    _, _ = tx.Exec(
-      "INSERT INTO app_db_audit_logs (draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-      req.DraftVersionID,
-      req.EntityTable,
-      req.EntityID,
-      "submit",
-      req.SubmitBy,
-      string(raw),
-      time.Now(),
-    )
+  c.JSON(http.StatusOK, gin.H{"status": "confirmed"})
+}
+
+// Reject marks a submission as rejected, optionally reverting the entity to the previous submission.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *SubmissionHandler) Reject(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  var req rejectRequest
+  if err := c.ShouldBindJSON(&req); err != nil {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+    return
+  }
+
+  req.Reason = strings.TrimSpace(req.Reason)
+  if req.SubmissionID <= 0 || req.RejectedBy <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
+    return
+  }
+  if req.Reason == "" {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required"})
+    return
+  }
+
+  tx, err := h.db.Begin()
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+    return
+  }
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  var (
+    draftVersionID int64
+    moduleKey      string
+    entityTable    string
+    entityID       int64
+    submitBy       int64
+    status         sql.NullString
+    prevSubmission sql.NullInt64
+  )
+
+  row := tx.QueryRow(
+    "SELECT draft_version_id, module_key, entity_table, entity_id, submit_by, status, prev_submission_id FROM app_db_submissions WHERE id = ? FOR UPDATE",
+    req.SubmissionID,
+  )
+  if err := row.Scan(&draftVersionID, &moduleKey, &entityTable, &entityID, &submitBy, &status, &prevSubmission); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  if status.String != "submitted" && status.String != "pending_confirm" {
+    c.JSON(http.StatusConflict, gin.H{"error": "invalid_status", "status": status.String})
+    return
+  }
+
+  now := time.Now()
+  reverted := []string{}
+  if req.Revert {
+    if !requireDraftEditable(c, tx, draftVersionID) {
+      return
+    }
+    if !prevSubmission.Valid {
+      c.JSON(http.StatusConflict, gin.H{"error": "no_previous_submission"})
+      return
+    }
+    var prevPayload sql.NullString
+    if err := tx.QueryRow("SELECT payload_json FROM app_db_submissions WHERE id = ?", prevSubmission.Int64).Scan(&prevPayload); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+      return
+    }
+    prevMap, err := decodePayload([]byte(prevPayload.String))
+    if err != nil {
+      c.JSON(http.StatusConflict, gin.H{"error": "previous payload is invalid"})
+      return
+    }
+    restored, err := BuildSubmissionRevertPayload(entityTable, prevMap)
+    if err != nil {
+      c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+      return
+    }
+    fields, before, after, err := writeSubmissionPayloadTx(tx, entityTable, entityID, restored, req.RejectedBy, now)
+    if err != nil {
+      if err == sql.ErrNoRows {
+        c.JSON(http.StatusConflict, gin.H{"error": "entity not found"})
+        return
+      }
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "revert failed"})
+      return
+    }
+    if err := recordDraftChangeTx(tx, entityTable, entityID, "revert", req.RejectedBy, before, after, nil, now); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+      return
+    }
+    reverted = fields
+  }
+
+  if _, err := tx.Exec(
+    "UPDATE app_db_submissions SET status = ?, rejected_by = ?, rejected_at = ?, reject_reason = ?, reverted = ? WHERE id = ?",
+    "rejected",
+    req.RejectedBy,
+    now,
+    req.Reason,
+    req.Revert,
+    req.SubmissionID,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "reject failed"})
+    return
+  }
+
+  taskID, err := reopenSubmitterTask(tx, draftVersionID, moduleKey, entityTable, entityID, submitBy, req.RejectedBy, req.Reason, now)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update tasks failed"})
+    return
+  }
+  if err := insertTaskAction(tx, taskID, "reject", req.RejectedBy, map[string]interface{}{
+    "submission_id": req.SubmissionID,
+    "module_key":    moduleKey,
+    "reason":        req.Reason,
+    "reverted":      req.Revert,
+  }); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update task actions failed"})
+    return
+  }
+
+  auditPayload := map[string]interface{}{
+    "submission_id":   req.SubmissionID,
+    "module_key":      moduleKey,
+    "entity_table":    entityTable,
+    "entity_id":       entityID,
+    "reason":          req.Reason,
+    "reverted":        req.Revert,
+    "reverted_fields": reverted,
+    "task_id":         taskID,
+  }
+  raw, err := json.Marshal(auditPayload)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
+  }
+  if err := insertAuditLog(
+    tx,
+    draftVersionID,
+    entityTable,
+    entityID,
+    "reject",
+    req.RejectedBy,
+    string(raw),
+    now,
+  ); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+    return
   }
 
   if err := tx.Commit(); err != nil {
@@ -240,30 +471,36 @@ func (h *SubmissionHandler) Submit(c *gin.Context) {
   }
 
   c.JSON(http.StatusOK, gin.H{
-    "submission_id": submissionID,
-    "need_confirm":  needConfirm,
-    "diff":          diffItems,
+    "status":          "rejected",
+    "task_id":         taskID,
+    "reverted":        req.Revert,
+    "reverted_fields": reverted,
   })
 }
 
-// Confirm marks a submission as confirmed.
+// Restore writes an earlier submission back to its entity and records it as a new submission version.
 // Args:
 //   c: Gin context.
 // Returns:
 //   None.
-func (h *SubmissionHandler) Confirm(c *gin.Context) {
+func (h *SubmissionHandler) Restore(c *gin.Context) {
   if h.db == nil {
     c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
     return
   }
 
-  var req confirmRequest
+  id := parseInt64Param(c, "id")
+  if id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+
+  var req restoreRequest
   if err := c.ShouldBindJSON(&req); err != nil {
     c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
     return
   }
-
-  if req.SubmissionID <= 0 || req.ConfirmedBy <= 0 {
+  if req.SubmitBy <= 0 {
     c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
     return
   }
@@ -282,13 +519,16 @@ func (h *SubmissionHandler) Confirm(c *gin.Context) {
     moduleKey      string
     entityTable    string
     entityID       int64
+    submitVersion  int64
+    status         sql.NullString
+    payloadJSON    sql.NullString
   )
 
   row := tx.QueryRow(
-    "SELECT draft_version_id, module_key, entity_table, entity_id FROM app_db_submissions WHERE id = ?",
-    req.SubmissionID,
+    "SELECT draft_version_id, module_key, entity_table, entity_id, submit_version, status, payload_json FROM app_db_submissions WHERE id = ?",
+    id,
   )
-  if err := row.Scan(&draftVersionID, &moduleKey, &entityTable, &entityID); err != nil {
+  if err := row.Scan(&draftVersionID, &moduleKey, &entityTable, &entityID, &submitVersion, &status, &payloadJSON); err != nil {
     if err == sql.ErrNoRows {
       c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
       return
@@ -296,80 +536,71 @@ func (h *SubmissionHandler) Confirm(c *gin.Context) {
     c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
     return
   }
-
-  result, err := tx.Exec(
-    "UPDATE app_db_submissions SET status = ?, confirmed_by = ?, confirmed_at = ? WHERE id = ?",
-    "confirmed",
-    req.ConfirmedBy,
-    time.Now(),
-    req.SubmissionID,
-  )
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "confirm failed"})
+  if !requireDraftEditable(c, tx, draftVersionID) {
     return
   }
 
-  rows, err := result.RowsAffected()
-  if err != nil || rows == 0 {
-    c.JSON(http.StatusNotFound, gin.H{"error": "submission not found"})
+  // Lock the entity row before reading it so the diff matches what gets overwritten.
+  var lockedID int64
+  if err := tx.QueryRow("SELECT id FROM "+quoteSQLIdent(entityTable)+" WHERE id = ? FOR UPDATE", entityID).Scan(&lockedID); err != nil && err != sql.ErrNoRows {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
     return
   }
-
-  _, err = tx.Exec(
-    "UPDATE app_db_tasks SET status = ?, updated_by = ?, updated_at = ? WHERE draft_version_id = ? AND module_key = ?",
-    "confirmed",
-    req.ConfirmedBy,
-    time.Now(),
-    draftVersionID,
-    moduleKey,
-  )
+  current, _, err := loadDraftEntityRow(tx, entityTable, "id", entityID)
   if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "update tasks failed"})
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
     return
   }
 
-  if err := insertTaskActions(tx, draftVersionID, moduleKey, "confirm", req.ConfirmedBy, req.SubmissionID); err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "update task actions failed"})
+  plan, err := BuildSubmissionRestorePlan(entityTable, status.String, payloadJSON.String, current)
+  if err != nil {
+    switch {
+    case errors.Is(err, ErrSubmissionRejected):
+      c.JSON(http.StatusConflict, gin.H{"error": "submission_rejected"})
+    case errors.Is(err, ErrSubmissionPayloadInvalid):
+      c.JSON(http.StatusConflict, gin.H{"error": "submission payload is invalid"})
+    case errors.Is(err, ErrSubmissionEntityTrashed):
+      c.JSON(http.StatusConflict, gin.H{"error": "entity is in trash"})
+    default:
+      c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+    }
     return
   }
-
-  pendingCount, err := countPendingConfirm(tx, draftVersionID)
-  if err != nil {
-    c.JSON(http.StatusInternalServerError, gin.H{"error": "query pending failed"})
+  if len(plan.Changes) == 0 {
+    c.JSON(http.StatusOK, gin.H{"restored_from_id": id, "unchanged": true, "diff": plan.Changes})
     return
   }
 
-  if pendingCount == 0 {
-    _, err := tx.Exec(
-      "UPDATE app_db_version_names SET draft_status = ?, confirmed_by = ?, confirmed_at = ? WHERE id = ?",
-      "confirmed",
-      req.ConfirmedBy,
-      time.Now(),
-      draftVersionID,
-    )
-    if err != nil {
-      c.JSON(http.StatusInternalServerError, gin.H{"error": "update version failed"})
+  // The restore submission below writes the field history and audit row of this write.
+  now := time.Now()
+  if _, _, _, err := writeSubmissionPayloadTx(tx, entityTable, entityID, plan.Restored, req.SubmitBy, now); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "entity not found"})
       return
     }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "restore failed"})
+    return
   }
 
-  auditPayload := map[string]interface{}{
-    "module_key":   moduleKey,
-    "entity_table": entityTable,
-    "entity_id":    entityID,
+  result, err := submitEntityTx(tx, submitRequest{
+    DraftVersionID: draftVersionID,
+    ModuleKey:      moduleKey,
+    EntityTable:    entityTable,
+    EntityID:       entityID,
+    SubmitBy:       req.SubmitBy,
+  }, plan.Payload, plan.Base, "restore", now)
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+    return
   }
 
-  if raw, err := json.Marshal(auditPayload); err == nil {
-    _, _ = tx.Exec(
-      "INSERT INTO app_db_audit_logs (draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-      draftVersionID,
-      entityTable,
-      entityID,
-      "confirm",
-      req.ConfirmedBy,
-      string(raw),
-      time.Now(),
-    )
+  if _, err := tx.Exec("UPDATE app_db_submissions SET restored_from_id = ? WHERE id = ?", id, result.SubmissionID); err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+    return
   }
 
   if err := tx.Commit(); err != nil {
@@ -377,7 +608,14 @@ func (h *SubmissionHandler) Confirm(c *gin.Context) {
     return
   }
 
-  c.JSON(http.StatusOK, gin.H{"status": "confirmed"})
+  c.JSON(http.StatusOK, gin.H{
+    "submission_id":    result.SubmissionID,
+    "submit_version":   result.SubmitVersion,
+    "restored_from_id": id,
+    "restored_version": submitVersion,
+    "need_confirm":     result.NeedConfirm,
+    "diff":             plan.Changes,
+  })
 }
 
 // List returns submission history.
@@ -395,18 +633,25 @@ func (h *SubmissionHandler) List(c *gin.Context) {
   moduleKey := strings.TrimSpace(c.Query("module_key"))
   entityTable := strings.TrimSpace(c.Query("entity_table"))
   entityID := parseInt64Query(c, "entity_id")
+  status := strings.ToLower(strings.TrimSpace(c.Query("status")))
 
   if draftID <= 0 || moduleKey == "" || entityTable == "" {
     c.JSON(http.StatusBadRequest, gin.H{"error": "missing required fields"})
     return
   }
+  if _, ok := submissionStatusFilters[status]; status != "" && !ok {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid status"})
+    return
+  }
 
   query := `SELECT s.id, s.module_key, s.entity_table, s.entity_id, s.submit_version, s.submit_by, s.need_confirm, s.status,
-    s.prev_submission_id, s.confirmed_by, s.confirmed_at, s.created_at, s.diff_json,
-    su.display_name, su.username, cu.display_name, cu.username
+    s.prev_submission_id, s.restored_from_id, s.confirmed_by, s.confirmed_at, s.created_at, s.diff_json,
+    s.rejected_by, s.rejected_at, s.reject_reason, s.reverted,
+    su.display_name, su.username, cu.display_name, cu.username, ru.display_name, ru.username
     FROM app_db_submissions s
     LEFT JOIN app_db_users su ON su.id = s.submit_by
     LEFT JOIN app_db_users cu ON cu.id = s.confirmed_by
+    LEFT JOIN app_db_users ru ON ru.id = s.rejected_by
     WHERE s.draft_version_id = ? AND s.module_key = ? AND s.entity_table = ?`
   args := []interface{}{draftID, moduleKey, entityTable}
 
@@ -414,6 +659,10 @@ func (h *SubmissionHandler) List(c *gin.Context) {
     query += " AND entity_id = ?"
     args = append(args, entityID)
   }
+  if status != "" {
+    query += " AND s.status = ?"
+    args = append(args, status)
+  }
 
   query += " ORDER BY s.submit_version DESC"
 
@@ -434,16 +683,23 @@ func (h *SubmissionHandler) List(c *gin.Context) {
       submitVersion   int64
       submitBy        int64
       needConfirm     bool
-      status          string
+      itemStatus      string
       prevSubmission  sql.NullInt64
+      restoredFrom    sql.NullInt64
       confirmedBy     sql.NullInt64
       confirmedAt     sql.NullTime
       createdAt       time.Time
       diffJSON        sql.NullString
+      rejectedBy      sql.NullInt64
+      rejectedAt      sql.NullTime
+      rejectReason    sql.NullString
+      reverted        bool
       submitName      sql.NullString
       submitUsername  sql.NullString
       confirmName     sql.NullString
       confirmUsername sql.NullString
+      rejectName      sql.NullString
+      rejectUsername  sql.NullString
     )
 
     if err := rows.Scan(
@@ -454,16 +710,23 @@ func (h *SubmissionHandler) List(c *gin.Context) {
       &submitVersion,
       &submitBy,
       &needConfirm,
-      &status,
+      &itemStatus,
       &prevSubmission,
+      &restoredFrom,
       &confirmedBy,
       &confirmedAt,
       &createdAt,
       &diffJSON,
+      &rejectedBy,
+      &rejectedAt,
+      &rejectReason,
+      &reverted,
       &submitName,
       &submitUsername,
       &confirmName,
       &confirmUsername,
+      &rejectName,
+      &rejectUsername,
     ); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
@@ -479,12 +742,19 @@ func (h *SubmissionHandler) List(c *gin.Context) {
       "submit_name":        nullableStringValue(submitName),
       "submit_username":    nullableStringValue(submitUsername),
       "need_confirm":       needConfirm,
-      "status":             status,
+      "status":             itemStatus,
       "prev_submission_id": nullableInt64Pointer(prevSubmission),
+      "restored_from_id":   nullableInt64Pointer(restoredFrom),
       "confirmed_by":       nullableInt64Pointer(confirmedBy),
       "confirmed_name":     nullableStringValue(confirmName),
       "confirmed_username": nullableStringValue(confirmUsername),
       "confirmed_at":       nullableTimePointer(confirmedAt),
+      "rejected_by":        nullableInt64Pointer(rejectedBy),
+      "rejected_name":      nullableStringValue(rejectName),
+      "rejected_username":  nullableStringValue(rejectUsername),
+      "rejected_at":        nullableTimePointer(rejectedAt),
+      "reject_reason":      nullableStringValue(rejectReason),
+      "reverted":           reverted,
       "created_at":         createdAt,
       "diff":               decodeDiff(diffJSON),
     })
@@ -493,6 +763,191 @@ func (h *SubmissionHandler) List(c *gin.Context) {
   c.JSON(http.StatusOK, gin.H{"data": items})
 }
 
+type submitEntityResult struct {
+  SubmissionID  int64
+  SubmitVersion int64
+  NeedConfirm   bool
+  Diff          []DiffItem
+}
+
+// submitEntityTx records a submission snapshot with its field history, task status and audit entry.
+// Args:
+//   tx: Active transaction.
+//   req: Submission target and submitter.
+//   payloadMap: Decoded entity payload.
+//   basePayload: Payload diffed against when the entity has no previous submission (nil for none).
+//   action: Task action and audit action name.
+//   now: Submission time.
+// Returns:
+//   submitEntityResult: Submission id, version, confirm flag and diff.
+//   error: Error named after the failed step.
+func submitEntityTx(tx *sql.Tx, req submitRequest, payloadMap, basePayload map[string]interface{}, action string, now time.Time) (submitEntityResult, error) {
+  var (
+    prevID       sql.NullInt64
+    prevBy       sql.NullInt64
+    prevVersion  sql.NullInt64
+    prevPayload  sql.NullString
+  )
+
+  prevRow := tx.QueryRow(
+    "SELECT id, submit_by, submit_version, payload_json FROM app_db_submissions WHERE draft_version_id = ? AND module_key = ? AND entity_table = ? AND entity_id = ? ORDER BY submit_version DESC LIMIT 1",
+    req.DraftVersionID,
+    req.ModuleKey,
+    req.EntityTable,
+    req.EntityID,
+  )
+  if err := prevRow.Scan(&prevID, &prevBy, &prevVersion, &prevPayload); err != nil {
+    if err != sql.ErrNoRows {
+      return submitEntityResult{}, errors.New("query failed")
+    }
+  }
+
+  submitVersion := int64(1)
+  if prevVersion.Valid {
+    submitVersion = prevVersion.Int64 + 1
+  }
+
+  diffItems := make([]DiffItem, 0)
+  if prevPayload.Valid {
+    prevMap, err := decodePayload([]byte(prevPayload.String))
+    if err == nil {
+      diffItems = BuildPayloadDiff(prevMap, payloadMap)
+    }
+  } else if basePayload != nil {
+    diffItems = BuildPayloadDiff(basePayload, payloadMap)
+  }
+
+  needConfirm := false
+  if prevBy.Valid && prevBy.Int64 != req.SubmitBy {
+    needConfirm = true
+  }
+
+  diffJSON := interface{}(nil)
+  if len(diffItems) > 0 {
+    if raw, err := json.Marshal(diffItems); err == nil {
+      diffJSON = string(raw)
+    }
+  }
+
+  payloadJSON := string(req.Payload)
+  if len(req.Payload) == 0 {
+    raw, err := json.Marshal(payloadMap)
+    if err != nil {
+      return submitEntityResult{}, errors.New("insert failed")
+    }
+    payloadJSON = string(raw)
+  }
+
+  status := SubmissionStatus(needConfirm)
+
+  result, err := tx.Exec(
+    "INSERT INTO app_db_submissions (draft_version_id, module_key, entity_table, entity_id, submit_version, submit_by, payload_json, diff_json, need_confirm, status, prev_submission_id, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+    req.DraftVersionID,
+    req.ModuleKey,
+    req.EntityTable,
+    req.EntityID,
+    submitVersion,
+    req.SubmitBy,
+    payloadJSON,
+    diffJSON,
+    needConfirm,
+    status,
+    nullableInt64Value(prevID),
+    now,
+  )
+  if err != nil {
+    return submitEntityResult{}, errors.New("insert failed")
+  }
+
+  submissionID, err := result.LastInsertId()
+  if err != nil {
+    return submitEntityResult{}, errors.New("insert failed")
+  }
+
+  for _, diff := range diffItems {
+    _, err := tx.Exec(
+      "INSERT INTO app_db_field_history (draft_version_id, entity_table, entity_id, field_name, old_value, new_value, action, submit_id, changed_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+      req.DraftVersionID,
+      req.EntityTable,
+      req.EntityID,
+      diff.Field,
+      jsonValue(diff.Old),
+      jsonValue(diff.New),
+      action,
+      submissionID,
+      req.SubmitBy,
+      now,
+    )
+    if err != nil {
+      return submitEntityResult{}, errors.New("history insert failed")
+    }
+  }
+
+  _, err = tx.Exec(
+    "UPDATE app_db_tasks SET status = ?, updated_by = ?, updated_at = ? WHERE draft_version_id = ? AND module_key = ?",
+    status,
+    req.SubmitBy,
+    now,
+    req.DraftVersionID,
+    req.ModuleKey,
+  )
+  if err != nil {
+    return submitEntityResult{}, errors.New("update tasks failed")
+  }
+
+  if err := insertTaskActions(tx, req.DraftVersionID, req.ModuleKey, action, req.SubmitBy, submissionID); err != nil {
+    return submitEntityResult{}, errors.New("update task actions failed")
+  }
+
+  if _, err := tx.Exec(
+    "UPDATE app_db_version_names SET last_submit_by = ?, last_submit_at = ?, submit_version = ? WHERE id = ?",
+    req.SubmitBy,
+    now,
+    submitVersion,
+    req.DraftVersionID,
+  ); err != nil {
+    return submitEntityResult{}, errors.New("update version failed")
+  }
+
+  auditPayload := map[string]interface{}{
+    "module_key":     req.ModuleKey,
+    "entity_table":   req.EntityTable,
+    "entity_id":      req.EntityID,
+    "submit_version": submitVersion,
+    "need_confirm":   needConfirm,
+  }
+
+  raw, err := json.Marshal(auditPayload)
+  if err != nil {
+    return submitEntityResult{}, errors.New("audit failed")
+  }
+  if err := insertAuditLog(
+    tx,
+    req.DraftVersionID,
+    req.EntityTable,
+    req.EntityID,
+    action,
+    req.SubmitBy,
+    string(raw),
+    now,
+  ); err != nil {
+    return submitEntityResult{}, errors.New("audit failed")
+  }
+
+  if len(diffItems) > 0 {
+    if err := resetDraftApprovalsTx(tx, req.DraftVersionID, req.SubmitBy, now); err != nil {
+      return submitEntityResult{}, errors.New("reset approvals failed")
+    }
+  }
+
+  return submitEntityResult{
+    SubmissionID:  submissionID,
+    SubmitVersion: submitVersion,
+    NeedConfirm:   needConfirm,
+    Diff:          diffItems,
+  }, nil
+}
+
 // BuildPayloadDiff computes differences between two payloads.
 // Args:
 //   prev: Previous payload.
@@ -604,6 +1059,179 @@ func insertTaskActions(tx *sql.Tx, draftVersionID int64, moduleKey, action strin
   return nil
 }
 
+// BuildSubmissionRevertPayload keeps the writable fields of a submitted snapshot.
+// Args:
+//   entityTable: Submitted entity table.
+//   payload: Snapshot payload.
+// Returns:
+//   map[string]interface{}: Fields to write back, without linkage and bookkeeping columns.
+//   error: Error when the table is not revertible or nothing is left to write.
+func BuildSubmissionRevertPayload(entityTable string, payload map[string]interface{}) (map[string]interface{}, error) {
+  allowed, ok := submissionEntityColumns[entityTable]
+  if !ok {
+    return nil, errors.New("entity table is not revertible")
+  }
+  filtered := FilterPayload(payload, allowed)
+  for key := range submissionRevertSkipColumns {
+    delete(filtered, key)
+  }
+  if len(filtered) == 0 {
+    return nil, errors.New("empty payload")
+  }
+  return filtered, nil
+}
+
+// SubmissionRestorePlan is what restoring a submission writes back to its entity.
+type SubmissionRestorePlan struct {
+  Payload  map[string]interface{}
+  Restored map[string]interface{}
+  Base     map[string]interface{}
+  Changes  []DiffItem
+}
+
+// BuildSubmissionRestorePlan checks that a submission can be restored and compares it with the current entity row.
+// Args:
+//   entityTable: Submitted entity table.
+//   status: Submission status.
+//   payloadJSON: Submitted snapshot.
+//   current: Current entity row.
+// Returns:
+//   SubmissionRestorePlan: Decoded payload, fields to write, their current values and the changes; no changes means the row already matches.
+//   error: ErrSubmissionRejected, ErrSubmissionPayloadInvalid, ErrSubmissionEntityTrashed or a BuildSubmissionRevertPayload error.
+func BuildSubmissionRestorePlan(entityTable, status, payloadJSON string, current map[string]interface{}) (SubmissionRestorePlan, error) {
+  if status == "rejected" {
+    return SubmissionRestorePlan{}, ErrSubmissionRejected
+  }
+  payload, err := decodePayload([]byte(payloadJSON))
+  if err != nil {
+    return SubmissionRestorePlan{}, ErrSubmissionPayloadInvalid
+  }
+  restored, err := BuildSubmissionRevertPayload(entityTable, payload)
+  if err != nil {
+    return SubmissionRestorePlan{}, err
+  }
+  if current["deleted_at"] != nil {
+    return SubmissionRestorePlan{}, ErrSubmissionEntityTrashed
+  }
+  base := make(map[string]interface{}, len(restored))
+  for field := range restored {
+    base[field] = current[field]
+  }
+  return SubmissionRestorePlan{
+    Payload:  payload,
+    Restored: restored,
+    Base:     base,
+    Changes:  BuildPayloadDiff(base, restored),
+  }, nil
+}
+
+// writeSubmissionPayloadTx writes a snapshot back to its entity row and bumps the row revision.
+// The caller records the change, either directly or through the submission it creates.
+// Args:
+//   tx: Active transaction.
+//   entityTable: Entity table.
+//   entityID: Entity id.
+//   payload: Fields from BuildSubmissionRevertPayload.
+//   actorID: Operator id.
+//   now: Write time.
+// Returns:
+//   []string: Written field names.
+//   map[string]interface{}: Row image before the write.
+//   map[string]interface{}: Row image after the write.
+//   error: sql.ErrNoRows when the row is missing or deleted.
+func writeSubmissionPayloadTx(tx *sql.Tx, entityTable string, entityID int64, payload map[string]interface{}, actorID int64, now time.Time) ([]string, map[string]interface{}, map[string]interface{}, error) {
+  current, err := queryDraftEntityRows(tx, "SELECT * FROM "+quoteSQLIdent(entityTable)+" WHERE id = ? AND deleted_at IS NULL FOR UPDATE", entityID)
+  if err != nil {
+    return nil, nil, nil, err
+  }
+  if len(current) == 0 {
+    return nil, nil, nil, sql.ErrNoRows
+  }
+  before := current[0]
+  revision := parseID(before[draftRevisionColumn])
+
+  fields := make([]string, 0, len(payload))
+  for key := range payload {
+    fields = append(fields, key)
+  }
+  sort.Strings(fields)
+
+  payload["updated_by"] = actorID
+  payload["updated_at"] = now
+  sqlText, args, err := BuildRevisionedUpdateSQL(entityTable, "id", entityID, revision, payload)
+  if err != nil {
+    return nil, nil, nil, err
+  }
+  if _, err := tx.Exec(sqlText, args...); err != nil {
+    return nil, nil, nil, err
+  }
+  after, err := lockDraftEntityRow(tx, entityTable, entityID)
+  if err != nil {
+    return nil, nil, nil, err
+  }
+  return fields, before, after, nil
+}
+
+// reopenSubmitterTask reopens the submitter's task for a module, creating one when none exists.
+// Args:
+//   tx: Active transaction.
+//   draftVersionID: Draft version id.
+//   moduleKey: Module key.
+//   entityTable: Rejected entity table.
+//   entityID: Rejected entity id.
+//   submitBy: Submitter id.
+//   actorID: Reviewer id.
+//   reason: Reject reason.
+//   now: Update time.
+// Returns:
+//   int64: Task id.
+//   error: Error when query or write fails.
+func reopenSubmitterTask(tx *sql.Tx, draftVersionID int64, moduleKey, entityTable string, entityID, submitBy, actorID int64, reason string, now time.Time) (int64, error) {
+  var taskID int64
+  err := tx.QueryRow(
+    "SELECT id FROM app_db_tasks WHERE draft_version_id = ? AND module_key = ? AND assigned_to = ? ORDER BY id DESC LIMIT 1",
+    draftVersionID,
+    moduleKey,
+    submitBy,
+  ).Scan(&taskID)
+  if err != nil && err != sql.ErrNoRows {
+    return 0, err
+  }
+
+  if err == nil {
+    if _, err := tx.Exec(
+      "UPDATE app_db_tasks SET status = ?, updated_by = ?, updated_at = ? WHERE id = ?",
+      "open",
+      actorID,
+      now,
+      taskID,
+    ); err != nil {
+      return 0, err
+    }
+    return taskID, nil
+  }
+
+  result, err := tx.Exec(
+    "INSERT INTO app_db_tasks (draft_version_id, module_key, title, description, status, assigned_to, allow_assist, priority, created_by, updated_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+    draftVersionID,
+    moduleKey,
+    fmt.Sprintf("修改被驳回：%s #%d", entityTable, entityID),
+    reason,
+    "open",
+    submitBy,
+    false,
+    0,
+    actorID,
+    actorID,
+    now,
+    now,
+  )
+  if err != nil {
+    return 0, err
+  }
+  return result.LastInsertId()
+}
+
 // countPendingConfirm counts pending confirmations for a draft version.
 // Args:
 //   tx: Active transaction.
@@ -20,6 +20,7 @@ type draftBannerRow struct {
 	Sort     sql.NullInt64
 	IsActive sql.NullInt64
 	Type     sql.NullInt64
+	TargetID sql.NullInt64
 }
 
 type draftIdentityRow struct {
@@ -107,7 +108,7 @@ type draftData struct {
 	ExtraSteps        []draftExtraStepRow
 }
 
-func loadDraftVersion(db *sql.DB, draftVersionID int64) (draftVersionRow, error) {
+func loadDraftVersion(db sqlRowQueryer, draftVersionID int64) (draftVersionRow, error) {
 	row := db.QueryRow(
 		"SELECT app_version_name, location_name, feishu_field_names, ai_modal, status FROM app_db_version_names WHERE id = ?",
 		draftVersionID,
@@ -134,7 +135,7 @@ func findAppVersionNameID(db *sql.DB, appVersionName string) (int64, error) {
 	return id, nil
 }
 
-func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
+func loadDraftData(db sqlReader, draftVersionID, syncTargetID int64) (draftData, error) {
 	data := draftData{
 		Banners:           make([]draftBannerRow, 0),
 		Identities:        make([]draftIdentityRow, 0),
@@ -145,7 +146,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	appUIRow := db.QueryRow(
-		"SELECT f.id, f.home_title_left, f.home_title_right, f.home_subtitle, f.start_experience, f.step1_music, f.step1_music_text, f.step1_title, f.step2_music, f.step2_music_text, f.step2_title, f.status, f.print_wait, m.target_row_id FROM app_db_app_ui_fields f LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = f.draft_version_id AND m.module_key = 'app_ui_fields' AND m.draft_row_id = f.id WHERE f.draft_version_id = ?",
+		"SELECT f.id, f.home_title_left, f.home_title_right, f.home_subtitle, f.start_experience, f.step1_music, f.step1_music_text, f.step1_title, f.step2_music, f.step2_music_text, f.step2_title, f.status, f.print_wait, m.target_row_id FROM app_db_app_ui_fields f LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = f.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'app_ui_fields' AND m.draft_row_id = f.id WHERE f.draft_version_id = ?",
+		syncTargetID,
 		draftVersionID,
 	)
 	var appUI draftAppUIRow
@@ -171,13 +173,14 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT id, title, image, sort, is_active, type FROM app_db_banners WHERE draft_version_id = ?",
+		"SELECT b.id, b.title, b.image, b.sort, b.is_active, b.type, m.target_row_id FROM app_db_banners b LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = b.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'banners' AND m.draft_row_id = b.id WHERE b.draft_version_id = ? AND b.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
 		for rows.Next() {
 			var row draftBannerRow
-			if err := rows.Scan(&row.ID, &row.Title, &row.Image, &row.Sort, &row.IsActive, &row.Type); err != nil {
+			if err := rows.Scan(&row.ID, &row.Title, &row.Image, &row.Sort, &row.IsActive, &row.Type, &row.TargetID); err != nil {
 				return data, err
 			}
 			data.Banners = append(data.Banners, row)
@@ -187,7 +190,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT i.id, i.name, i.image, i.sort, i.status, m.target_row_id FROM app_db_identities i LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = i.draft_version_id AND m.module_key = 'identities' AND m.draft_row_id = i.id WHERE i.draft_version_id = ?",
+		"SELECT i.id, i.name, i.image, i.sort, i.status, m.target_row_id FROM app_db_identities i LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = i.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'identities' AND m.draft_row_id = i.id WHERE i.draft_version_id = ? AND i.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
@@ -203,7 +207,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT s.id, s.name, s.image, s.`desc`, s.music, s.watermark_path, s.need_watermark, s.sort, s.status, s.oss_style, m.target_row_id FROM app_db_scenes s LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = s.draft_version_id AND m.module_key = 'scenes' AND m.draft_row_id = s.id WHERE s.draft_version_id = ?",
+		"SELECT s.id, s.name, s.image, s.`desc`, s.music, s.watermark_path, s.need_watermark, s.sort, s.status, s.oss_style, m.target_row_id FROM app_db_scenes s LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = s.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'scenes' AND m.draft_row_id = s.id WHERE s.draft_version_id = ? AND s.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
@@ -231,7 +236,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT c.id, c.name, c.image, c.sort, c.status, c.music, c.`desc`, c.music_text, m.target_row_id FROM app_db_clothes_categories c LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = c.draft_version_id AND m.module_key = 'clothes_categories' AND m.draft_row_id = c.id WHERE c.draft_version_id = ?",
+		"SELECT c.id, c.name, c.image, c.sort, c.status, c.music, c.`desc`, c.music_text, m.target_row_id FROM app_db_clothes_categories c LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = c.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'clothes_categories' AND m.draft_row_id = c.id WHERE c.draft_version_id = ? AND c.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
@@ -257,7 +263,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT p.id, p.name, p.image, p.sort, p.status, p.music, p.music_text, p.`desc`, m.target_row_id FROM app_db_photo_hobbies p LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = p.draft_version_id AND m.module_key = 'photo_hobbies' AND m.draft_row_id = p.id WHERE p.draft_version_id = ?",
+		"SELECT p.id, p.name, p.image, p.sort, p.status, p.music, p.music_text, p.`desc`, m.target_row_id FROM app_db_photo_hobbies p LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = p.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'photo_hobbies' AND m.draft_row_id = p.id WHERE p.draft_version_id = ? AND p.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
@@ -283,7 +290,8 @@ func loadDraftData(db *sql.DB, draftVersionID int64) (draftData, error) {
 	}
 
 	if rows, err := db.Query(
-		"SELECT e.id, e.step_index, e.field_name, e.label, e.music, e.music_text, e.status, m.target_row_id FROM app_db_config_extra_steps e LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = e.draft_version_id AND m.module_key = 'config_extra_steps' AND m.draft_row_id = e.id WHERE e.draft_version_id = ?",
+		"SELECT e.id, e.step_index, e.field_name, e.label, e.music, e.music_text, e.status, m.target_row_id FROM app_db_config_extra_steps e LEFT JOIN app_db_sync_id_map m ON m.draft_version_id = e.draft_version_id AND m.sync_target_id = ? AND m.module_key = 'config_extra_steps' AND m.draft_row_id = e.id WHERE e.draft_version_id = ? AND e.deleted_at IS NULL",
+		syncTargetID,
 		draftVersionID,
 	); err == nil {
 		defer rows.Close()
@@ -0,0 +1,742 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shushu-app-ui-dashboard/internal/config"
+	"shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const (
+	syncDriftAddedOnline    = "added_online"
+	syncDriftRemovedOnline  = "removed_online"
+	syncDriftModifiedOnline = "modified_online"
+
+	syncDriftVersionMissing = "online version not found"
+)
+
+// SyncDriftStats counts online rows that diverged from the draft since the last sync.
+// Unsynced counts draft rows that were never pushed and are not drift.
+type SyncDriftStats struct {
+	Added    int `json:"added"`
+	Removed  int `json:"removed"`
+	Modified int `json:"modified"`
+	Unsynced int `json:"unsynced"`
+}
+
+// SyncDriftRow is one online row that differs from the draft; Old is the draft value, New the online value.
+type SyncDriftRow struct {
+	Action   string            `json:"action"`
+	DraftID  int64             `json:"draft_id,omitempty"`
+	TargetID int64             `json:"target_id,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Fields   []SyncFieldChange `json:"fields"`
+}
+
+// SyncDriftModule is the drift report of one module.
+type SyncDriftModule struct {
+	Drifted bool           `json:"drifted"`
+	Stats   SyncDriftStats `json:"stats"`
+	Rows    []SyncDriftRow `json:"rows"`
+}
+
+type syncDriftCheckRequest struct {
+	DraftVersionID int64  `json:"draft_version_id"`
+	SyncTargetID   *int64 `json:"sync_target_id"`
+}
+
+type syncDriftImportRequest struct {
+	DraftVersionID int64    `json:"draft_version_id"`
+	SyncTargetID   int64    `json:"sync_target_id"`
+	Modules        []string `json:"modules"`
+}
+
+type syncDriftCandidate struct {
+	DraftVersionID  int64
+	SyncTargetID    int64
+	TargetVersionID int64
+}
+
+// SyncDriftDetector periodically compares synced drafts with their online versions.
+type SyncDriftDetector struct {
+	executor *SyncHandler
+	interval time.Duration
+}
+
+// NewSyncDriftDetector creates a periodic drift detector.
+// Args:
+//
+//	cfg: App config.
+//	db: Database connection.
+//
+// Returns:
+//
+//	*SyncDriftDetector: Initialized detector.
+func NewSyncDriftDetector(cfg *config.Config, db *sql.DB) *SyncDriftDetector {
+	return &SyncDriftDetector{
+		executor: NewSyncHandler(cfg, db, nil),
+		interval: time.Duration(cfg.SyncDriftIntervalMinutes) * time.Minute,
+	}
+}
+
+// Start launches the detector goroutine; a non-positive interval disables it.
+// Args:
+//
+//	ctx: Lifecycle context; the goroutine stops when it is cancelled.
+//
+// Returns:
+//
+//	None.
+func (d *SyncDriftDetector) Start(ctx context.Context) {
+	if d == nil || d.executor == nil || d.executor.db == nil || d.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			candidates, err := loadSyncDriftCandidates(d.executor.db, 0, nil)
+			if err != nil {
+				log.Printf("sync drift query failed: %v", err)
+				continue
+			}
+			for _, candidate := range candidates {
+				if _, err := d.executor.checkSyncDrift(ctx, candidate, 0); err != nil {
+					log.Printf("sync drift check draft %d target %d failed: %v", candidate.DraftVersionID, candidate.SyncTargetID, err)
+				}
+			}
+		}
+	}()
+}
+
+// DetectSyncDrift compares draft rows with online rows of one module.
+// Rows are matched through the sync id map (draft TargetID) first, then by natural key.
+// Args:
+//
+//	columns: Column names matching the row values.
+//	draftRows: Rows built from the draft, TargetID set from app_db_sync_id_map.
+//	onlineRows: Rows from the online snapshot, ID set to the online row id.
+//
+// Returns:
+//
+//	SyncDriftModule: Rows added, removed or modified online.
+func DetectSyncDrift(columns []string, draftRows, onlineRows []SyncDiffRow) SyncDriftModule {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = strings.Trim(column, "`")
+	}
+	byID := make(map[int64]SyncDiffRow, len(onlineRows))
+	for _, row := range onlineRows {
+		byID[row.ID] = row
+	}
+
+	diff := DiffSyncRows(onlineRows, draftRows)
+	report := SyncDriftModule{Rows: make([]SyncDriftRow, 0)}
+	for _, row := range diff.Inserts {
+		if row.TargetID <= 0 {
+			report.Stats.Unsynced++
+			continue
+		}
+		report.Stats.Removed++
+		report.Rows = append(report.Rows, SyncDriftRow{
+			Action:   syncDriftRemovedOnline,
+			DraftID:  row.DraftID,
+			TargetID: row.TargetID,
+			Key:      row.Key,
+			Fields:   diffSyncFields(names, row.Values, nil),
+		})
+	}
+	for _, row := range diff.Updates {
+		report.Stats.Modified++
+		report.Rows = append(report.Rows, SyncDriftRow{
+			Action:   syncDriftModifiedOnline,
+			DraftID:  row.DraftID,
+			TargetID: row.ID,
+			Key:      row.Key,
+			Fields:   diffSyncFields(names, row.Values, byID[row.ID].Values),
+		})
+	}
+	for _, id := range diff.Deletes {
+		online := byID[id]
+		report.Stats.Added++
+		report.Rows = append(report.Rows, SyncDriftRow{
+			Action:   syncDriftAddedOnline,
+			TargetID: id,
+			Key:      online.Key,
+			Fields:   diffSyncFields(names, nil, online.Values),
+		})
+	}
+	report.Drifted = report.Stats.Added+report.Stats.Removed+report.Stats.Modified > 0
+	return report
+}
+
+// buildSyncDriftReport diffs the draft payload against the online snapshot for every module.
+func buildSyncDriftReport(payload SyncPushRequest, snapshot *SyncPullSnapshot) map[string]SyncDriftModule {
+	report := make(map[string]SyncDriftModule)
+
+	draftVersion := buildSyncVersionValues(payload.Version.AppVersionName, payload.Version.LocationName, syncVersionStatus(payload.Version.Status), payload.Version.FeishuFieldNames, syncVersionAiModal(payload.Version.AiModal))
+	onlineVersion := buildSyncVersionValues(snapshot.Version.AppVersionName, snapshot.Version.LocationName, syncVersionStatus(snapshot.Version.Status), snapshot.Version.FeishuFieldNames, syncVersionAiModal(snapshot.Version.AiModal))
+	report["version_names"] = DetectSyncDrift(
+		syncVersionColumns,
+		[]SyncDiffRow{{DraftID: payload.DraftVersionID, TargetID: snapshot.Version.TargetID, Values: draftVersion}},
+		[]SyncDiffRow{{ID: snapshot.Version.TargetID, Values: onlineVersion}},
+	)
+
+	desiredData := buildDraftDataFromPush(payload)
+	onlineData := buildSnapshotDraftData(snapshot)
+	for _, moduleKey := range syncRowModules {
+		report[moduleKey] = DetectSyncDrift(
+			syncTableSpecs[moduleKey].Columns,
+			buildSyncDesiredRows(moduleKey, desiredData),
+			buildSyncOnlineRows(moduleKey, onlineData),
+		)
+	}
+	return report
+}
+
+// checkSyncDrift fetches the online snapshot of one synced draft and stores its drift report.
+func (h *SyncHandler) checkSyncDrift(ctx context.Context, candidate syncDriftCandidate, checkedBy int64) (map[string]SyncDriftModule, error) {
+	target, err := h.resolveSyncTarget(candidate.SyncTargetID)
+	if err != nil {
+		return nil, err
+	}
+	draftVersion, err := loadDraftVersion(h.db, candidate.DraftVersionID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := loadDraftData(h.db, candidate.DraftVersionID, candidate.SyncTargetID)
+	if err != nil {
+		return nil, err
+	}
+	payload := buildSyncPushFromDraft(syncRequest{DraftVersionID: candidate.DraftVersionID}, draftVersion, data)
+
+	now := time.Now()
+	snapshot, err := h.fetchRemoteSnapshot(ctx, target, candidate.TargetVersionID, payload.Version.AppVersionName)
+	if errors.Is(err, errRemoteVersionNotFound) {
+		report := map[string]SyncDriftModule{
+			"version_names": {
+				Drifted: true,
+				Stats:   SyncDriftStats{Removed: 1},
+				Rows:    []SyncDriftRow{{Action: syncDriftRemovedOnline, DraftID: candidate.DraftVersionID, TargetID: candidate.TargetVersionID, Fields: []SyncFieldChange{}}},
+			},
+		}
+		return report, storeSyncDriftReports(h.db, candidate, report, syncDriftVersionMissing, checkedBy, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := buildSyncDriftReport(payload, snapshot)
+	return report, storeSyncDriftReports(h.db, candidate, report, "", checkedBy, now)
+}
+
+// CheckDrift runs drift detection on demand for one draft (or every synced draft when omitted).
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) CheckDrift(c *gin.Context) {
+	if strings.ToLower(strings.TrimSpace(h.cfg.AppMode)) == "online" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available in online mode"})
+		return
+	}
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	var req syncDriftCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	candidates, err := loadSyncDriftCandidates(h.db, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	operatorID := int64(0)
+	if claims, ok := middleware.GetAuthClaims(c); ok {
+		operatorID = claims.UserID
+	}
+
+	results := make([]gin.H, 0, len(candidates))
+	for _, candidate := range candidates {
+		item := gin.H{
+			"draft_version_id":           candidate.DraftVersionID,
+			"sync_target_id":             candidate.SyncTargetID,
+			"target_app_version_name_id": candidate.TargetVersionID,
+		}
+		report, err := h.checkSyncDrift(c.Request.Context(), candidate, operatorID)
+		if err != nil {
+			item["error"] = err.Error()
+			results = append(results, item)
+			continue
+		}
+		drifted := make([]string, 0)
+		for _, moduleKey := range syncDriftModuleOrder() {
+			if module, ok := report[moduleKey]; ok && module.Drifted {
+				drifted = append(drifted, moduleKey)
+			}
+		}
+		item["drifted"] = len(drifted) > 0
+		item["drifted_modules"] = drifted
+		item["modules"] = report
+		results = append(results, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": results})
+}
+
+// ListDrift returns stored drift reports.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) ListDrift(c *gin.Context) {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	query := `SELECT d.draft_version_id, v.app_version_name, v.location_name, d.sync_target_id, d.module_key,
+      d.target_app_version_name_id, d.drifted, d.added_count, d.removed_count, d.modified_count,
+      d.report_json, d.error_message, d.checked_by, d.checked_at
+      FROM app_db_sync_drift_reports d
+      LEFT JOIN app_db_version_names v ON v.id = d.draft_version_id
+      WHERE 1 = 1`
+	args := make([]any, 0)
+	if draftID := parseInt64Query(c, "draft_version_id"); draftID > 0 {
+		query += " AND d.draft_version_id = ?"
+		args = append(args, draftID)
+	}
+	if raw := strings.TrimSpace(c.Query("sync_target_id")); raw != "" {
+		targetID, err := parseInt64ParamValue(raw)
+		if err != nil || targetID < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sync_target_id"})
+			return
+		}
+		query += " AND d.sync_target_id = ?"
+		args = append(args, targetID)
+	}
+	if raw := strings.TrimSpace(c.Query("drifted")); raw == "1" || strings.EqualFold(raw, "true") {
+		query += " AND d.drifted = 1"
+	}
+	query += " ORDER BY d.draft_version_id DESC, d.sync_target_id ASC, d.module_key ASC"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]gin.H, 0)
+	for rows.Next() {
+		var (
+			draftVersionID  int64
+			appVersionName  sql.NullString
+			locationName    sql.NullString
+			syncTargetID    int64
+			moduleKey       string
+			targetVersionID sql.NullInt64
+			drifted         bool
+			added           int
+			removed         int
+			modified        int
+			reportJSON      sql.NullString
+			errorMessage    sql.NullString
+			checkedBy       sql.NullInt64
+			checkedAt       time.Time
+		)
+		if err := rows.Scan(&draftVersionID, &appVersionName, &locationName, &syncTargetID, &moduleKey, &targetVersionID, &drifted, &added, &removed, &modified, &reportJSON, &errorMessage, &checkedBy, &checkedAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+			return
+		}
+		driftRows := make([]SyncDriftRow, 0)
+		if reportJSON.Valid && strings.TrimSpace(reportJSON.String) != "" {
+			_ = json.Unmarshal([]byte(reportJSON.String), &driftRows)
+		}
+		items = append(items, gin.H{
+			"draft_version_id":           draftVersionID,
+			"app_version_name":           nullableString(appVersionName),
+			"location_name":              nullableString(locationName),
+			"sync_target_id":             syncTargetID,
+			"module_key":                 moduleKey,
+			"target_app_version_name_id": nullableInt64Pointer(targetVersionID),
+			"drifted":                    drifted,
+			"stats":                      SyncDriftStats{Added: added, Removed: removed, Modified: modified},
+			"rows":                       driftRows,
+			"error_message":              nullableString(errorMessage),
+			"checked_by":                 nullableInt64Pointer(checkedBy),
+			"checked_at":                 checkedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// ImportDrift pulls the online state of drifted modules back into the draft.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) ImportDrift(c *gin.Context) {
+	if strings.ToLower(strings.TrimSpace(h.cfg.AppMode)) == "online" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available in online mode"})
+		return
+	}
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	var req syncDriftImportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.DraftVersionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+		return
+	}
+	invalidModules := findInvalidModules(req.Modules)
+	if len(invalidModules) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_modules", "modules": invalidModules})
+		return
+	}
+	modules := resolveSyncModules(normalizeModules(req.Modules))
+	if !requireDraftEditable(c, h.db, req.DraftVersionID) {
+		return
+	}
+
+	candidates, err := loadSyncDriftCandidates(h.db, req.DraftVersionID, &req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if len(candidates) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "draft not synced to target"})
+		return
+	}
+	candidate := candidates[0]
+
+	target, err := h.resolveSyncTarget(req.SyncTargetID)
+	if err != nil {
+		respondSyncTargetError(c, err)
+		return
+	}
+	snapshot, err := h.fetchRemoteSnapshot(c.Request.Context(), target, candidate.TargetVersionID, "")
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	operatorID := int64(0)
+	if claims, ok := middleware.GetAuthClaims(c); ok {
+		operatorID = claims.UserID
+	}
+
+	now := time.Now()
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockDraftVersionTx(tx, req.DraftVersionID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	activeID, err := findActiveSyncJob(tx, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if activeID > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "sync_in_progress", "job_id": activeID})
+		return
+	}
+
+	before, err := snapshotDraftTx(tx, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if shouldSyncModule(modules, "version_names") {
+		if err := applySnapshotVersionTx(tx, req.DraftVersionID, snapshot.Version, operatorID, now); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "update draft failed"})
+			return
+		}
+	}
+	if err := purgeDraftModulesTx(tx, req.DraftVersionID, req.SyncTargetID, modules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "clear draft failed"})
+		return
+	}
+	if err := importSnapshotModulesTx(tx, req.DraftVersionID, req.SyncTargetID, filterSnapshotModules(snapshot, modules), operatorID, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "import failed"})
+		return
+	}
+	if err := clearSyncDriftReports(tx, req.DraftVersionID, req.SyncTargetID, modules); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+		return
+	}
+
+	after, err := snapshotDraftTx(tx, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if err := recordDraftSnapshotChangesTx(tx, before, after, operatorID, map[string]interface{}{
+		"source":         "import_drift",
+		"sync_target_id": req.SyncTargetID,
+	}, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+
+	detail, _ := json.Marshal(map[string]interface{}{
+		"source":                     "online_drift",
+		"sync_target_id":             req.SyncTargetID,
+		"target_app_version_name_id": snapshot.Version.TargetID,
+		"modules":                    modules,
+	})
+	if err := insertAuditLog(
+		tx,
+		req.DraftVersionID,
+		"sync_import",
+		snapshot.Version.TargetID,
+		"import_drift",
+		nullableID(operatorID),
+		string(detail),
+		now,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "import failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                     "imported",
+		"draft_version_id":           req.DraftVersionID,
+		"sync_target_id":             req.SyncTargetID,
+		"target_app_version_name_id": snapshot.Version.TargetID,
+		"modules":                    modules,
+	})
+}
+
+// loadSyncDriftCandidates lists draft/target pairs that have an online version and no sync in flight.
+func loadSyncDriftCandidates(db *sql.DB, draftVersionID int64, syncTargetID *int64) ([]syncDriftCandidate, error) {
+	query := "SELECT draft_version_id, sync_target_id, target_app_version_name_id FROM app_db_sync_target_states WHERE target_app_version_name_id > 0 AND (sync_status IS NULL OR sync_status NOT IN (?, ?, ?, ?))"
+	args := []any{syncJobQueued, syncJobRunning, syncJobRetrying, syncStatusRollingBack}
+	if draftVersionID > 0 {
+		query += " AND draft_version_id = ?"
+		args = append(args, draftVersionID)
+	}
+	if syncTargetID != nil {
+		query += " AND sync_target_id = ?"
+		args = append(args, *syncTargetID)
+	}
+	query += " ORDER BY draft_version_id ASC, sync_target_id ASC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]syncDriftCandidate, 0)
+	for rows.Next() {
+		var candidate syncDriftCandidate
+		if err := rows.Scan(&candidate.DraftVersionID, &candidate.SyncTargetID, &candidate.TargetVersionID); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+func storeSyncDriftReports(db *sql.DB, candidate syncDriftCandidate, report map[string]SyncDriftModule, message string, checkedBy int64, now time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec(
+		"DELETE FROM app_db_sync_drift_reports WHERE draft_version_id = ? AND sync_target_id = ?",
+		candidate.DraftVersionID,
+		candidate.SyncTargetID,
+	); err != nil {
+		return err
+	}
+	for _, moduleKey := range syncDriftModuleOrder() {
+		module, ok := report[moduleKey]
+		if !ok {
+			continue
+		}
+		var rowsJSON interface{}
+		if len(module.Rows) > 0 {
+			raw, err := json.Marshal(module.Rows)
+			if err != nil {
+				return err
+			}
+			rowsJSON = string(raw)
+		}
+		if _, err := tx.Exec(
+			"INSERT INTO app_db_sync_drift_reports (draft_version_id, sync_target_id, module_key, target_app_version_name_id, drifted, added_count, removed_count, modified_count, report_json, error_message, checked_by, checked_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			candidate.DraftVersionID,
+			candidate.SyncTargetID,
+			moduleKey,
+			nullableID(candidate.TargetVersionID),
+			module.Drifted,
+			module.Stats.Added,
+			module.Stats.Removed,
+			module.Stats.Modified,
+			rowsJSON,
+			nullIfEmpty(message),
+			nullableID(checkedBy),
+			now,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// clearSyncDriftReports drops reports of modules whose draft and online data were just aligned.
+func clearSyncDriftReports(db sqlExecutor, draftVersionID, syncTargetID int64, modules []string) error {
+	resolved := resolveSyncModules(modules)
+	if len(resolved) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimRight(strings.Repeat("?,", len(resolved)), ",")
+	args := []any{draftVersionID, syncTargetID}
+	for _, moduleKey := range resolved {
+		args = append(args, moduleKey)
+	}
+	_, err := db.Exec(
+		"DELETE FROM app_db_sync_drift_reports WHERE draft_version_id = ? AND sync_target_id = ? AND module_key IN ("+placeholders+")",
+		args...,
+	)
+	return err
+}
+
+func syncDriftModuleOrder() []string {
+	return append([]string{"version_names"}, syncRowModules...)
+}
+
+// purgeDraftModulesTx removes draft rows of the given modules and their id mappings for one target;
+// mappings of other targets are left alone.
+func purgeDraftModulesTx(tx *sql.Tx, draftVersionID, syncTargetID int64, modules []string) error {
+	for _, moduleKey := range syncRowModules {
+		if !shouldSyncModule(modules, moduleKey) {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM app_db_sync_id_map WHERE draft_version_id = ? AND sync_target_id = ? AND module_key = ?", draftVersionID, syncTargetID, moduleKey); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM app_db_"+syncTableSpecs[moduleKey].Table+" WHERE draft_version_id = ?", draftVersionID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// filterSnapshotModules keeps only the selected modules of a snapshot.
+func filterSnapshotModules(snapshot *SyncPullSnapshot, modules []string) *SyncPullSnapshot {
+	filtered := &SyncPullSnapshot{Version: snapshot.Version}
+	if shouldSyncModule(modules, "app_ui_fields") {
+		filtered.AppUIFields = snapshot.AppUIFields
+	}
+	if shouldSyncModule(modules, "banners") {
+		filtered.Banners = snapshot.Banners
+	}
+	if shouldSyncModule(modules, "identities") {
+		filtered.Identities = snapshot.Identities
+	}
+	if shouldSyncModule(modules, "scenes") {
+		filtered.Scenes = snapshot.Scenes
+	}
+	if shouldSyncModule(modules, "clothes_categories") {
+		filtered.ClothesCategories = snapshot.ClothesCategories
+	}
+	if shouldSyncModule(modules, "photo_hobbies") {
+		filtered.PhotoHobbies = snapshot.PhotoHobbies
+	}
+	if shouldSyncModule(modules, "config_extra_steps") {
+		filtered.ExtraSteps = snapshot.ExtraSteps
+	}
+	return filtered
+}
+
+// applySnapshotVersionTx copies online version settings into the draft without resetting its workflow state.
+func applySnapshotVersionTx(tx *sql.Tx, draftVersionID int64, version SyncRemoteVersion, operatorID int64, now time.Time) error {
+	aiModal, err := NormalizeAiModal(version.AiModal)
+	if err != nil {
+		aiModal = "SD"
+	}
+	_, err = tx.Exec(
+		"UPDATE app_db_version_names SET app_version_name = ?, location_name = ?, feishu_field_names = ?, ai_modal = ?, status = ?, updated_by = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
+		nullIfEmpty(version.AppVersionName),
+		nullIfEmpty(version.LocationName),
+		nullIfEmpty(version.FeishuFieldNames),
+		aiModal,
+		syncVersionStatus(version.Status),
+		nullableID(operatorID),
+		now,
+		draftVersionID,
+	)
+	return err
+}
+
+func syncVersionStatus(status *int64) int64 {
+	if status == nil {
+		return 1
+	}
+	return *status
+}
+
+func syncVersionAiModal(aiModal string) string {
+	if trimmed := strings.TrimSpace(aiModal); trimmed != "" {
+		return trimmed
+	}
+	return "SD"
+}
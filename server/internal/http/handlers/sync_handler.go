@@ -8,23 +8,26 @@ import (
 	"errors"
 	"io"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"shushu-app-ui-dashboard/internal/config"
+	"shushu-app-ui-dashboard/internal/services"
 )
 
 type SyncHandler struct {
 	cfg    *config.Config
 	db     *sql.DB
 	client *http.Client
+	runner *SyncRunner
+	signer *services.SyncSigner
 }
 
 type syncRequest struct {
 	DraftVersionID int64    `json:"draft_version_id"`
+	SyncTargetID   int64    `json:"sync_target_id"`
 	TriggerBy      int64    `json:"trigger_by"`
 	Confirm        bool     `json:"confirm"`
 	Modules        []string `json:"modules"`
@@ -33,26 +36,31 @@ type syncRequest struct {
 // NewSyncHandler creates a handler for sync flow.
 // Args:
 //
+//	cfg: App config.
 //	db: Database connection.
+//	runner: Background runner that executes queued sync jobs.
 //
 // Returns:
 //
 //	*SyncHandler: Initialized handler.
-func NewSyncHandler(cfg *config.Config, db *sql.DB) *SyncHandler {
+func NewSyncHandler(cfg *config.Config, db *sql.DB, runner *SyncRunner) *SyncHandler {
 	timeout := time.Duration(cfg.SyncTimeoutSeconds) * time.Second
 	if timeout <= 0 {
 		timeout = 20 * time.Second
 	}
+	signer, _ := services.NewSyncSigner(cfg)
 	return &SyncHandler{
 		cfg: cfg,
 		db:  db,
 		client: &http.Client{
 			Timeout: timeout,
 		},
+		runner: runner,
+		signer: signer,
 	}
 }
 
-// Sync validates and pushes draft data to the online sync API.
+// Sync validates a draft and queues it for a background push to the online sync API.
 // Args:
 //
 //	c: Gin context.
@@ -70,6 +78,10 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
 		return
 	}
+	if h.runner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync runner not ready"})
+		return
+	}
 
 	var req syncRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -82,12 +94,8 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 		return
 	}
 
-	if strings.TrimSpace(h.cfg.SyncTargetURL) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync target not configured"})
-		return
-	}
-	if strings.TrimSpace(h.cfg.SyncAPIKey) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync api key not configured"})
+	if _, err := h.resolveSyncTarget(req.SyncTargetID); err != nil {
+		respondSyncTargetError(c, err)
 		return
 	}
 
@@ -101,6 +109,25 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 		return
 	}
 
+	draftStatus, err := loadDraftStatus(h.db, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if draftStatus != DraftStatusApproved {
+		c.JSON(http.StatusConflict, gin.H{"error": "draft_not_approved", "draft_status": draftStatus})
+		return
+	}
+	evaluation, err := evaluateDraftApprovals(h.db, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if !evaluation.Satisfied {
+		c.JSON(http.StatusConflict, gin.H{"error": "approval_required", "missing": evaluation.Missing, "rejected": evaluation.Rejected})
+		return
+	}
+
 	appVersionName := strings.TrimSpace(nullableStringValue(draftVersion.AppVersionName))
 	locationName := strings.TrimSpace(nullableStringValue(draftVersion.LocationName))
 
@@ -111,7 +138,7 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 	}
 	modules := normalizeModules(req.Modules)
 
-	draftData, err := loadDraftData(h.db, req.DraftVersionID)
+	draftData, err := loadDraftData(h.db, req.DraftVersionID, req.SyncTargetID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 		return
@@ -119,7 +146,7 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 	payload := buildSyncValidationPayload(draftData, appVersionName, locationName)
 	validationErrors := ValidateSyncPayload(payload, modules)
 	if len(validationErrors) > 0 {
-		_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "failed", "validation_failed", 0)
+		_ = updateDraftSyncState(h.db, req.DraftVersionID, req.SyncTargetID, "failed", "validation_failed")
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "validation_failed",
 			"details": validationErrors,
@@ -127,82 +154,92 @@ func (h *SyncHandler) Sync(c *gin.Context) {
 		return
 	}
 
-	now := time.Now()
-	jobID, moduleJobs, err := h.startSyncJob(req.DraftVersionID, req.TriggerBy, modules, now)
+	jobID, err := h.runner.enqueue(req, modules, time.Now())
 	if err != nil {
+		if errors.Is(err, errSyncJobActive) {
+			c.JSON(http.StatusConflict, gin.H{"error": "sync_in_progress", "job_id": jobID})
+			return
+		}
+		var lifecycleErr *draftLifecycleError
+		if errors.As(err, &lifecycleErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": "draft_not_approved", "draft_status": lifecycleErr.Status})
+			return
+		}
+		var approvalErr *approvalPendingError
+		if errors.As(err, &approvalErr) {
+			c.JSON(http.StatusConflict, gin.H{"error": "approval_required", "missing": approvalErr.Evaluation.Missing, "rejected": approvalErr.Evaluation.Rejected})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync job failed"})
 		return
 	}
+	h.runner.Notify()
 
-	_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "running", "", 0)
+	c.JSON(http.StatusAccepted, gin.H{
+		"status":           syncJobQueued,
+		"job_id":           jobID,
+		"draft_version_id": req.DraftVersionID,
+		"sync_target_id":   req.SyncTargetID,
+	})
+}
 
-	uploadCache, err := h.uploadDraftModules(req.DraftVersionID, modules)
+// executeSync runs one attempt of a queued sync job: upload media, build the
+// payload and push it to the online sync API.
+func (h *SyncHandler) executeSync(ctx context.Context, req syncRequest) (*syncPushResult, draftData, map[string]uploadCacheEntry, error) {
+	target, err := h.resolveSyncTarget(req.SyncTargetID)
 	if err != nil {
-		_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "failed", err.Error(), 0)
-		_ = h.finishSyncJobWithError(jobID, moduleJobs, "failed", err.Error(), now)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		if isSyncTargetError(err) {
+			return nil, draftData{}, nil, &syncJobError{Message: err.Error()}
+		}
+		return nil, draftData{}, nil, err
 	}
 
-	draftData, err = loadDraftData(h.db, req.DraftVersionID)
+	draftVersion, err := loadDraftVersion(h.db, req.DraftVersionID)
 	if err != nil {
-		_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "failed", "query failed", 0)
-		_ = h.finishSyncJobWithError(jobID, moduleJobs, "failed", "query failed", now)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		if err == sql.ErrNoRows {
+			return nil, draftData{}, nil, &syncJobError{Message: "draft version not found"}
+		}
+		return nil, draftData{}, nil, err
 	}
+	appVersionName := strings.TrimSpace(nullableStringValue(draftVersion.AppVersionName))
+	locationName := strings.TrimSpace(nullableStringValue(draftVersion.LocationName))
+	modules := normalizeModules(req.Modules)
 
-	req.Modules = modules
-	pushPayload := buildSyncPushFromDraft(req, draftVersion, draftData)
-	result, err := h.pushToRemote(c.Request.Context(), pushPayload)
+	data, err := loadDraftData(h.db, req.DraftVersionID, req.SyncTargetID)
 	if err != nil {
-		if pushErr := asSyncPushError(err); pushErr != nil {
-			if pushErr.NeedConfirm {
-				_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "pending_confirm", pushErr.Message, pushErr.TargetID)
-				_ = h.finishSyncJobWithError(jobID, moduleJobs, "pending_confirm", pushErr.Message, now)
-				c.JSON(http.StatusConflict, gin.H{
-					"need_confirm":               true,
-					"reason":                     pushErr.Message,
-					"target_app_version_name_id": pushErr.TargetID,
-				})
-				return
-			}
-			_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "failed", pushErr.Message, pushErr.TargetID)
-			_ = h.finishSyncJobWithError(jobID, moduleJobs, "failed", pushErr.Message, now)
-			if len(pushErr.Details) > 0 {
-				c.JSON(http.StatusBadRequest, gin.H{"error": pushErr.Message, "details": pushErr.Details})
-			} else {
-				c.JSON(http.StatusBadRequest, gin.H{"error": pushErr.Message})
-			}
-			return
-		}
-		_ = updateDraftSyncStatus(h.db, req.DraftVersionID, "failed", err.Error(), 0)
-		_ = h.finishSyncJobWithError(jobID, moduleJobs, "failed", err.Error(), now)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-		return
+		return nil, draftData{}, nil, err
+	}
+	payload := buildSyncValidationPayload(data, appVersionName, locationName)
+	if validationErrors := ValidateSyncPayload(payload, modules); len(validationErrors) > 0 {
+		return nil, draftData{}, nil, &syncJobError{Message: "validation_failed"}
 	}
 
-	if err := h.finishSyncSuccess(req, result.TargetID, result.Mappings, draftData, jobID, moduleJobs, now); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-		return
+	_ = updateDraftSyncState(h.db, req.DraftVersionID, req.SyncTargetID, syncJobRunning, "")
+
+	uploadCache, err := h.uploadDraftModules(req.DraftVersionID, modules)
+	if err != nil {
+		return nil, draftData{}, nil, err
 	}
 
-	for _, entry := range uploadCache {
-		if entry.localAbs != "" {
-			_ = os.Remove(entry.localAbs)
-		}
+	data, err = loadDraftData(h.db, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		return nil, draftData{}, uploadCache, err
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":                     "synced",
-		"draft_version_id":           req.DraftVersionID,
-		"target_app_version_name_id": result.TargetID,
-	})
+	req.Modules = modules
+	pushPayload := buildSyncPushFromDraft(req, draftVersion, data)
+	result, err := h.pushToRemote(ctx, target, pushPayload)
+	if err != nil {
+		return nil, draftData{}, uploadCache, err
+	}
+	return result, data, uploadCache, nil
 }
 
 type syncPushResult struct {
 	TargetID int64
 	Mappings []SyncIDMapping
+	Stats    map[string]SyncModuleStats
+	Previous *SyncPullSnapshot
 }
 
 type syncPushError struct {
@@ -225,22 +262,22 @@ func asSyncPushError(err error) *syncPushError {
 	return nil
 }
 
-func (h *SyncHandler) pushToRemote(ctx context.Context, payload SyncPushRequest) (*syncPushResult, error) {
+func (h *SyncHandler) pushToRemote(ctx context.Context, target *syncTarget, payload SyncPushRequest) (*syncPushResult, error) {
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSpace(h.cfg.SyncTargetURL), bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
-	if apiKey := strings.TrimSpace(h.cfg.SyncAPIKey); apiKey != "" {
-		req.Header.Set("X-API-Key", apiKey)
+	if err := target.signer.Sign(req, body, time.Now()); err != nil {
+		return nil, &syncJobError{Message: err.Error()}
 	}
 
-	resp, err := h.client.Do(req)
+	resp, err := target.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -250,13 +287,15 @@ func (h *SyncHandler) pushToRemote(ctx context.Context, payload SyncPushRequest)
 
 	payloadBytes, _ := io.ReadAll(resp.Body)
 	var parsed struct {
-		Status      string                `json:"status"`
-		TargetID    int64                 `json:"target_app_version_name_id"`
-		NeedConfirm bool                  `json:"need_confirm"`
-		Reason      string                `json:"reason"`
-		Error       string                `json:"error"`
-		Details     []SyncValidationError `json:"details"`
-		Mappings    []SyncIDMapping       `json:"mappings"`
+		Status      string                     `json:"status"`
+		TargetID    int64                      `json:"target_app_version_name_id"`
+		NeedConfirm bool                       `json:"need_confirm"`
+		Reason      string                     `json:"reason"`
+		Error       string                     `json:"error"`
+		Details     []SyncValidationError      `json:"details"`
+		Mappings    []SyncIDMapping            `json:"mappings"`
+		Stats       map[string]SyncModuleStats `json:"stats"`
+		Previous    *SyncPullSnapshot          `json:"previous_snapshot"`
 	}
 	if len(payloadBytes) > 0 {
 		_ = json.Unmarshal(payloadBytes, &parsed)
@@ -279,36 +318,10 @@ func (h *SyncHandler) pushToRemote(ctx context.Context, payload SyncPushRequest)
 		}
 	}
 
-	return &syncPushResult{TargetID: parsed.TargetID, Mappings: parsed.Mappings}, nil
-}
-
-func (h *SyncHandler) startSyncJob(draftVersionID, triggerBy int64, modules []string, now time.Time) (int64, map[string]int64, error) {
-	tx, err := h.db.Begin()
-	if err != nil {
-		return 0, nil, err
-	}
-	defer func() {
-		_ = tx.Rollback()
-	}()
-	jobID, err := insertSyncJob(tx, draftVersionID, triggerBy, "running", now)
-	if err != nil {
-		return 0, nil, err
-	}
-	moduleJobs := make(map[string]int64)
-	for _, moduleKey := range resolveSyncModules(modules) {
-		moduleID, err := insertSyncModuleJob(tx, draftVersionID, triggerBy, moduleKey, "running", now)
-		if err != nil {
-			return 0, nil, err
-		}
-		moduleJobs[moduleKey] = moduleID
-	}
-	if err := tx.Commit(); err != nil {
-		return 0, nil, err
-	}
-	return jobID, moduleJobs, nil
+	return &syncPushResult{TargetID: parsed.TargetID, Mappings: parsed.Mappings, Stats: parsed.Stats, Previous: parsed.Previous}, nil
 }
 
-func (h *SyncHandler) finishSyncJobWithError(jobID int64, moduleJobs map[string]int64, status, message string, now time.Time) error {
+func (h *SyncHandler) finishSyncJobWithError(jobID int64, moduleJobs map[string]int64, status, message string, targetID int64, now time.Time) error {
 	tx, err := h.db.Begin()
 	if err != nil {
 		return err
@@ -316,7 +329,7 @@ func (h *SyncHandler) finishSyncJobWithError(jobID int64, moduleJobs map[string]
 	defer func() {
 		_ = tx.Rollback()
 	}()
-	if err := failSyncJob(tx, jobID, message, now); err != nil {
+	if err := failSyncJob(tx, jobID, status, message, targetID, now); err != nil {
 		return err
 	}
 	for _, moduleID := range moduleJobs {
@@ -327,7 +340,8 @@ func (h *SyncHandler) finishSyncJobWithError(jobID int64, moduleJobs map[string]
 	return tx.Commit()
 }
 
-func (h *SyncHandler) finishSyncSuccess(req syncRequest, targetID int64, mappings []SyncIDMapping, data draftData, jobID int64, moduleJobs map[string]int64, now time.Time) error {
+func (h *SyncHandler) finishSyncSuccess(req syncRequest, result *syncPushResult, data draftData, jobID int64, moduleJobs map[string]int64, now time.Time) error {
+	targetID := result.TargetID
 	tx, err := h.db.Begin()
 	if err != nil {
 		return err
@@ -336,29 +350,51 @@ func (h *SyncHandler) finishSyncSuccess(req syncRequest, targetID int64, mapping
 		_ = tx.Rollback()
 	}()
 
-	if err := updateDraftSyncStatusTx(tx, req.DraftVersionID, "synced", "", targetID, now); err != nil {
+	if err := updateDraftSyncStatusTx(tx, req.DraftVersionID, req.SyncTargetID, "synced", "", targetID, now); err != nil {
 		return err
 	}
 
-	if err := upsertSyncIDMappings(tx, req.DraftVersionID, mappings, now); err != nil {
+	if err := upsertSyncIDMappings(tx, req.DraftVersionID, req.SyncTargetID, result.Mappings, now); err != nil {
+		return err
+	}
+	if err := clearSyncDriftReports(tx, req.DraftVersionID, req.SyncTargetID, req.Modules); err != nil {
 		return err
 	}
 
 	auditPayload := buildSyncAuditPayload(data)
-	if raw, err := json.Marshal(auditPayload); err == nil {
-		_, _ = tx.Exec(
-			"INSERT INTO app_db_audit_logs (draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			req.DraftVersionID,
-			"sync",
-			targetID,
-			"sync",
-			req.TriggerBy,
-			string(raw),
-			now,
-		)
-	}
-
-	if err := finishSyncJob(tx, jobID, now); err != nil {
+	auditPayload["sync_target_id"] = req.SyncTargetID
+	if len(result.Stats) > 0 {
+		auditPayload["changes"] = result.Stats
+	}
+	raw, err := json.Marshal(auditPayload)
+	if err != nil {
+		return err
+	}
+	if err := insertAuditLog(
+		tx,
+		req.DraftVersionID,
+		"sync",
+		targetID,
+		"sync",
+		req.TriggerBy,
+		string(raw),
+		now,
+	); err != nil {
+		return err
+	}
+
+	if result.Previous != nil {
+		if _, err := insertSyncRevision(tx, req.DraftVersionID, req.SyncTargetID, jobID, req.TriggerBy, "sync", 0, result.Previous, now); err != nil {
+			return err
+		}
+	}
+
+	detail := map[string]interface{}{"job_id": jobID, "sync_target_id": req.SyncTargetID}
+	if _, err := transitionDraftStatus(tx, req.DraftVersionID, DraftStatusSyncing, DraftStatusPublished, "publish", req.TriggerBy, detail, now); err != nil {
+		return err
+	}
+
+	if err := finishSyncJob(tx, jobID, targetID, now); err != nil {
 		return err
 	}
 	for _, moduleID := range moduleJobs {
@@ -0,0 +1,822 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	syncImportModeMerge = "merge"
+
+	syncMergeInsert = "insert"
+	syncMergeUpdate = "update"
+	syncMergeDelete = "delete"
+	syncMergeLink   = "link"
+
+	syncMergeChoiceLocal  = "local"
+	syncMergeChoiceOnline = "online"
+
+	// syncMergeRowField marks a row-level conflict (row removed online but edited locally).
+	syncMergeRowField = "*"
+)
+
+var syncMergeNumericColumns = map[string]struct{}{
+	"sort":           {},
+	"is_active":      {},
+	"type":           {},
+	"status":         {},
+	"need_watermark": {},
+	"step_index":     {},
+}
+
+type syncMergeResolution struct {
+	ModuleKey string `json:"module_key"`
+	TargetID  int64  `json:"target_id"`
+	Field     string `json:"field"`
+	Choice    string `json:"choice"`
+}
+
+// SyncMergeLocalChange describes local edits of one draft row since the last sync.
+// Base holds the value a field had at the last sync, taken from field history.
+type SyncMergeLocalChange struct {
+	RowChanged bool
+	Base       map[string]sql.NullString
+}
+
+// SyncMergeConflict is one field changed both locally and online since the last sync.
+type SyncMergeConflict struct {
+	ModuleKey string  `json:"module_key"`
+	Action    string  `json:"action"`
+	DraftID   int64   `json:"draft_id,omitempty"`
+	TargetID  int64   `json:"target_id"`
+	Key       string  `json:"key,omitempty"`
+	Field     string  `json:"field"`
+	Base      *string `json:"base"`
+	Local     *string `json:"local"`
+	Online    *string `json:"online"`
+}
+
+// SyncMergeRow is one draft row operation of a merge; Fields hold local (Old) and online (New) values.
+type SyncMergeRow struct {
+	Action   string            `json:"action"`
+	DraftID  int64             `json:"draft_id,omitempty"`
+	TargetID int64             `json:"target_id,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Fields   []SyncFieldChange `json:"fields"`
+	Values   []sql.NullString  `json:"-"`
+	Local    []sql.NullString  `json:"-"`
+}
+
+// SyncMergePlan is the merge result of one module.
+type SyncMergePlan struct {
+	Rows      []SyncMergeRow      `json:"rows"`
+	Conflicts []SyncMergeConflict `json:"conflicts"`
+}
+
+// SyncMergeResolutionKey builds the lookup key of a conflict resolution.
+// Args:
+//
+//	moduleKey: Module key.
+//	targetID: Online row id.
+//	field: Field name, or "*" for a row-level conflict.
+//
+// Returns:
+//
+//	string: Resolution key.
+func SyncMergeResolutionKey(moduleKey string, targetID int64, field string) string {
+	return fmt.Sprintf("%s:%d:%s", moduleKey, targetID, field)
+}
+
+// PlanSyncMerge decides, field by field, which online changes can be pulled into the draft.
+// Fields edited only online are taken, fields edited only locally are kept, and fields
+// edited on both sides are conflicts unless resolutions pick a side.
+// Args:
+//
+//	moduleKey: Module key.
+//	columns: Column names matching the row values.
+//	draftRows: Draft rows, TargetID set from app_db_sync_id_map.
+//	onlineRows: Online rows, ID set to the online row id.
+//	local: Local edits since the last sync keyed by draft row id.
+//	selected: Online row ids to pull (nil for all).
+//	resolutions: Choices keyed by SyncMergeResolutionKey.
+//
+// Returns:
+//
+//	SyncMergePlan: Row operations and unresolved conflicts.
+func PlanSyncMerge(moduleKey string, columns []string, draftRows, onlineRows []SyncDiffRow, local map[int64]SyncMergeLocalChange, selected map[int64]bool, resolutions map[string]string) SyncMergePlan {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = strings.Trim(column, "`")
+	}
+	byID := make(map[int64]SyncDiffRow, len(onlineRows))
+	for _, row := range onlineRows {
+		byID[row.ID] = row
+	}
+	isSelected := func(targetID int64) bool {
+		return selected == nil || selected[targetID]
+	}
+
+	plan := SyncMergePlan{Rows: make([]SyncMergeRow, 0), Conflicts: make([]SyncMergeConflict, 0)}
+	diff := DiffSyncRows(onlineRows, draftRows)
+
+	for _, row := range diff.Unchanged {
+		if isSelected(row.ID) && row.TargetID != row.ID {
+			plan.Rows = append(plan.Rows, SyncMergeRow{Action: syncMergeLink, DraftID: row.DraftID, TargetID: row.ID, Key: row.Key, Fields: []SyncFieldChange{}})
+		}
+	}
+
+	for _, row := range diff.Updates {
+		if !isSelected(row.ID) {
+			continue
+		}
+		online := byID[row.ID].Values
+		change := local[row.DraftID]
+		values := make([]sql.NullString, len(row.Values))
+		copy(values, row.Values)
+		fields := make([]SyncFieldChange, 0)
+		for i, name := range names {
+			var localValue, onlineValue sql.NullString
+			if i < len(row.Values) {
+				localValue = row.Values[i]
+			}
+			if i < len(online) {
+				onlineValue = online[i]
+			}
+			if syncNullStringEqual(localValue, onlineValue) {
+				continue
+			}
+
+			takeOnline := false
+			base, edited := change.Base[name]
+			switch {
+			case edited && syncNullStringEqual(base, onlineValue):
+				takeOnline = false
+			case !edited && (!change.RowChanged || len(change.Base) > 0):
+				// Field history lists every field edited since the sync, so others are untouched.
+				takeOnline = true
+			default:
+				switch resolutions[SyncMergeResolutionKey(moduleKey, row.ID, name)] {
+				case syncMergeChoiceOnline:
+					takeOnline = true
+				case syncMergeChoiceLocal:
+					takeOnline = false
+				default:
+					conflict := SyncMergeConflict{
+						ModuleKey: moduleKey,
+						Action:    syncMergeUpdate,
+						DraftID:   row.DraftID,
+						TargetID:  row.ID,
+						Key:       row.Key,
+						Field:     name,
+						Local:     syncStringPointer(localValue),
+						Online:    syncStringPointer(onlineValue),
+					}
+					if edited {
+						conflict.Base = syncStringPointer(base)
+					}
+					plan.Conflicts = append(plan.Conflicts, conflict)
+					continue
+				}
+			}
+			if takeOnline {
+				values[i] = onlineValue
+				fields = append(fields, SyncFieldChange{Field: name, Old: syncStringPointer(localValue), New: syncStringPointer(onlineValue)})
+			}
+		}
+		if len(fields) > 0 {
+			plan.Rows = append(plan.Rows, SyncMergeRow{Action: syncMergeUpdate, DraftID: row.DraftID, TargetID: row.ID, Key: row.Key, Fields: fields, Values: values, Local: row.Values})
+		} else if row.TargetID != row.ID {
+			plan.Rows = append(plan.Rows, SyncMergeRow{Action: syncMergeLink, DraftID: row.DraftID, TargetID: row.ID, Key: row.Key, Fields: []SyncFieldChange{}})
+		}
+	}
+
+	for _, row := range diff.Inserts {
+		// Rows never pushed are local additions and stay untouched.
+		if row.TargetID <= 0 || !isSelected(row.TargetID) {
+			continue
+		}
+		change := local[row.DraftID]
+		remove := !change.RowChanged && len(change.Base) == 0
+		if !remove {
+			switch resolutions[SyncMergeResolutionKey(moduleKey, row.TargetID, syncMergeRowField)] {
+			case syncMergeChoiceOnline:
+				remove = true
+			case syncMergeChoiceLocal:
+			default:
+				plan.Conflicts = append(plan.Conflicts, SyncMergeConflict{
+					ModuleKey: moduleKey,
+					Action:    syncMergeDelete,
+					DraftID:   row.DraftID,
+					TargetID:  row.TargetID,
+					Key:       row.Key,
+					Field:     syncMergeRowField,
+				})
+			}
+		}
+		if remove {
+			plan.Rows = append(plan.Rows, SyncMergeRow{Action: syncMergeDelete, DraftID: row.DraftID, TargetID: row.TargetID, Key: row.Key, Fields: diffSyncFields(names, row.Values, nil), Local: row.Values})
+		}
+	}
+
+	for _, id := range diff.Deletes {
+		if !isSelected(id) {
+			continue
+		}
+		online := byID[id]
+		plan.Rows = append(plan.Rows, SyncMergeRow{Action: syncMergeInsert, TargetID: id, Key: online.Key, Fields: diffSyncFields(names, nil, online.Values), Values: online.Values})
+	}
+	return plan
+}
+
+func syncNullStringEqual(left, right sql.NullString) bool {
+	return left.Valid == right.Valid && (!left.Valid || left.String == right.String)
+}
+
+// mergeFromOnline pulls selected online modules and rows into an existing draft without discarding local edits.
+func (h *SyncHandler) mergeFromOnline(c *gin.Context, req syncImportRequest, snapshot *SyncPullSnapshot, operatorID int64) {
+	if req.DraftVersionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required for merge"})
+		return
+	}
+	invalidModules := findInvalidModules(req.Modules)
+	if len(invalidModules) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_modules", "modules": invalidModules})
+		return
+	}
+	modules := resolveSyncModules(normalizeModules(req.Modules))
+
+	resolutions := make(map[string]string, len(req.Resolutions))
+	for _, item := range req.Resolutions {
+		choice := strings.ToLower(strings.TrimSpace(item.Choice))
+		if choice != syncMergeChoiceLocal && choice != syncMergeChoiceOnline {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid resolution choice", "resolution": item})
+			return
+		}
+		resolutions[SyncMergeResolutionKey(strings.TrimSpace(item.ModuleKey), item.TargetID, strings.TrimSpace(item.Field))] = choice
+	}
+
+	now := time.Now()
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// Lock the version and every draft row before reading them, so the plan cannot go stale before commit.
+	if err := lockDraftVersionTx(tx, req.DraftVersionID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	before, err := snapshotDraftTx(tx, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	draftVersion, err := loadDraftVersion(tx, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	data, err := loadDraftData(tx, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	localChanges, err := loadSyncMergeLocalChanges(tx, req.DraftVersionID, req.SyncTargetID, modules)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	payload := buildSyncPushFromDraft(syncRequest{DraftVersionID: req.DraftVersionID}, draftVersion, data)
+	plans := buildSyncMergePlans(payload, snapshot, modules, localChanges, req.Rows, resolutions)
+	conflicts := make([]SyncMergeConflict, 0)
+	for _, moduleKey := range syncDriftModuleOrder() {
+		if plan, ok := plans[moduleKey]; ok {
+			conflicts = append(conflicts, plan.Conflicts...)
+		}
+	}
+
+	if req.DryRun {
+		c.JSON(http.StatusOK, gin.H{"status": "preview", "draft_version_id": req.DraftVersionID, "modules": plans, "conflicts": conflicts})
+		return
+	}
+	if !requireDraftEditable(c, tx, req.DraftVersionID) {
+		return
+	}
+	if len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "merge_conflicts", "draft_version_id": req.DraftVersionID, "modules": plans, "conflicts": conflicts})
+		return
+	}
+
+	activeID, err := findActiveSyncJob(tx, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if activeID > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "sync_in_progress", "job_id": activeID})
+		return
+	}
+
+	stats := make(map[string]map[string]int)
+	submissionIDs := make([]int64, 0)
+	submitted := make(map[string][]int64)
+	needConfirm := 0
+	for _, moduleKey := range syncDriftModuleOrder() {
+		plan, ok := plans[moduleKey]
+		if !ok || len(plan.Rows) == 0 {
+			continue
+		}
+		applied, err := applySyncMergePlanTx(tx, req.DraftVersionID, req.SyncTargetID, moduleKey, plan, snapshot, operatorID, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "merge failed"})
+			return
+		}
+		stats[moduleKey] = applied.Stats
+		submissionIDs = append(submissionIDs, applied.SubmissionIDs...)
+		submitted[applied.Table] = append(submitted[applied.Table], applied.SubmittedIDs...)
+		needConfirm += applied.NeedConfirm
+	}
+
+	after, err := snapshotDraftTx(tx, req.DraftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	// Merged rows already carry field history from their submissions; the snapshot records the rest, such as trashed rows.
+	for table, ids := range submitted {
+		omitDraftSnapshotRows(before, after, table, ids)
+	}
+	if err := recordDraftSnapshotChangesTx(tx, before, after, operatorID, map[string]interface{}{
+		"source":         "import_merge",
+		"sync_target_id": req.SyncTargetID,
+	}, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+
+	detail, _ := json.Marshal(map[string]interface{}{
+		"source":                     "online",
+		"mode":                       syncImportModeMerge,
+		"sync_target_id":             req.SyncTargetID,
+		"target_app_version_name_id": snapshot.Version.TargetID,
+		"modules":                    modules,
+		"stats":                      stats,
+		"submission_ids":             submissionIDs,
+		"resolutions":                req.Resolutions,
+	})
+	if err := insertAuditLog(
+		tx,
+		req.DraftVersionID,
+		"sync_import",
+		snapshot.Version.TargetID,
+		"import_merge",
+		nullableID(operatorID),
+		string(detail),
+		now,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "merge failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                     "merged",
+		"draft_version_id":           req.DraftVersionID,
+		"sync_target_id":             req.SyncTargetID,
+		"target_app_version_name_id": snapshot.Version.TargetID,
+		"stats":                      stats,
+		"submission_ids":             submissionIDs,
+		"need_confirm":               needConfirm,
+	})
+}
+
+func buildSyncMergePlans(payload SyncPushRequest, snapshot *SyncPullSnapshot, modules []string, local map[string]map[int64]SyncMergeLocalChange, rows map[string][]int64, resolutions map[string]string) map[string]SyncMergePlan {
+	selectedOf := func(moduleKey string) map[int64]bool {
+		ids, ok := rows[moduleKey]
+		if !ok {
+			return nil
+		}
+		selected := make(map[int64]bool, len(ids))
+		for _, id := range ids {
+			selected[id] = true
+		}
+		return selected
+	}
+
+	plans := make(map[string]SyncMergePlan)
+	if shouldSyncModule(modules, "version_names") {
+		draftVersion := buildSyncVersionValues(payload.Version.AppVersionName, payload.Version.LocationName, syncVersionStatus(payload.Version.Status), payload.Version.FeishuFieldNames, syncVersionAiModal(payload.Version.AiModal))
+		onlineVersion := buildSyncVersionValues(snapshot.Version.AppVersionName, snapshot.Version.LocationName, syncVersionStatus(snapshot.Version.Status), snapshot.Version.FeishuFieldNames, syncVersionAiModal(snapshot.Version.AiModal))
+		plans["version_names"] = PlanSyncMerge(
+			"version_names",
+			syncVersionColumns,
+			[]SyncDiffRow{{DraftID: payload.DraftVersionID, TargetID: snapshot.Version.TargetID, Values: draftVersion}},
+			[]SyncDiffRow{{ID: snapshot.Version.TargetID, Values: onlineVersion}},
+			local["version_names"],
+			selectedOf("version_names"),
+			resolutions,
+		)
+	}
+
+	desiredData := buildDraftDataFromPush(payload)
+	onlineData := buildSnapshotDraftData(snapshot)
+	for _, moduleKey := range syncRowModules {
+		if !shouldSyncModule(modules, moduleKey) {
+			continue
+		}
+		plans[moduleKey] = PlanSyncMerge(
+			moduleKey,
+			syncTableSpecs[moduleKey].Columns,
+			buildSyncDesiredRows(moduleKey, desiredData),
+			buildSyncOnlineRows(moduleKey, onlineData),
+			local[moduleKey],
+			selectedOf(moduleKey),
+			resolutions,
+		)
+	}
+	return plans
+}
+
+// loadSyncMergeLocalChanges finds draft rows edited after the last sync to the target,
+// with per-field baselines from field history.
+func loadSyncMergeLocalChanges(db sqlReader, draftVersionID, syncTargetID int64, modules []string) (map[string]map[int64]SyncMergeLocalChange, error) {
+	var lastSync sql.NullTime
+	err := db.QueryRow(
+		"SELECT synced_at FROM app_db_sync_target_states WHERE draft_version_id = ? AND sync_target_id = ?",
+		draftVersionID,
+		syncTargetID,
+	).Scan(&lastSync)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	changes := make(map[string]map[int64]SyncMergeLocalChange)
+	tableModules := map[string]string{"app_db_version_names": "version_names"}
+	markRows := func(moduleKey, query string) error {
+		rows, err := db.Query(query, draftVersionID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		changes[moduleKey] = make(map[int64]SyncMergeLocalChange)
+		for rows.Next() {
+			var (
+				id        int64
+				updatedAt sql.NullTime
+			)
+			if err := rows.Scan(&id, &updatedAt); err != nil {
+				return err
+			}
+			changed := !lastSync.Valid || (updatedAt.Valid && updatedAt.Time.After(lastSync.Time))
+			changes[moduleKey][id] = SyncMergeLocalChange{RowChanged: changed, Base: map[string]sql.NullString{}}
+		}
+		return rows.Err()
+	}
+
+	if shouldSyncModule(modules, "version_names") {
+		if err := markRows("version_names", "SELECT id, COALESCE(updated_at, created_at) FROM app_db_version_names WHERE id = ?"); err != nil {
+			return nil, err
+		}
+	}
+	for _, moduleKey := range syncRowModules {
+		if !shouldSyncModule(modules, moduleKey) {
+			continue
+		}
+		table := "app_db_" + syncTableSpecs[moduleKey].Table
+		tableModules[table] = moduleKey
+		if err := markRows(moduleKey, "SELECT id, COALESCE(updated_at, created_at) FROM "+table+" WHERE draft_version_id = ?"); err != nil {
+			return nil, err
+		}
+	}
+	if !lastSync.Valid {
+		return changes, nil
+	}
+
+	rows, err := db.Query(
+		"SELECT entity_table, entity_id, field_name, old_value FROM app_db_field_history WHERE draft_version_id = ? AND created_at > ? ORDER BY id ASC",
+		draftVersionID,
+		lastSync.Time,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			entityTable sql.NullString
+			entityID    sql.NullInt64
+			fieldName   sql.NullString
+			oldValue    sql.NullString
+		)
+		if err := rows.Scan(&entityTable, &entityID, &fieldName, &oldValue); err != nil {
+			return nil, err
+		}
+		moduleKey, ok := tableModules[entityTable.String]
+		if !ok {
+			continue
+		}
+		change, ok := changes[moduleKey][entityID.Int64]
+		if !ok {
+			continue
+		}
+		field := strings.TrimSpace(fieldName.String)
+		if _, seen := change.Base[field]; seen {
+			continue
+		}
+		change.Base[field] = syncMergeBaseValue(moduleKey, field, oldValue)
+		changes[moduleKey][entityID.Int64] = change
+	}
+	return changes, rows.Err()
+}
+
+// syncMergeBaseValue converts a JSON field history value into the normalized sync form.
+func syncMergeBaseValue(moduleKey, field string, raw sql.NullString) sql.NullString {
+	if !raw.Valid {
+		return sql.NullString{}
+	}
+	decoder := json.NewDecoder(strings.NewReader(raw.String))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return syncText(raw)
+	}
+	var text sql.NullString
+	switch typed := value.(type) {
+	case nil:
+		return sql.NullString{}
+	case string:
+		text = syncText(sql.NullString{String: typed, Valid: true})
+	case json.Number:
+		text = sql.NullString{String: typed.String(), Valid: true}
+	case bool:
+		text = syncInt(0)
+		if typed {
+			text = syncInt(1)
+		}
+	default:
+		return syncText(raw)
+	}
+	if moduleKey == "banners" && field == "type" && text.Valid {
+		if parsed, err := strconv.ParseInt(text.String, 10, 64); err == nil {
+			text = syncInt(mapBannerTypeForSync(parsed))
+		}
+	}
+	return text
+}
+
+// syncMergeDraftValue converts a normalized online value into a draft column value.
+func syncMergeDraftValue(moduleKey, field string, value sql.NullString) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	if _, ok := syncMergeNumericColumns[field]; ok {
+		parsed, err := strconv.ParseInt(strings.TrimSpace(value.String), 10, 64)
+		if err != nil {
+			return value.String
+		}
+		if moduleKey == "banners" && field == "type" {
+			return mapBannerTypeFromOnline(parsed)
+		}
+		return parsed
+	}
+	return value.String
+}
+
+type syncMergeApplied struct {
+	Stats         map[string]int
+	SubmissionIDs []int64
+	NeedConfirm   int
+	Table         string
+	SubmittedIDs  []int64
+}
+
+func applySyncMergePlanTx(tx *sql.Tx, draftVersionID, syncTargetID int64, moduleKey string, plan SyncMergePlan, snapshot *SyncPullSnapshot, operatorID int64, now time.Time) (syncMergeApplied, error) {
+	columns := syncVersionColumns
+	table := "app_db_version_names"
+	if moduleKey != "version_names" {
+		columns = syncTableSpecs[moduleKey].Columns
+		table = "app_db_" + syncTableSpecs[moduleKey].Table
+	}
+	applied := syncMergeApplied{Stats: map[string]int{}, SubmissionIDs: make([]int64, 0), Table: table}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = strings.Trim(column, "`")
+	}
+
+	submit := func(entityID int64, local, merged []sql.NullString) error {
+		base := make(map[string]interface{}, len(names))
+		current := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			if i < len(local) {
+				base[name] = syncMergeDraftValue(moduleKey, name, local[i])
+			}
+			current[name] = syncMergeDraftValue(moduleKey, name, merged[i])
+		}
+		payloadMap, err := loadLatestSubmissionPayloadTx(tx, draftVersionID, moduleKey, table, entityID)
+		if err != nil {
+			return err
+		}
+		if payloadMap == nil {
+			payloadMap = map[string]interface{}{"id": entityID}
+			for name, value := range base {
+				payloadMap[name] = value
+			}
+		}
+		for name, value := range current {
+			payloadMap[name] = value
+		}
+		result, err := submitEntityTx(tx, submitRequest{
+			DraftVersionID: draftVersionID,
+			ModuleKey:      moduleKey,
+			EntityTable:    table,
+			EntityID:       entityID,
+			SubmitBy:       operatorID,
+		}, payloadMap, base, "import_merge", now)
+		if err != nil {
+			return err
+		}
+		applied.SubmissionIDs = append(applied.SubmissionIDs, result.SubmissionID)
+		applied.SubmittedIDs = append(applied.SubmittedIDs, entityID)
+		if result.NeedConfirm {
+			applied.NeedConfirm++
+		}
+		return nil
+	}
+
+	inserts := make(map[int64]SyncMergeRow)
+	mappings := make([]SyncIDMapping, 0)
+	for _, row := range plan.Rows {
+		switch row.Action {
+		case syncMergeLink:
+			if moduleKey != "version_names" {
+				mappings = append(mappings, SyncIDMapping{ModuleKey: moduleKey, DraftID: row.DraftID, TargetID: row.TargetID})
+			}
+			applied.Stats["linked"]++
+		case syncMergeUpdate:
+			assignments := make([]string, 0, len(row.Fields)+2)
+			args := make([]interface{}, 0, len(row.Fields)+4)
+			for _, field := range row.Fields {
+				for i, name := range names {
+					if name == field.Field {
+						assignments = append(assignments, columns[i]+" = ?")
+						args = append(args, syncMergeDraftValue(moduleKey, name, row.Values[i]))
+					}
+				}
+			}
+			assignments = append(assignments, "updated_by = ?", "updated_at = ?", "row_version = row_version + 1")
+			args = append(args, nullableID(operatorID), now)
+			query := "UPDATE " + table + " SET " + strings.Join(assignments, ", ") + " WHERE id = ?"
+			args = append(args, row.DraftID)
+			if moduleKey != "version_names" {
+				query += " AND draft_version_id = ?"
+				args = append(args, draftVersionID)
+				mappings = append(mappings, SyncIDMapping{ModuleKey: moduleKey, DraftID: row.DraftID, TargetID: row.TargetID})
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return applied, err
+			}
+			if err := submit(row.DraftID, row.Local, row.Values); err != nil {
+				return applied, err
+			}
+			applied.Stats["updated"]++
+		case syncMergeDelete:
+			if _, err := tx.Exec(
+				"UPDATE "+table+" SET deleted_at = ?, deleted_by = ?, row_version = row_version + 1 WHERE id = ? AND draft_version_id = ? AND deleted_at IS NULL",
+				now,
+				nullableID(operatorID),
+				row.DraftID,
+				draftVersionID,
+			); err != nil {
+				return applied, err
+			}
+			if _, err := tx.Exec("DELETE FROM app_db_sync_id_map WHERE draft_version_id = ? AND sync_target_id = ? AND module_key = ? AND draft_row_id = ?", draftVersionID, syncTargetID, moduleKey, row.DraftID); err != nil {
+				return applied, err
+			}
+			applied.Stats["deleted"]++
+		case syncMergeInsert:
+			inserts[row.TargetID] = row
+		}
+	}
+	if err := upsertSyncIDMappings(tx, draftVersionID, syncTargetID, mappings, now); err != nil {
+		return applied, err
+	}
+
+	if len(inserts) == 0 {
+		return applied, nil
+	}
+	ids := make(map[int64]bool, len(inserts))
+	for id := range inserts {
+		ids[id] = true
+	}
+	if err := importSnapshotModulesTx(tx, draftVersionID, syncTargetID, filterSnapshotRows(snapshot, moduleKey, ids), operatorID, now); err != nil {
+		return applied, err
+	}
+	for targetID, row := range inserts {
+		var draftRowID int64
+		if err := tx.QueryRow(
+			"SELECT draft_row_id FROM app_db_sync_id_map WHERE draft_version_id = ? AND sync_target_id = ? AND module_key = ? AND target_row_id = ? ORDER BY id DESC LIMIT 1",
+			draftVersionID,
+			syncTargetID,
+			moduleKey,
+			targetID,
+		).Scan(&draftRowID); err != nil {
+			return applied, err
+		}
+		if err := submit(draftRowID, nil, row.Values); err != nil {
+			return applied, err
+		}
+		applied.Stats["inserted"]++
+	}
+	return applied, nil
+}
+
+func loadLatestSubmissionPayloadTx(tx *sql.Tx, draftVersionID int64, moduleKey, entityTable string, entityID int64) (map[string]interface{}, error) {
+	var payload sql.NullString
+	err := tx.QueryRow(
+		"SELECT payload_json FROM app_db_submissions WHERE draft_version_id = ? AND module_key = ? AND entity_table = ? AND entity_id = ? ORDER BY submit_version DESC LIMIT 1",
+		draftVersionID,
+		moduleKey,
+		entityTable,
+		entityID,
+	).Scan(&payload)
+	if err == sql.ErrNoRows || (err == nil && !payload.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decodePayload([]byte(payload.String))
+	if err != nil {
+		return nil, nil
+	}
+	return decoded, nil
+}
+
+// filterSnapshotRows keeps only the given online rows of one module.
+func filterSnapshotRows(snapshot *SyncPullSnapshot, moduleKey string, ids map[int64]bool) *SyncPullSnapshot {
+	filtered := &SyncPullSnapshot{Version: snapshot.Version}
+	switch moduleKey {
+	case "app_ui_fields":
+		if snapshot.AppUIFields != nil && ids[snapshot.AppUIFields.ID] {
+			filtered.AppUIFields = snapshot.AppUIFields
+		}
+	case "banners":
+		for _, item := range snapshot.Banners {
+			if ids[item.ID] {
+				filtered.Banners = append(filtered.Banners, item)
+			}
+		}
+	case "identities":
+		for _, item := range snapshot.Identities {
+			if ids[item.ID] {
+				filtered.Identities = append(filtered.Identities, item)
+			}
+		}
+	case "scenes":
+		for _, item := range snapshot.Scenes {
+			if ids[item.ID] {
+				filtered.Scenes = append(filtered.Scenes, item)
+			}
+		}
+	case "clothes_categories":
+		for _, item := range snapshot.ClothesCategories {
+			if ids[item.ID] {
+				filtered.ClothesCategories = append(filtered.ClothesCategories, item)
+			}
+		}
+	case "photo_hobbies":
+		for _, item := range snapshot.PhotoHobbies {
+			if ids[item.ID] {
+				filtered.PhotoHobbies = append(filtered.PhotoHobbies, item)
+			}
+		}
+	case "config_extra_steps":
+		for _, item := range snapshot.ExtraSteps {
+			if ids[item.ID] {
+				filtered.ExtraSteps = append(filtered.ExtraSteps, item)
+			}
+		}
+	}
+	return filtered
+}
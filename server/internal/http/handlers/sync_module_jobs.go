@@ -3,6 +3,7 @@ package handlers
 import (
   "database/sql"
   "net/http"
+  "strconv"
   "strings"
 
   "github.com/gin-gonic/gin"
@@ -27,9 +28,10 @@ func (h *SyncHandler) ListModuleJobs(c *gin.Context) {
 
   moduleKey := strings.TrimSpace(c.Query("module_key"))
 
-  query := `SELECT j.id, j.module_key, j.status, j.error_message, j.started_at, j.finished_at, j.created_at,
-    j.trigger_by, u.display_name, u.username
+  query := `SELECT j.id, j.job_id, j.module_key, j.status, j.error_message, j.started_at, j.finished_at, j.created_at,
+    j.trigger_by, u.display_name, u.username, sj.sync_target_id, sj.attempts, sj.max_attempts, sj.next_run_at
     FROM app_db_sync_module_jobs j
+    LEFT JOIN app_db_sync_jobs sj ON sj.id = j.job_id
     LEFT JOIN app_db_users u ON u.id = j.trigger_by
     WHERE j.draft_version_id = ?`
   args := []any{draftID}
@@ -37,6 +39,10 @@ func (h *SyncHandler) ListModuleJobs(c *gin.Context) {
     query += " AND j.module_key = ?"
     args = append(args, moduleKey)
   }
+  if strings.TrimSpace(c.Query("sync_target_id")) != "" {
+    query += " AND sj.sync_target_id = ?"
+    args = append(args, parseInt64Query(c, "sync_target_id"))
+  }
   query += " ORDER BY j.created_at DESC, j.id DESC"
 
   rows, err := h.db.Query(query, args...)
@@ -50,6 +56,7 @@ func (h *SyncHandler) ListModuleJobs(c *gin.Context) {
   for rows.Next() {
     var (
       id           int64
+      jobID        sql.NullInt64
       module       sql.NullString
       status       sql.NullString
       errorMessage sql.NullString
@@ -59,13 +66,18 @@ func (h *SyncHandler) ListModuleJobs(c *gin.Context) {
       triggerBy    sql.NullInt64
       displayName  sql.NullString
       username     sql.NullString
+      syncTargetID sql.NullInt64
+      attempts     sql.NullInt64
+      maxAttempts  sql.NullInt64
+      nextRunAt    sql.NullTime
     )
-    if err := rows.Scan(&id, &module, &status, &errorMessage, &startedAt, &finishedAt, &createdAt, &triggerBy, &displayName, &username); err != nil {
+    if err := rows.Scan(&id, &jobID, &module, &status, &errorMessage, &startedAt, &finishedAt, &createdAt, &triggerBy, &displayName, &username, &syncTargetID, &attempts, &maxAttempts, &nextRunAt); err != nil {
       c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
       return
     }
     items = append(items, gin.H{
       "id":             id,
+      "job_id":         nullableInt64Pointer(jobID),
       "module_key":     nullableString(module),
       "status":         nullableString(status),
       "error_message":  nullableString(errorMessage),
@@ -75,8 +87,113 @@ func (h *SyncHandler) ListModuleJobs(c *gin.Context) {
       "trigger_by":     nullableInt64Pointer(triggerBy),
       "trigger_name":   nullableString(displayName),
       "trigger_username": nullableString(username),
+      "sync_target_id": nullableInt64Pointer(syncTargetID),
+      "attempts":       nullableInt64Pointer(attempts),
+      "max_attempts":   nullableInt64Pointer(maxAttempts),
+      "next_run_at":    nullableTimePointer(nextRunAt),
     })
   }
 
   c.JSON(http.StatusOK, gin.H{"data": items})
 }
+
+// GetJob returns one queued sync job with its module jobs.
+// Args:
+//   c: Gin context.
+// Returns:
+//   None.
+func (h *SyncHandler) GetJob(c *gin.Context) {
+  if h.db == nil {
+    c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+    return
+  }
+
+  id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+  if err != nil || id <= 0 {
+    c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+    return
+  }
+
+  row := h.db.QueryRow(
+    `SELECT id, draft_version_id, sync_target_id, trigger_by, status, attempts, max_attempts, next_run_at, error_message,
+      target_app_version_name_id, started_at, finished_at, created_at, updated_at
+      FROM app_db_sync_jobs WHERE id = ?`,
+    id,
+  )
+  var (
+    jobID          int64
+    draftVersionID sql.NullInt64
+    syncTargetID   int64
+    triggerBy      sql.NullInt64
+    status         sql.NullString
+    attempts       sql.NullInt64
+    maxAttempts    sql.NullInt64
+    nextRunAt      sql.NullTime
+    errorMessage   sql.NullString
+    targetID       sql.NullInt64
+    startedAt      sql.NullTime
+    finishedAt     sql.NullTime
+    createdAt      sql.NullTime
+    updatedAt      sql.NullTime
+  )
+  if err := row.Scan(&jobID, &draftVersionID, &syncTargetID, &triggerBy, &status, &attempts, &maxAttempts, &nextRunAt, &errorMessage, &targetID, &startedAt, &finishedAt, &createdAt, &updatedAt); err != nil {
+    if err == sql.ErrNoRows {
+      c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+      return
+    }
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+
+  rows, err := h.db.Query(
+    "SELECT id, module_key, status, error_message, started_at, finished_at FROM app_db_sync_module_jobs WHERE job_id = ? ORDER BY id ASC",
+    jobID,
+  )
+  if err != nil {
+    c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+    return
+  }
+  defer rows.Close()
+
+  modules := make([]gin.H, 0)
+  for rows.Next() {
+    var (
+      moduleJobID  int64
+      moduleKey    sql.NullString
+      moduleStatus sql.NullString
+      moduleError  sql.NullString
+      moduleStart  sql.NullTime
+      moduleFinish sql.NullTime
+    )
+    if err := rows.Scan(&moduleJobID, &moduleKey, &moduleStatus, &moduleError, &moduleStart, &moduleFinish); err != nil {
+      c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+      return
+    }
+    modules = append(modules, gin.H{
+      "id":            moduleJobID,
+      "module_key":    nullableString(moduleKey),
+      "status":        nullableString(moduleStatus),
+      "error_message": nullableString(moduleError),
+      "started_at":    nullableTimePointer(moduleStart),
+      "finished_at":   nullableTimePointer(moduleFinish),
+    })
+  }
+
+  c.JSON(http.StatusOK, gin.H{"data": gin.H{
+    "id":                         jobID,
+    "draft_version_id":           nullableInt64Pointer(draftVersionID),
+    "sync_target_id":             syncTargetID,
+    "trigger_by":                 nullableInt64Pointer(triggerBy),
+    "status":                     nullableString(status),
+    "attempts":                   nullableInt64Pointer(attempts),
+    "max_attempts":               nullableInt64Pointer(maxAttempts),
+    "next_run_at":                nullableTimePointer(nextRunAt),
+    "error_message":              nullableString(errorMessage),
+    "target_app_version_name_id": nullableInt64Pointer(targetID),
+    "started_at":                 nullableTimePointer(startedAt),
+    "finished_at":                nullableTimePointer(finishedAt),
+    "created_at":                 nullableTimePointer(createdAt),
+    "updated_at":                 nullableTimePointer(updatedAt),
+    "modules":                    modules,
+  }})
+}
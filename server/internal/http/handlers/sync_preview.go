@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type syncPreviewRequest struct {
+	DraftVersionID int64    `json:"draft_version_id"`
+	SyncTargetID   int64    `json:"sync_target_id"`
+	Modules        []string `json:"modules"`
+}
+
+// Preview shows what a sync would change online without writing anything.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) Preview(c *gin.Context) {
+	if strings.ToLower(strings.TrimSpace(h.cfg.AppMode)) == "online" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available in online mode"})
+		return
+	}
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	var req syncPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.DraftVersionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+		return
+	}
+
+	target, err := h.resolveSyncTarget(req.SyncTargetID)
+	if err != nil {
+		respondSyncTargetError(c, err)
+		return
+	}
+
+	invalidModules := findInvalidModules(req.Modules)
+	if len(invalidModules) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_modules", "modules": invalidModules})
+		return
+	}
+	modules := normalizeModules(req.Modules)
+
+	draftVersion, err := loadDraftVersion(h.db, req.DraftVersionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	data, err := loadDraftData(h.db, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	appVersionName := strings.TrimSpace(nullableStringValue(draftVersion.AppVersionName))
+	locationName := strings.TrimSpace(nullableStringValue(draftVersion.LocationName))
+	validationErrors := ValidateSyncPayload(buildSyncValidationPayload(data, appVersionName, locationName), modules)
+
+	payload := buildSyncPushFromDraft(syncRequest{DraftVersionID: req.DraftVersionID, Modules: modules}, draftVersion, data)
+	snapshot, err := h.fetchRemoteSnapshot(c.Request.Context(), target, 0, appVersionName)
+	if err != nil && !errors.Is(err, errRemoteVersionNotFound) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, errRemoteVersionNotFound) {
+		snapshot = nil
+	}
+
+	targetID := int64(0)
+	if snapshot != nil {
+		targetID = snapshot.Version.TargetID
+	}
+	changes := buildSyncPreview(payload, snapshot, modules)
+
+	c.JSON(http.StatusOK, gin.H{
+		"draft_version_id":           req.DraftVersionID,
+		"sync_target_id":             req.SyncTargetID,
+		"target_app_version_name_id": targetID,
+		"need_confirm":               targetID > 0,
+		"validation_errors":          validationErrors,
+		"changes":                    changes,
+	})
+}
+
+// buildSyncPreview diffs a push payload against an online snapshot (nil when the version is not online yet).
+func buildSyncPreview(payload SyncPushRequest, snapshot *SyncPullSnapshot, modules []string) map[string]SyncModuleChanges {
+	changes := make(map[string]SyncModuleChanges)
+	desiredData := buildDraftDataFromPush(payload)
+	onlineData := draftData{}
+	targetID := int64(0)
+	if snapshot != nil {
+		targetID = snapshot.Version.TargetID
+		onlineData = buildSnapshotDraftData(snapshot)
+	}
+
+	if shouldSyncModule(modules, "version_names") {
+		aiModal := strings.TrimSpace(payload.Version.AiModal)
+		if aiModal == "" {
+			aiModal = "SD"
+		}
+		status := int64(1)
+		if payload.Version.Status != nil {
+			status = *payload.Version.Status
+		}
+		desired := buildSyncVersionValues(payload.Version.AppVersionName, payload.Version.LocationName, status, payload.Version.FeishuFieldNames, aiModal)
+		var existing []sql.NullString
+		if snapshot != nil {
+			onlineStatus := int64(1)
+			if snapshot.Version.Status != nil {
+				onlineStatus = *snapshot.Version.Status
+			}
+			existing = buildSyncVersionValues(snapshot.Version.AppVersionName, snapshot.Version.LocationName, onlineStatus, snapshot.Version.FeishuFieldNames, snapshot.Version.AiModal)
+		}
+		existingRows, diff := diffSyncVersion(targetID, existing, desired)
+		changes["version_names"] = DescribeSyncDiff(syncVersionColumns, existingRows, diff)
+	}
+
+	for _, moduleKey := range syncRowModules {
+		if !shouldSyncModule(modules, moduleKey) {
+			continue
+		}
+		existing := buildSyncOnlineRows(moduleKey, onlineData)
+		diff := DiffSyncRows(existing, buildSyncDesiredRows(moduleKey, desiredData))
+		changes[moduleKey] = DescribeSyncDiff(syncTableSpecs[moduleKey].Columns, existing, diff)
+	}
+	return changes
+}
+
+// buildSnapshotDraftData converts an online snapshot into draft rows keyed by online id.
+func buildSnapshotDraftData(snapshot *SyncPullSnapshot) draftData {
+	return buildDraftDataFromPush(SyncPushRequest{
+		AppUIFields:       snapshot.AppUIFields,
+		Banners:           snapshot.Banners,
+		Identities:        snapshot.Identities,
+		Scenes:            snapshot.Scenes,
+		ClothesCategories: snapshot.ClothesCategories,
+		PhotoHobbies:      snapshot.PhotoHobbies,
+		ExtraSteps:        snapshot.ExtraSteps,
+	})
+}
+
+// buildSyncOnlineRows builds diff rows of online data where ID is the online row id.
+func buildSyncOnlineRows(moduleKey string, onlineData draftData) []SyncDiffRow {
+	rows := buildSyncDesiredRows(moduleKey, onlineData)
+	for i := range rows {
+		rows[i].ID = rows[i].DraftID
+		rows[i].DraftID = 0
+		rows[i].TargetID = 0
+	}
+	return rows
+}
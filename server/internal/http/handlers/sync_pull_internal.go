@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,10 +17,19 @@ import (
 	"shushu-app-ui-dashboard/internal/http/middleware"
 )
 
+var errRemoteVersionNotFound = errors.New("version not found")
+
 type syncImportRequest struct {
+	SyncTargetID   int64  `json:"sync_target_id"`
 	TargetID       int64  `json:"target_app_version_name_id"`
 	AppVersionName string `json:"app_version_name"`
 	DraftVersionID int64  `json:"draft_version_id"`
+	// Mode "merge" pulls selected online changes into the draft instead of replacing it.
+	Mode        string                `json:"mode"`
+	Modules     []string              `json:"modules"`
+	Rows        map[string][]int64    `json:"rows"`
+	Resolutions []syncMergeResolution `json:"resolutions"`
+	DryRun      bool                  `json:"dry_run"`
 }
 
 // PullVersions loads online version list for import entry.
@@ -40,16 +50,13 @@ func (h *SyncHandler) PullVersions(c *gin.Context) {
 		return
 	}
 
-	if strings.TrimSpace(h.cfg.SyncTargetURL) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync target not configured"})
-		return
-	}
-	if strings.TrimSpace(h.cfg.SyncAPIKey) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync api key not configured"})
+	target, err := h.resolveSyncTarget(parseInt64Query(c, "sync_target_id"))
+	if err != nil {
+		respondSyncTargetError(c, err)
 		return
 	}
 
-	data, err := h.fetchRemoteVersions(c.Request.Context())
+	data, err := h.fetchRemoteVersions(c.Request.Context(), target)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
@@ -75,15 +82,6 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 		return
 	}
 
-	if strings.TrimSpace(h.cfg.SyncTargetURL) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync target not configured"})
-		return
-	}
-	if strings.TrimSpace(h.cfg.SyncAPIKey) == "" {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync api key not configured"})
-		return
-	}
-
 	var req syncImportRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
@@ -94,7 +92,13 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 		return
 	}
 
-	snapshot, err := h.fetchRemoteSnapshot(c.Request.Context(), req.TargetID, req.AppVersionName)
+	target, err := h.resolveSyncTarget(req.SyncTargetID)
+	if err != nil {
+		respondSyncTargetError(c, err)
+		return
+	}
+
+	snapshot, err := h.fetchRemoteSnapshot(c.Request.Context(), target, req.TargetID, req.AppVersionName)
 	if err != nil {
 		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
@@ -106,7 +110,13 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 		operatorID = claims.UserID
 	}
 
+	if strings.EqualFold(strings.TrimSpace(req.Mode), syncImportModeMerge) {
+		h.mergeFromOnline(c, req, snapshot, operatorID)
+		return
+	}
+
 	draftVersionID := req.DraftVersionID
+	before := draftSnapshot{}
 	now := time.Now()
 	tx, err := h.db.Begin()
 	if err != nil {
@@ -127,6 +137,14 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
 			return
 		}
+		if !requireDraftEditable(c, tx, draftVersionID) {
+			return
+		}
+		before, err = snapshotDraftTx(tx, draftVersionID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+			return
+		}
 		if err := purgeDraftVersionTx(tx, draftVersionID); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "clear draft failed"})
 			return
@@ -143,12 +161,28 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 		}
 	}
 
-	if err := importSnapshotModulesTx(tx, draftVersionID, snapshot, operatorID, now); err != nil {
+	if err := importSnapshotModulesTx(tx, draftVersionID, req.SyncTargetID, snapshot, operatorID, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "import failed"})
+		return
+	}
+	if err := updateDraftSyncStatusTx(tx, draftVersionID, req.SyncTargetID, "imported", "", snapshot.Version.TargetID, now); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "import failed"})
 		return
 	}
 
-	if err := recordImportAuditTx(tx, draftVersionID, snapshot.Version.TargetID, operatorID, req.DraftVersionID > 0, now); err != nil {
+	after, err := snapshotDraftTx(tx, draftVersionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if err := recordDraftSnapshotChangesTx(tx, before, after, operatorID, map[string]interface{}{
+		"source":         "import_from_online",
+		"sync_target_id": req.SyncTargetID,
+	}, now); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+	if err := recordImportAuditTx(tx, draftVersionID, req.SyncTargetID, snapshot.Version.TargetID, operatorID, req.DraftVersionID > 0, now); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
 		return
 	}
@@ -161,21 +195,24 @@ func (h *SyncHandler) ImportFromOnline(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status":                     "imported",
 		"draft_version_id":           draftVersionID,
+		"sync_target_id":             req.SyncTargetID,
 		"target_app_version_name_id": snapshot.Version.TargetID,
 		"app_version_name":           snapshot.Version.AppVersionName,
 		"location_name":              snapshot.Version.LocationName,
 	})
 }
 
-func (h *SyncHandler) fetchRemoteVersions(ctx context.Context) ([]SyncRemoteVersion, error) {
-	endpoint := buildRemoteSyncURL(h.cfg.SyncTargetURL, "/sync/versions")
+func (h *SyncHandler) fetchRemoteVersions(ctx context.Context, target *syncTarget) ([]SyncRemoteVersion, error) {
+	endpoint := buildRemoteSyncURL(target.URL, "/sync/versions")
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("X-API-Key", strings.TrimSpace(h.cfg.SyncAPIKey))
+	if err := target.signer.Sign(req, nil, time.Now()); err != nil {
+		return nil, err
+	}
 
-	resp, err := h.client.Do(req)
+	resp, err := target.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -197,8 +234,8 @@ func (h *SyncHandler) fetchRemoteVersions(ctx context.Context) ([]SyncRemoteVers
 	return parsed.Data, nil
 }
 
-func (h *SyncHandler) fetchRemoteSnapshot(ctx context.Context, targetID int64, appVersionName string) (*SyncPullSnapshot, error) {
-	endpoint := buildRemoteSyncURL(h.cfg.SyncTargetURL, "/sync/snapshot")
+func (h *SyncHandler) fetchRemoteSnapshot(ctx context.Context, target *syncTarget, targetID int64, appVersionName string) (*SyncPullSnapshot, error) {
+	endpoint := buildRemoteSyncURL(target.URL, "/sync/snapshot")
 	query := url.Values{}
 	if targetID > 0 {
 		query.Set("target_app_version_name_id", fmt.Sprintf("%d", targetID))
@@ -215,15 +252,20 @@ func (h *SyncHandler) fetchRemoteSnapshot(ctx context.Context, targetID int64, a
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("X-API-Key", strings.TrimSpace(h.cfg.SyncAPIKey))
+	if err := target.signer.Sign(req, nil, time.Now()); err != nil {
+		return nil, err
+	}
 
-	resp, err := h.client.Do(req)
+	resp, err := target.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errRemoteVersionNotFound
+	}
 	if resp.StatusCode >= http.StatusMultipleChoices {
 		return nil, parseRemoteError(body, "pull snapshot failed")
 	}
@@ -259,13 +301,14 @@ func buildRemoteSyncURL(raw, suffix string) string {
 	return trimmed + suffix
 }
 
-func recordImportAuditTx(tx *sql.Tx, draftVersionID, targetID, operatorID int64, overwrite bool, now time.Time) error {
+func recordImportAuditTx(tx *sql.Tx, draftVersionID, syncTargetID, targetID, operatorID int64, overwrite bool, now time.Time) error {
 	actionMode := "create"
 	if overwrite {
 		actionMode = "overwrite"
 	}
 	payload := map[string]interface{}{
 		"source":                     "online",
+		"sync_target_id":             syncTargetID,
 		"target_app_version_name_id": targetID,
 		"mode":                       actionMode,
 	}
@@ -273,8 +316,8 @@ func recordImportAuditTx(tx *sql.Tx, draftVersionID, targetID, operatorID int64,
 	if err != nil {
 		return err
 	}
-	_, err = tx.Exec(
-		"INSERT INTO app_db_audit_logs (draft_version_id, entity_table, entity_id, action, actor_id, detail_json, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+	err = insertAuditLog(
+		tx,
 		draftVersionID,
 		"sync_import",
 		targetID,
@@ -327,7 +370,7 @@ func updateDraftVersionMetaTx(tx *sql.Tx, draftVersionID int64, version SyncRemo
 		status = *version.Status
 	}
 	_, err = tx.Exec(
-		"UPDATE app_db_version_names SET app_version_name = ?, location_name = ?, feishu_field_names = ?, ai_modal = ?, status = ?, draft_status = ?, submit_version = ?, last_submit_by = NULL, last_submit_at = NULL, confirmed_by = NULL, confirmed_at = NULL, sync_status = ?, sync_message = NULL, synced_at = ?, target_app_version_name_id = ?, updated_by = ?, updated_at = ? WHERE id = ?",
+		"UPDATE app_db_version_names SET app_version_name = ?, location_name = ?, feishu_field_names = ?, ai_modal = ?, status = ?, draft_status = ?, submit_version = ?, last_submit_by = NULL, last_submit_at = NULL, confirmed_by = NULL, confirmed_at = NULL, sync_status = ?, sync_message = NULL, synced_at = ?, target_app_version_name_id = ?, updated_by = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
 		nullIfEmpty(version.AppVersionName),
 		nullIfEmpty(version.LocationName),
 		nullIfEmpty(version.FeishuFieldNames),
@@ -377,8 +420,7 @@ func insertDraftVersionFromSnapshotTx(tx *sql.Tx, version SyncRemoteVersion, ope
 	return result.LastInsertId()
 }
 
-func importSnapshotModulesTx(tx *sql.Tx, draftVersionID int64, snapshot *SyncPullSnapshot, operatorID int64, now time.Time) error {
-	targetVersionID := snapshot.Version.TargetID
+func importSnapshotModulesTx(tx *sql.Tx, draftVersionID, syncTargetID int64, snapshot *SyncPullSnapshot, operatorID int64, now time.Time) error {
 	appVersionName := snapshot.Version.AppVersionName
 
 	if snapshot.AppUIFields != nil {
@@ -409,7 +451,7 @@ func importSnapshotModulesTx(tx *sql.Tx, draftVersionID int64, snapshot *SyncPul
 		}
 		draftID, _ := result.LastInsertId()
 		if snapshot.AppUIFields.ID > 0 {
-			if err := upsertSyncIDMappings(tx, draftVersionID, []SyncIDMapping{{
+			if err := upsertSyncIDMappings(tx, draftVersionID, syncTargetID, []SyncIDMapping{{
 				ModuleKey: "app_ui_fields",
 				DraftID:   draftID,
 				TargetID:  snapshot.AppUIFields.ID,
@@ -586,21 +628,11 @@ func importSnapshotModulesTx(tx *sql.Tx, draftVersionID int64, snapshot *SyncPul
 	allMappings = append(allMappings, photoMappings...)
 	allMappings = append(allMappings, extraMappings...)
 	if len(allMappings) > 0 {
-		if err := upsertSyncIDMappings(tx, draftVersionID, allMappings, now); err != nil {
+		if err := upsertSyncIDMappings(tx, draftVersionID, syncTargetID, allMappings, now); err != nil {
 			return err
 		}
 	}
 
-	if _, err := tx.Exec(
-		"UPDATE app_db_version_names SET target_app_version_name_id = ?, sync_status = ?, sync_message = NULL, synced_at = ? WHERE id = ?",
-		nullableID(targetVersionID),
-		"imported",
-		now,
-		draftVersionID,
-	); err != nil {
-		return err
-	}
-
 	return nil
 }
 
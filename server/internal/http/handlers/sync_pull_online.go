@@ -94,7 +94,7 @@ func (h *SyncPushHandler) Snapshot(c *gin.Context) {
 		return
 	}
 
-	version, err := h.loadRemoteVersion(targetID)
+	snapshot, err := loadRemoteSnapshot(h.db, targetID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			c.JSON(http.StatusNotFound, gin.H{"error": "version not found"})
@@ -104,58 +104,56 @@ func (h *SyncPushHandler) Snapshot(c *gin.Context) {
 		return
 	}
 
-	appUI, err := h.loadRemoteAppUIFields(targetID)
+	c.JSON(http.StatusOK, gin.H{"data": snapshot})
+}
+
+func loadRemoteSnapshot(db sqlReader, targetID int64) (*SyncPullSnapshot, error) {
+	version, err := loadRemoteVersion(db, targetID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	banners, err := h.loadRemoteBanners(version.AppVersionName)
+	appUI, err := loadRemoteAppUIFields(db, targetID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	identities, err := h.loadRemoteIdentities(version.AppVersionName)
+	banners, err := loadRemoteBanners(db, version.AppVersionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	scenes, err := h.loadRemoteScenes(version.AppVersionName)
+	identities, err := loadRemoteIdentities(db, version.AppVersionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	clothes, err := h.loadRemoteClothesCategories(version.AppVersionName)
+	scenes, err := loadRemoteScenes(db, version.AppVersionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	photoHobbies, err := h.loadRemotePhotoHobbies(version.AppVersionName)
+	clothes, err := loadRemoteClothesCategories(db, version.AppVersionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-	extraSteps, err := h.loadRemoteExtraSteps(targetID)
+	photoHobbies, err := loadRemotePhotoHobbies(db, version.AppVersionName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
-		return
+		return nil, err
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"data": SyncPullSnapshot{
-			Version:           version,
-			AppUIFields:       appUI,
-			Banners:           banners,
-			Identities:        identities,
-			Scenes:            scenes,
-			ClothesCategories: clothes,
-			PhotoHobbies:      photoHobbies,
-			ExtraSteps:        extraSteps,
-		},
-	})
+	extraSteps, err := loadRemoteExtraSteps(db, targetID)
+	if err != nil {
+		return nil, err
+	}
+	return &SyncPullSnapshot{
+		Version:           version,
+		AppUIFields:       appUI,
+		Banners:           banners,
+		Identities:        identities,
+		Scenes:            scenes,
+		ClothesCategories: clothes,
+		PhotoHobbies:      photoHobbies,
+		ExtraSteps:        extraSteps,
+	}, nil
 }
 
-func (h *SyncPushHandler) loadRemoteVersion(targetID int64) (SyncRemoteVersion, error) {
-	row := h.db.QueryRow(
+func loadRemoteVersion(db sqlReader, targetID int64) (SyncRemoteVersion, error) {
+	row := db.QueryRow(
 		"SELECT id, app_version_name, location_name, feishu_field_names, ai_modal, status, updated_at FROM app_version_names WHERE id = ? LIMIT 1",
 		targetID,
 	)
@@ -182,8 +180,8 @@ func (h *SyncPushHandler) loadRemoteVersion(targetID int64) (SyncRemoteVersion,
 	}, nil
 }
 
-func (h *SyncPushHandler) loadRemoteAppUIFields(targetID int64) (*SyncPushAppUIFields, error) {
-	row := h.db.QueryRow(
+func loadRemoteAppUIFields(db sqlReader, targetID int64) (*SyncPushAppUIFields, error) {
+	row := db.QueryRow(
 		"SELECT id, home_title_left, home_title_right, home_subtitle, start_experience, step1_music, step1_music_text, step1_title, step2_music, step2_music_text, step2_title, status, print_wait FROM app_ui_fields WHERE app_version_name_id = ? ORDER BY id DESC LIMIT 1",
 		targetID,
 	)
@@ -239,8 +237,8 @@ func (h *SyncPushHandler) loadRemoteAppUIFields(targetID int64) (*SyncPushAppUIF
 	}, nil
 }
 
-func (h *SyncPushHandler) loadRemoteBanners(appVersionName string) ([]SyncPushBanner, error) {
-	rows, err := h.db.Query(
+func loadRemoteBanners(db sqlReader, appVersionName string) ([]SyncPushBanner, error) {
+	rows, err := db.Query(
 		"SELECT id, title, image, sort, is_active, type FROM banners WHERE app_version_name = ? ORDER BY sort ASC, id ASC",
 		appVersionName,
 	)
@@ -273,8 +271,8 @@ func (h *SyncPushHandler) loadRemoteBanners(appVersionName string) ([]SyncPushBa
 	return items, nil
 }
 
-func (h *SyncPushHandler) loadRemoteIdentities(appVersionName string) ([]SyncPushIdentity, error) {
-	rows, err := h.db.Query(
+func loadRemoteIdentities(db sqlReader, appVersionName string) ([]SyncPushIdentity, error) {
+	rows, err := db.Query(
 		"SELECT id, name, image, sort, status FROM identities WHERE app_version_name = ? ORDER BY sort ASC, id ASC",
 		appVersionName,
 	)
@@ -305,8 +303,8 @@ func (h *SyncPushHandler) loadRemoteIdentities(appVersionName string) ([]SyncPus
 	return items, nil
 }
 
-func (h *SyncPushHandler) loadRemoteScenes(appVersionName string) ([]SyncPushScene, error) {
-	rows, err := h.db.Query(
+func loadRemoteScenes(db sqlReader, appVersionName string) ([]SyncPushScene, error) {
+	rows, err := db.Query(
 		"SELECT id, name, image, `desc`, music, watermark_path, need_watermark, sort, status, oss_style FROM scenes WHERE app_version_name = ? ORDER BY sort ASC, id ASC",
 		appVersionName,
 	)
@@ -358,8 +356,8 @@ func (h *SyncPushHandler) loadRemoteScenes(appVersionName string) ([]SyncPushSce
 	return items, nil
 }
 
-func (h *SyncPushHandler) loadRemoteClothesCategories(appVersionName string) ([]SyncPushClothes, error) {
-	rows, err := h.db.Query(
+func loadRemoteClothesCategories(db sqlReader, appVersionName string) ([]SyncPushClothes, error) {
+	rows, err := db.Query(
 		"SELECT id, name, image, sort, status, music, `desc`, music_text FROM clothes_categories WHERE app_version_name = ? ORDER BY sort ASC, id ASC",
 		appVersionName,
 	)
@@ -396,8 +394,8 @@ func (h *SyncPushHandler) loadRemoteClothesCategories(appVersionName string) ([]
 	return items, nil
 }
 
-func (h *SyncPushHandler) loadRemotePhotoHobbies(appVersionName string) ([]SyncPushPhotoHobby, error) {
-	rows, err := h.db.Query(
+func loadRemotePhotoHobbies(db sqlReader, appVersionName string) ([]SyncPushPhotoHobby, error) {
+	rows, err := db.Query(
 		"SELECT id, name, image, sort, status, music, music_text, `desc` FROM photo_hobbies WHERE app_version_name = ? ORDER BY sort ASC, id ASC",
 		appVersionName,
 	)
@@ -434,8 +432,8 @@ func (h *SyncPushHandler) loadRemotePhotoHobbies(appVersionName string) ([]SyncP
 	return items, nil
 }
 
-func (h *SyncPushHandler) loadRemoteExtraSteps(targetID int64) ([]SyncPushExtraStep, error) {
-	rows, err := h.db.Query(
+func loadRemoteExtraSteps(db sqlReader, targetID int64) ([]SyncPushExtraStep, error) {
+	rows, err := db.Query(
 		"SELECT id, step_index, field_name, label, music, music_text, status FROM config_extra_steps WHERE app_version_name_id = ? ORDER BY step_index ASC, id ASC",
 		targetID,
 	)
@@ -73,7 +73,7 @@ func (h *SyncPushHandler) Push(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 		return
 	}
-	if targetID > 0 && !req.Confirm {
+	if targetID > 0 && !req.Confirm && !req.DryRun {
 		c.JSON(http.StatusConflict, gin.H{
 			"need_confirm":               true,
 			"reason":                     "app_version_name_exists",
@@ -107,67 +107,62 @@ func (h *SyncPushHandler) Push(c *gin.Context) {
 		_ = tx.Rollback()
 	}()
 
-	if shouldSyncModule(modules, "version_names") {
-		if targetID > 0 {
-			if err := updateAppVersionName(tx, targetID, appVersionName, locationName, status, feishuFields, aiModal, now); err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-				return
-			}
-		} else {
-			targetID, err = insertAppVersionName(tx, appVersionName, locationName, status, feishuFields, aiModal, now)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+	// The archived snapshot must be the exact state this push replaces, so it is read
+	// under the version row lock that serializes pushes to the same online version.
+	var previous *SyncPullSnapshot
+	if targetID > 0 && !req.DryRun {
+		if err := lockAppVersionNameTx(tx, targetID); err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusConflict, gin.H{"error": "version_changed", "target_app_version_name_id": targetID})
 				return
 			}
-		}
-	}
-
-	if shouldSyncModule(modules, "app_ui_fields") {
-		if err := syncAppUIFields(tx, targetID, draftData.AppUIFields, now); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 			return
 		}
-	}
-	if shouldSyncModule(modules, "banners") {
-		if err := syncBanners(tx, appVersionName, draftData.Banners, now); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+		previous, err = loadRemoteSnapshot(tx, targetID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
 			return
 		}
 	}
-	if shouldSyncModule(modules, "identities") {
-		if err := syncIdentities(tx, appVersionName, draftData.Identities, now); err != nil {
+
+	existingTargetID := targetID
+	changes := make(map[string]SyncModuleChanges)
+	if shouldSyncModule(modules, "version_names") {
+		var versionChanges SyncModuleChanges
+		targetID, versionChanges, err = syncVersionName(tx, targetID, appVersionName, locationName, status, feishuFields, aiModal, now)
+		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
 			return
 		}
+		changes["version_names"] = versionChanges
 	}
-	if shouldSyncModule(modules, "scenes") {
-		if err := syncScenes(tx, appVersionName, draftData.Scenes, now); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-			return
-		}
+
+	moduleChanges, mappings, err := syncModuleRows(tx, modules, appVersionName, targetID, draftData, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+		return
 	}
-	if shouldSyncModule(modules, "clothes_categories") {
-		if err := syncClothesCategories(tx, appVersionName, draftData.ClothesCategories, now); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-			return
-		}
+	stats := make(map[string]SyncModuleStats, len(changes)+len(moduleChanges))
+	for moduleKey, moduleChange := range moduleChanges {
+		changes[moduleKey] = moduleChange
 	}
-	if shouldSyncModule(modules, "photo_hobbies") {
-		if err := syncPhotoHobbies(tx, appVersionName, draftData.PhotoHobbies, now); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
-			return
-		}
+	for moduleKey, moduleChange := range changes {
+		stats[moduleKey] = moduleChange.Stats
 	}
-	if shouldSyncModule(modules, "config_extra_steps") {
-		if err := syncExtraSteps(tx, targetID, draftData.ExtraSteps, now); err != nil {
+
+	if req.DryRun {
+		if err := tx.Rollback(); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
 			return
 		}
-	}
-
-	mappings, err := buildSyncMappings(tx, modules, appVersionName, targetID, draftData)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "sync failed"})
+		c.JSON(http.StatusOK, gin.H{
+			"status":                     "dry_run",
+			"target_app_version_name_id": existingTargetID,
+			"need_confirm":               existingTargetID > 0,
+			"stats":                      stats,
+			"changes":                    changes,
+		})
 		return
 	}
 
@@ -180,5 +175,12 @@ func (h *SyncPushHandler) Push(c *gin.Context) {
 		"status":                     "synced",
 		"target_app_version_name_id": targetID,
 		"mappings":                   mappings,
+		"stats":                      stats,
+		"previous_snapshot":          previous,
 	})
 }
+
+func lockAppVersionNameTx(tx *sql.Tx, targetID int64) error {
+	var lockedID int64
+	return tx.QueryRow("SELECT id FROM app_version_names WHERE id = ? FOR UPDATE", targetID).Scan(&lockedID)
+}
@@ -0,0 +1,618 @@
+package handlers
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncModuleStats counts row changes applied to one module during a push.
+type SyncModuleStats struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Deleted   int `json:"deleted"`
+	Unchanged int `json:"unchanged"`
+}
+
+// SyncDiffRow is a normalized row used to diff draft data against online rows.
+// ID is the online row id, DraftID/TargetID come from the draft side.
+type SyncDiffRow struct {
+	ID       int64
+	DraftID  int64
+	TargetID int64
+	Key      string
+	Values   []sql.NullString
+}
+
+// SyncRowDiff is the set of operations needed to turn online rows into the draft rows.
+type SyncRowDiff struct {
+	Inserts   []SyncDiffRow
+	Updates   []SyncDiffRow
+	Unchanged []SyncDiffRow
+	Deletes   []int64
+}
+
+// Stats returns the per-operation counts of the diff.
+// Args:
+//
+//	None.
+//
+// Returns:
+//
+//	SyncModuleStats: Row counts.
+func (d SyncRowDiff) Stats() SyncModuleStats {
+	return SyncModuleStats{
+		Inserted:  len(d.Inserts),
+		Updated:   len(d.Updates),
+		Deleted:   len(d.Deletes),
+		Unchanged: len(d.Unchanged),
+	}
+}
+
+// DiffSyncRows matches desired rows to existing rows and classifies the changes.
+// Rows are matched by TargetID first, then by natural key; unmatched existing rows are deleted.
+// Args:
+//
+//	existing: Rows currently stored online.
+//	desired: Rows built from the draft.
+//
+// Returns:
+//
+//	SyncRowDiff: Inserts, updates, unchanged rows and deletions.
+func DiffSyncRows(existing, desired []SyncDiffRow) SyncRowDiff {
+	byID := make(map[int64]int, len(existing))
+	byKey := make(map[string][]int)
+	for i, row := range existing {
+		byID[row.ID] = i
+		if row.Key != "" {
+			byKey[row.Key] = append(byKey[row.Key], i)
+		}
+	}
+
+	claimed := make([]bool, len(existing))
+	matches := make([]int, len(desired))
+	for i, row := range desired {
+		matches[i] = -1
+		if row.TargetID <= 0 {
+			continue
+		}
+		if idx, ok := byID[row.TargetID]; ok && !claimed[idx] {
+			matches[i] = idx
+			claimed[idx] = true
+		}
+	}
+	for i, row := range desired {
+		if matches[i] >= 0 || row.Key == "" {
+			continue
+		}
+		for _, idx := range byKey[row.Key] {
+			if !claimed[idx] {
+				matches[i] = idx
+				claimed[idx] = true
+				break
+			}
+		}
+	}
+
+	diff := SyncRowDiff{}
+	for i, row := range desired {
+		idx := matches[i]
+		if idx < 0 {
+			diff.Inserts = append(diff.Inserts, row)
+			continue
+		}
+		row.ID = existing[idx].ID
+		if syncValuesEqual(existing[idx].Values, row.Values) {
+			diff.Unchanged = append(diff.Unchanged, row)
+		} else {
+			diff.Updates = append(diff.Updates, row)
+		}
+	}
+	for i, row := range existing {
+		if !claimed[i] {
+			diff.Deletes = append(diff.Deletes, row.ID)
+		}
+	}
+	return diff
+}
+
+func syncValuesEqual(left, right []sql.NullString) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for i := range left {
+		if left[i].Valid != right[i].Valid {
+			return false
+		}
+		if left[i].Valid && left[i].String != right[i].String {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncFieldChange is one column difference; nil means NULL/empty.
+type SyncFieldChange struct {
+	Field string  `json:"field"`
+	Old   *string `json:"old"`
+	New   *string `json:"new"`
+}
+
+// SyncRowChange describes an added, removed or modified row.
+type SyncRowChange struct {
+	Action   string            `json:"action"`
+	DraftID  int64             `json:"draft_id,omitempty"`
+	TargetID int64             `json:"target_id,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Fields   []SyncFieldChange `json:"fields"`
+}
+
+// SyncModuleChanges is the effect of a push on one module.
+type SyncModuleChanges struct {
+	Stats SyncModuleStats `json:"stats"`
+	Rows  []SyncRowChange `json:"rows"`
+}
+
+// DescribeSyncDiff expands a diff into per-row, per-field changes.
+// Args:
+//
+//	columns: Column names matching the row values.
+//	existing: Rows the diff was computed against.
+//	diff: Diff returned by DiffSyncRows.
+//
+// Returns:
+//
+//	SyncModuleChanges: Stats plus added/removed/modified rows.
+func DescribeSyncDiff(columns []string, existing []SyncDiffRow, diff SyncRowDiff) SyncModuleChanges {
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = strings.Trim(column, "`")
+	}
+	byID := make(map[int64]SyncDiffRow, len(existing))
+	for _, row := range existing {
+		byID[row.ID] = row
+	}
+
+	changes := SyncModuleChanges{Stats: diff.Stats(), Rows: make([]SyncRowChange, 0)}
+	for _, row := range diff.Inserts {
+		changes.Rows = append(changes.Rows, SyncRowChange{
+			Action:  "added",
+			DraftID: row.DraftID,
+			Key:     row.Key,
+			Fields:  diffSyncFields(names, nil, row.Values),
+		})
+	}
+	for _, row := range diff.Updates {
+		changes.Rows = append(changes.Rows, SyncRowChange{
+			Action:   "modified",
+			DraftID:  row.DraftID,
+			TargetID: row.ID,
+			Key:      row.Key,
+			Fields:   diffSyncFields(names, byID[row.ID].Values, row.Values),
+		})
+	}
+	for _, id := range diff.Deletes {
+		old := byID[id]
+		changes.Rows = append(changes.Rows, SyncRowChange{
+			Action:   "removed",
+			TargetID: id,
+			Key:      old.Key,
+			Fields:   diffSyncFields(names, old.Values, nil),
+		})
+	}
+	return changes
+}
+
+func diffSyncFields(names []string, oldValues, newValues []sql.NullString) []SyncFieldChange {
+	fields := make([]SyncFieldChange, 0)
+	for i, name := range names {
+		var oldValue, newValue sql.NullString
+		if i < len(oldValues) {
+			oldValue = oldValues[i]
+		}
+		if i < len(newValues) {
+			newValue = newValues[i]
+		}
+		if oldValue.Valid == newValue.Valid && oldValue.String == newValue.String {
+			continue
+		}
+		fields = append(fields, SyncFieldChange{
+			Field: name,
+			Old:   syncStringPointer(oldValue),
+			New:   syncStringPointer(newValue),
+		})
+	}
+	return fields
+}
+
+func syncStringPointer(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	v := value.String
+	return &v
+}
+
+type syncTableSpec struct {
+	Module      string
+	Table       string
+	ScopeColumn string
+	Columns     []string
+	KeyOf       func(values []sql.NullString) string
+}
+
+// syncRowModules lists row-level modules in push order.
+var syncRowModules = []string{
+	"app_ui_fields",
+	"banners",
+	"identities",
+	"scenes",
+	"clothes_categories",
+	"photo_hobbies",
+	"config_extra_steps",
+}
+
+var syncTableSpecs = map[string]syncTableSpec{
+	"app_ui_fields": {
+		Module:      "app_ui_fields",
+		Table:       "app_ui_fields",
+		ScopeColumn: "app_version_name_id",
+		Columns:     []string{"home_title_left", "home_title_right", "home_subtitle", "start_experience", "step1_music", "step1_music_text", "step1_title", "step2_music", "step2_music_text", "step2_title", "status", "print_wait"},
+		KeyOf: func(values []sql.NullString) string {
+			return "app_ui_fields"
+		},
+	},
+	"banners": {
+		Module:      "banners",
+		Table:       "banners",
+		ScopeColumn: "app_version_name",
+		Columns:     []string{"title", "image", "sort", "is_active", "type"},
+		KeyOf:       syncKeyAt(1),
+	},
+	"identities": {
+		Module:      "identities",
+		Table:       "identities",
+		ScopeColumn: "app_version_name",
+		Columns:     []string{"name", "image", "sort", "status"},
+		KeyOf:       syncKeyAt(0),
+	},
+	"scenes": {
+		Module:      "scenes",
+		Table:       "scenes",
+		ScopeColumn: "app_version_name",
+		Columns:     []string{"name", "image", "`desc`", "music", "watermark_path", "need_watermark", "sort", "status", "oss_style"},
+		KeyOf:       syncKeyAt(0),
+	},
+	"clothes_categories": {
+		Module:      "clothes_categories",
+		Table:       "clothes_categories",
+		ScopeColumn: "app_version_name",
+		Columns:     []string{"name", "image", "sort", "status", "music", "`desc`", "music_text"},
+		KeyOf:       syncKeyAt(0),
+	},
+	"photo_hobbies": {
+		Module:      "photo_hobbies",
+		Table:       "photo_hobbies",
+		ScopeColumn: "app_version_name",
+		Columns:     []string{"name", "image", "sort", "status", "music", "music_text", "`desc`"},
+		KeyOf:       syncKeyAt(0),
+	},
+	"config_extra_steps": {
+		Module:      "config_extra_steps",
+		Table:       "config_extra_steps",
+		ScopeColumn: "app_version_name_id",
+		Columns:     []string{"step_index", "field_name", "label", "music", "music_text", "status"},
+		KeyOf: func(values []sql.NullString) string {
+			stepIndex, _ := strconv.ParseInt(strings.TrimSpace(values[0].String), 10, 64)
+			return buildExtraStepKey(stepIndex, values[1].String)
+		},
+	},
+}
+
+func syncKeyAt(index int) func(values []sql.NullString) string {
+	return func(values []sql.NullString) string {
+		return strings.TrimSpace(values[index].String)
+	}
+}
+
+func syncText(value sql.NullString) sql.NullString {
+	trimmed := strings.TrimSpace(value.String)
+	if !value.Valid || trimmed == "" {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: trimmed, Valid: true}
+}
+
+func syncInt(value int64) sql.NullString {
+	return sql.NullString{String: strconv.FormatInt(value, 10), Valid: true}
+}
+
+// normalizeSyncValue brings an online column value into the form buildSyncDesiredRows produces,
+// so blank text, padding and integer formatting do not show up as changes.
+func normalizeSyncValue(column string, value sql.NullString) sql.NullString {
+	if _, ok := syncMergeNumericColumns[strings.Trim(column, "`")]; ok && value.Valid {
+		if parsed, err := strconv.ParseInt(strings.TrimSpace(value.String), 10, 64); err == nil {
+			return syncInt(parsed)
+		}
+	}
+	return syncText(value)
+}
+
+func syncArg(value sql.NullString) interface{} {
+	if !value.Valid {
+		return nil
+	}
+	return value.String
+}
+
+// buildSyncDesiredRows converts draft rows of a module into diff rows.
+func buildSyncDesiredRows(moduleKey string, data draftData) []SyncDiffRow {
+	spec := syncTableSpecs[moduleKey]
+	rows := make([]SyncDiffRow, 0)
+	add := func(draftID int64, targetID sql.NullInt64, values []sql.NullString) {
+		rows = append(rows, SyncDiffRow{
+			DraftID:  draftID,
+			TargetID: int64OrDefault(targetID, 0),
+			Key:      spec.KeyOf(values),
+			Values:   values,
+		})
+	}
+
+	switch moduleKey {
+	case "app_ui_fields":
+		if item := data.AppUIFields; item != nil {
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.HomeTitleLeft),
+				syncText(item.HomeTitleRight),
+				syncText(item.HomeSubtitle),
+				syncText(item.StartExperience),
+				syncText(item.Step1Music),
+				syncText(item.Step1MusicText),
+				syncText(item.Step1Title),
+				syncText(item.Step2Music),
+				syncText(item.Step2MusicText),
+				syncText(item.Step2Title),
+				syncInt(int64OrDefault(item.Status, 1)),
+				syncText(item.PrintWait),
+			})
+		}
+	case "banners":
+		for _, item := range data.Banners {
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.Title),
+				syncText(item.Image),
+				syncInt(int64OrDefault(item.Sort, 0)),
+				syncInt(int64OrDefault(item.IsActive, 1)),
+				syncInt(mapBannerTypeForSync(int64OrDefault(item.Type, 0))),
+			})
+		}
+	case "identities":
+		for _, item := range data.Identities {
+			if strings.TrimSpace(nullableStringValue(item.Name)) == "" {
+				continue
+			}
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.Name),
+				syncText(item.Image),
+				syncInt(int64OrDefault(item.Sort, 0)),
+				syncInt(int64OrDefault(item.Status, 1)),
+			})
+		}
+	case "scenes":
+		for _, item := range data.Scenes {
+			if strings.TrimSpace(nullableStringValue(item.Name)) == "" {
+				continue
+			}
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.Name),
+				syncText(item.Image),
+				syncText(item.Desc),
+				syncText(item.Music),
+				syncText(item.WatermarkPath),
+				syncInt(int64OrDefault(item.NeedWatermark, 1)),
+				syncInt(int64OrDefault(item.Sort, 0)),
+				syncInt(int64OrDefault(item.Status, 1)),
+				syncText(item.OssStyle),
+			})
+		}
+	case "clothes_categories":
+		for _, item := range data.ClothesCategories {
+			if strings.TrimSpace(nullableStringValue(item.Name)) == "" {
+				continue
+			}
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.Name),
+				syncText(item.Image),
+				syncInt(int64OrDefault(item.Sort, 0)),
+				syncInt(int64OrDefault(item.Status, 1)),
+				syncText(item.Music),
+				syncText(item.Desc),
+				syncText(item.MusicText),
+			})
+		}
+	case "photo_hobbies":
+		for _, item := range data.PhotoHobbies {
+			if strings.TrimSpace(nullableStringValue(item.Name)) == "" {
+				continue
+			}
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncText(item.Name),
+				syncText(item.Image),
+				syncInt(int64OrDefault(item.Sort, 0)),
+				syncInt(int64OrDefault(item.Status, 1)),
+				syncText(item.Music),
+				syncText(item.MusicText),
+				syncText(item.Desc),
+			})
+		}
+	case "config_extra_steps":
+		for _, item := range data.ExtraSteps {
+			if strings.TrimSpace(nullableStringValue(item.FieldName)) == "" {
+				continue
+			}
+			add(item.ID, item.TargetID, []sql.NullString{
+				syncInt(int64OrDefault(item.StepIndex, 0)),
+				syncText(item.FieldName),
+				syncText(item.Label),
+				syncText(item.Music),
+				syncText(item.MusicText),
+				syncInt(int64OrDefault(item.Status, 1)),
+			})
+		}
+	}
+	return rows
+}
+
+func loadSyncExistingRows(tx *sql.Tx, spec syncTableSpec, scope interface{}) ([]SyncDiffRow, error) {
+	rows, err := tx.Query(
+		"SELECT id, "+strings.Join(spec.Columns, ", ")+" FROM "+spec.Table+" WHERE "+spec.ScopeColumn+" = ? ORDER BY id ASC",
+		scope,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]SyncDiffRow, 0)
+	for rows.Next() {
+		row := SyncDiffRow{Values: make([]sql.NullString, len(spec.Columns))}
+		dest := make([]interface{}, 0, len(spec.Columns)+1)
+		dest = append(dest, &row.ID)
+		for i := range row.Values {
+			dest = append(dest, &row.Values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, column := range spec.Columns {
+			row.Values[i] = normalizeSyncValue(column, row.Values[i])
+		}
+		row.Key = spec.KeyOf(row.Values)
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// planSyncModule diffs the draft rows of a module against the online table.
+func planSyncModule(tx *sql.Tx, moduleKey string, scope interface{}, data draftData) ([]SyncDiffRow, SyncRowDiff, error) {
+	spec := syncTableSpecs[moduleKey]
+	existing, err := loadSyncExistingRows(tx, spec, scope)
+	if err != nil {
+		return nil, SyncRowDiff{}, err
+	}
+	return existing, DiffSyncRows(existing, buildSyncDesiredRows(moduleKey, data)), nil
+}
+
+// applySyncModule writes a planned diff and returns the draft→online id mappings.
+func applySyncModule(tx *sql.Tx, moduleKey string, scope interface{}, diff SyncRowDiff, now time.Time) ([]SyncIDMapping, error) {
+	spec := syncTableSpecs[moduleKey]
+	mappings := make([]SyncIDMapping, 0, len(diff.Inserts)+len(diff.Updates)+len(diff.Unchanged))
+
+	for _, id := range diff.Deletes {
+		if _, err := tx.Exec("DELETE FROM "+spec.Table+" WHERE id = ?", id); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(diff.Updates) > 0 {
+		updateSQL := "UPDATE " + spec.Table + " SET " + strings.Join(spec.Columns, " = ?, ") + " = ?, updated_at = ? WHERE id = ?"
+		for _, row := range diff.Updates {
+			args := make([]interface{}, 0, len(row.Values)+2)
+			for _, value := range row.Values {
+				args = append(args, syncArg(value))
+			}
+			args = append(args, now, row.ID)
+			if _, err := tx.Exec(updateSQL, args...); err != nil {
+				return nil, err
+			}
+			mappings = append(mappings, SyncIDMapping{ModuleKey: moduleKey, DraftID: row.DraftID, TargetID: row.ID})
+		}
+	}
+
+	for _, row := range diff.Unchanged {
+		mappings = append(mappings, SyncIDMapping{ModuleKey: moduleKey, DraftID: row.DraftID, TargetID: row.ID})
+	}
+
+	if len(diff.Inserts) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(spec.Columns)+3), ", ")
+		insertSQL := "INSERT INTO " + spec.Table + " (" + strings.Join(spec.Columns, ", ") + ", " + spec.ScopeColumn + ", created_at, updated_at) VALUES (" + placeholders + ")"
+		for _, row := range diff.Inserts {
+			args := make([]interface{}, 0, len(row.Values)+3)
+			for _, value := range row.Values {
+				args = append(args, syncArg(value))
+			}
+			args = append(args, scope, now, now)
+			result, err := tx.Exec(insertSQL, args...)
+			if err != nil {
+				return nil, err
+			}
+			insertedID, _ := result.LastInsertId()
+			mappings = append(mappings, SyncIDMapping{ModuleKey: moduleKey, DraftID: row.DraftID, TargetID: insertedID})
+		}
+	}
+
+	filtered := mappings[:0]
+	for _, mapping := range mappings {
+		if mapping.DraftID > 0 && mapping.TargetID > 0 {
+			filtered = append(filtered, mapping)
+		}
+	}
+	return filtered, nil
+}
+
+// syncModuleScope returns the column value that scopes a module's online rows.
+func syncModuleScope(moduleKey, appVersionName string, appVersionNameID int64) interface{} {
+	if syncTableSpecs[moduleKey].ScopeColumn == "app_version_name_id" {
+		return appVersionNameID
+	}
+	return appVersionName
+}
+
+// syncModuleRows applies diff-based pushes for all selected row modules.
+func syncModuleRows(tx *sql.Tx, modules []string, appVersionName string, appVersionNameID int64, data draftData, now time.Time) (map[string]SyncModuleChanges, []SyncIDMapping, error) {
+	changes := make(map[string]SyncModuleChanges)
+	mappings := make([]SyncIDMapping, 0)
+	for _, moduleKey := range syncRowModules {
+		if !shouldSyncModule(modules, moduleKey) {
+			continue
+		}
+		scope := syncModuleScope(moduleKey, appVersionName, appVersionNameID)
+		existing, diff, err := planSyncModule(tx, moduleKey, scope, data)
+		if err != nil {
+			return nil, nil, err
+		}
+		moduleMappings, err := applySyncModule(tx, moduleKey, scope, diff, now)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes[moduleKey] = DescribeSyncDiff(syncTableSpecs[moduleKey].Columns, existing, diff)
+		mappings = append(mappings, moduleMappings...)
+	}
+	return changes, mappings, nil
+}
+
+var syncVersionColumns = []string{"app_version_name", "location_name", "status", "feishu_field_names", "ai_modal"}
+
+func buildSyncVersionValues(appVersionName, locationName string, status int64, feishuFields, aiModal string) []sql.NullString {
+	return []sql.NullString{
+		toNullString(appVersionName),
+		toNullString(locationName),
+		syncInt(status),
+		toNullString(feishuFields),
+		toNullString(aiModal),
+	}
+}
+
+// diffSyncVersion compares the online version row (nil when absent) with the draft version values.
+func diffSyncVersion(targetID int64, existing, desired []sql.NullString) ([]SyncDiffRow, SyncRowDiff) {
+	existingRows := make([]SyncDiffRow, 0, 1)
+	if targetID > 0 && existing != nil {
+		existingRows = append(existingRows, SyncDiffRow{ID: targetID, Key: "version", Values: existing})
+	}
+	desiredRows := []SyncDiffRow{{TargetID: targetID, Key: "version", Values: desired}}
+	return existingRows, DiffSyncRows(existingRows, desiredRows)
+}
@@ -15,6 +15,7 @@ type SyncPushVersion struct {
 
 type SyncPushBanner struct {
 	ID       int64  `json:"id"`
+	TargetID *int64 `json:"target_id"`
 	Title    string `json:"title"`
 	Image    string `json:"image"`
 	Sort     *int64 `json:"sort"`
@@ -101,6 +102,7 @@ type SyncPushRequest struct {
 	DraftVersionID    int64                `json:"draft_version_id"`
 	TriggerBy         int64                `json:"trigger_by"`
 	Confirm           bool                 `json:"confirm"`
+	DryRun            bool                 `json:"dry_run"`
 	Modules           []string             `json:"modules"`
 	Version           SyncPushVersion      `json:"version"`
 	AppUIFields       *SyncPushAppUIFields `json:"app_ui_fields"`
@@ -161,6 +163,7 @@ func buildSyncPushFromDraft(req syncRequest, version draftVersionRow, data draft
 	for _, item := range data.Banners {
 		payload.Banners = append(payload.Banners, SyncPushBanner{
 			ID:       item.ID,
+			TargetID: int64Pointer(item.TargetID),
 			Title:    nullableStringValue(item.Title),
 			Image:    nullableStringValue(item.Image),
 			Sort:     int64Pointer(item.Sort),
@@ -277,6 +280,7 @@ func buildDraftDataFromPush(req SyncPushRequest) draftData {
 			Sort:     toNullInt64(item.Sort),
 			IsActive: toNullInt64(item.IsActive),
 			Type:     toNullInt64(item.Type),
+			TargetID: toNullInt64(item.TargetID),
 		})
 	}
 
@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const (
+	// syncStatusRolledBack marks a draft whose online version was replaced by an archived snapshot.
+	syncStatusRolledBack = "rolled_back"
+	// syncStatusRollingBack marks a draft and target while a rollback push is in flight.
+	syncStatusRollingBack = "rolling_back"
+)
+
+type syncRollbackRequest struct {
+	RevisionID int64 `json:"revision_id"`
+}
+
+// ListRevisions returns archived pre-push online snapshots of a target version.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) ListRevisions(c *gin.Context) {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	targetID := parseInt64Query(c, "target_app_version_name_id")
+	if targetID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target_app_version_name_id is required"})
+		return
+	}
+
+	syncTargetID := parseInt64Query(c, "sync_target_id")
+
+	rows, err := h.db.Query(
+		`SELECT r.id, r.draft_version_id, r.sync_target_id, r.target_app_version_name_id, r.app_version_name, r.sync_job_id, r.source,
+      r.rollback_of_id, r.pushed_by, u.display_name, u.username, r.created_at
+      FROM app_db_sync_revisions r
+      LEFT JOIN app_db_users u ON u.id = r.pushed_by
+      WHERE r.sync_target_id = ? AND r.target_app_version_name_id = ?
+      ORDER BY r.id DESC`,
+		syncTargetID,
+		targetID,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	items := make([]gin.H, 0)
+	for rows.Next() {
+		var (
+			id             int64
+			draftVersionID sql.NullInt64
+			syncTarget     int64
+			target         int64
+			appVersionName sql.NullString
+			jobID          sql.NullInt64
+			source         sql.NullString
+			rollbackOfID   sql.NullInt64
+			pushedBy       sql.NullInt64
+			displayName    sql.NullString
+			username       sql.NullString
+			createdAt      sql.NullTime
+		)
+		if err := rows.Scan(&id, &draftVersionID, &syncTarget, &target, &appVersionName, &jobID, &source, &rollbackOfID, &pushedBy, &displayName, &username, &createdAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+			return
+		}
+		items = append(items, gin.H{
+			"id":                         id,
+			"draft_version_id":           nullableInt64Pointer(draftVersionID),
+			"sync_target_id":             syncTarget,
+			"target_app_version_name_id": target,
+			"app_version_name":           nullableString(appVersionName),
+			"sync_job_id":                nullableInt64Pointer(jobID),
+			"source":                     nullableString(source),
+			"rollback_of_id":             nullableInt64Pointer(rollbackOfID),
+			"pushed_by":                  nullableInt64Pointer(pushedBy),
+			"pushed_by_name":             nullableString(displayName),
+			"pushed_by_username":         nullableString(username),
+			"created_at":                 nullableTimePointer(createdAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// Rollback re-applies an archived online snapshot through the sync push path.
+// Like a queued sync it holds the draft and target for the duration of the push and is refused
+// while a sync job or another rollback of the same draft and target is in flight.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) Rollback(c *gin.Context) {
+	if strings.ToLower(strings.TrimSpace(h.cfg.AppMode)) == "online" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available in online mode"})
+		return
+	}
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+	var req syncRollbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RevisionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "revision_id is required"})
+		return
+	}
+
+	claims, _ := middleware.GetAuthClaims(c)
+	operatorID := int64(0)
+	if claims != nil {
+		operatorID = claims.UserID
+	}
+
+	draftVersionID, syncTargetID, snapshot, err := loadSyncRevision(h.db, req.RevisionID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "revision not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	target, err := h.resolveSyncTarget(syncTargetID)
+	if err != nil {
+		respondSyncTargetError(c, err)
+		return
+	}
+
+	if draftVersionID > 0 {
+		activeID, err := claimSyncRollback(h.db, draftVersionID, syncTargetID, req.RevisionID)
+		if err != nil {
+			if errors.Is(err, errSyncJobActive) {
+				c.JSON(http.StatusConflict, gin.H{"error": "sync_in_progress", "job_id": activeID})
+				return
+			}
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "sync job failed"})
+			return
+		}
+	}
+	// Any exit before the rollback is recorded releases the claim; once the push was accepted
+	// the online rows already follow the snapshot, so the state says so instead of failed.
+	pushed, recorded := false, false
+	defer func() {
+		if recorded || draftVersionID <= 0 {
+			return
+		}
+		status, message := syncJobFailed, "rollback failed"
+		if pushed {
+			status, message = syncJobUnrecorded, "rolled back online, local bookkeeping failed"
+		}
+		if err := updateDraftSyncState(h.db, draftVersionID, syncTargetID, status, message); err != nil {
+			log.Printf("sync rollback of revision %d release failed: %v", req.RevisionID, err)
+		}
+	}()
+
+	payload := BuildSyncPushFromSnapshot(snapshot, draftVersionID, operatorID)
+	result, err := h.pushToRemote(c.Request.Context(), target, payload)
+	if err != nil {
+		if pushErr := asSyncPushError(err); pushErr != nil {
+			if len(pushErr.Details) > 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": pushErr.Message, "details": pushErr.Details})
+			} else {
+				c.JSON(http.StatusBadGateway, gin.H{"error": pushErr.Message})
+			}
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	pushed = true
+
+	now := time.Now()
+	tx, err := h.db.Begin()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+		return
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	newRevisionID := int64(0)
+	if result.Previous != nil {
+		newRevisionID, err = insertSyncRevision(tx, draftVersionID, syncTargetID, 0, operatorID, "rollback", req.RevisionID, result.Previous, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "save revision failed"})
+			return
+		}
+	}
+	// The online rows now follow the snapshot rather than the draft, so the draft's
+	// row mappings for this target no longer hold; the next push re-matches by key.
+	if draftVersionID > 0 {
+		if _, err := tx.Exec("DELETE FROM app_db_sync_id_map WHERE draft_version_id = ? AND sync_target_id = ?", draftVersionID, syncTargetID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "update id map failed"})
+			return
+		}
+		message := "rolled back to revision " + strconv.FormatInt(req.RevisionID, 10)
+		if err := UpdateDraftSyncStatus(tx, draftVersionID, syncTargetID, syncStatusRolledBack, message, result.TargetID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "update sync status failed"})
+			return
+		}
+	}
+	detail, err := json.Marshal(map[string]interface{}{
+		"revision_id":     req.RevisionID,
+		"new_revision_id": newRevisionID,
+		"sync_target_id":  syncTargetID,
+		"changes":         result.Stats,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+	if err := insertAuditLog(
+		tx,
+		nullableID(draftVersionID),
+		"sync",
+		result.TargetID,
+		"sync_rollback",
+		nullableID(operatorID),
+		string(detail),
+		now,
+	); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "audit failed"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "transaction failed"})
+		return
+	}
+	recorded = true
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":                     "rolled_back",
+		"revision_id":                req.RevisionID,
+		"sync_target_id":             syncTargetID,
+		"target_app_version_name_id": result.TargetID,
+		"stats":                      result.Stats,
+	})
+}
+
+// claimSyncRollback reserves a draft and target for a rollback push under the draft version row lock.
+// Args:
+//
+//	db: Database connection.
+//	draftVersionID: Draft version id of the revision.
+//	syncTargetID: Sync target id of the revision.
+//	revisionID: Revision being re-applied.
+//
+// Returns:
+//
+//	int64: Id of the active sync job when refused, 0 otherwise.
+//	error: errSyncJobActive while a sync job, a syncing draft or another rollback is in flight.
+func claimSyncRollback(db *sql.DB, draftVersionID, syncTargetID, revisionID int64) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockDraftVersionTx(tx, draftVersionID); err != nil {
+		return 0, err
+	}
+	activeID, err := findActiveSyncJob(tx, draftVersionID, syncTargetID)
+	if err != nil {
+		return 0, err
+	}
+	if activeID > 0 {
+		return activeID, errSyncJobActive
+	}
+	status, err := loadDraftStatus(tx, draftVersionID)
+	if err != nil {
+		return 0, err
+	}
+	rollingBack, err := syncRollbackInFlight(tx, draftVersionID, syncTargetID)
+	if err != nil {
+		return 0, err
+	}
+	if rollingBack || status == DraftStatusSyncing {
+		return 0, errSyncJobActive
+	}
+	message := "rolling back to revision " + strconv.FormatInt(revisionID, 10)
+	if err := updateDraftSyncState(tx, draftVersionID, syncTargetID, syncStatusRollingBack, message); err != nil {
+		return 0, err
+	}
+	return 0, tx.Commit()
+}
+
+// syncRollbackInFlight reports whether a rollback push holds the draft and target.
+func syncRollbackInFlight(tx *sql.Tx, draftVersionID, syncTargetID int64) (bool, error) {
+	var status sql.NullString
+	err := tx.QueryRow(
+		"SELECT sync_status FROM app_db_sync_target_states WHERE draft_version_id = ? AND sync_target_id = ?",
+		draftVersionID,
+		syncTargetID,
+	).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return status.String == syncStatusRollingBack, nil
+}
+
+func insertSyncRevision(tx *sql.Tx, draftVersionID, syncTargetID, jobID, pushedBy int64, source string, rollbackOfID int64, snapshot *SyncPullSnapshot, now time.Time) (int64, error) {
+	if snapshot == nil || snapshot.Version.TargetID <= 0 {
+		return 0, errors.New("empty snapshot")
+	}
+	raw, err := json.Marshal(snapshot)
+	if err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec(
+		"INSERT INTO app_db_sync_revisions (draft_version_id, sync_target_id, target_app_version_name_id, app_version_name, sync_job_id, source, rollback_of_id, snapshot_json, pushed_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		nullableID(draftVersionID),
+		syncTargetID,
+		snapshot.Version.TargetID,
+		nullIfEmpty(snapshot.Version.AppVersionName),
+		nullableID(jobID),
+		source,
+		nullableID(rollbackOfID),
+		string(raw),
+		nullableID(pushedBy),
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+func loadSyncRevision(db *sql.DB, id int64) (int64, int64, *SyncPullSnapshot, error) {
+	row := db.QueryRow("SELECT draft_version_id, sync_target_id, snapshot_json FROM app_db_sync_revisions WHERE id = ?", id)
+	var draftVersionID sql.NullInt64
+	var syncTargetID int64
+	var raw string
+	if err := row.Scan(&draftVersionID, &syncTargetID, &raw); err != nil {
+		return 0, 0, nil, err
+	}
+	var snapshot SyncPullSnapshot
+	if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+		return 0, 0, nil, err
+	}
+	return draftVersionID.Int64, syncTargetID, &snapshot, nil
+}
+
+// BuildSyncPushFromSnapshot turns an archived online snapshot into a full-module push.
+// Rows carry their online ids as target ids so unchanged rows keep their ids.
+// Args:
+//
+//	snapshot: Archived online snapshot.
+//	draftVersionID: Draft version the revision was pushed from, 0 for none.
+//	triggerBy: Operator id.
+//
+// Returns:
+//
+//	SyncPushRequest: Confirmed push covering every module of the snapshot.
+func BuildSyncPushFromSnapshot(snapshot *SyncPullSnapshot, draftVersionID, triggerBy int64) SyncPushRequest {
+	payload := SyncPushRequest{
+		DraftVersionID: draftVersionID,
+		TriggerBy:      triggerBy,
+		Confirm:        true,
+		Version: SyncPushVersion{
+			AppVersionName:   snapshot.Version.AppVersionName,
+			LocationName:     snapshot.Version.LocationName,
+			FeishuFieldNames: snapshot.Version.FeishuFieldNames,
+			AiModal:          snapshot.Version.AiModal,
+			Status:           snapshot.Version.Status,
+		},
+		Banners:           make([]SyncPushBanner, 0, len(snapshot.Banners)),
+		Identities:        make([]SyncPushIdentity, 0, len(snapshot.Identities)),
+		Scenes:            make([]SyncPushScene, 0, len(snapshot.Scenes)),
+		ClothesCategories: make([]SyncPushClothes, 0, len(snapshot.ClothesCategories)),
+		PhotoHobbies:      make([]SyncPushPhotoHobby, 0, len(snapshot.PhotoHobbies)),
+		ExtraSteps:        make([]SyncPushExtraStep, 0, len(snapshot.ExtraSteps)),
+	}
+
+	if snapshot.AppUIFields != nil {
+		item := *snapshot.AppUIFields
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.AppUIFields = &item
+	}
+	for _, item := range snapshot.Banners {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.Banners = append(payload.Banners, item)
+	}
+	for _, item := range snapshot.Identities {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.Identities = append(payload.Identities, item)
+	}
+	for _, item := range snapshot.Scenes {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.Scenes = append(payload.Scenes, item)
+	}
+	for _, item := range snapshot.ClothesCategories {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.ClothesCategories = append(payload.ClothesCategories, item)
+	}
+	for _, item := range snapshot.PhotoHobbies {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.PhotoHobbies = append(payload.PhotoHobbies, item)
+	}
+	for _, item := range snapshot.ExtraSteps {
+		item.TargetID = snapshotTargetID(item.ID)
+		item.ID = 0
+		payload.ExtraSteps = append(payload.ExtraSteps, item)
+	}
+	return payload
+}
+
+func snapshotTargetID(id int64) *int64 {
+	if id <= 0 {
+		return nil
+	}
+	v := id
+	return &v
+}
@@ -0,0 +1,515 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"shushu-app-ui-dashboard/internal/config"
+)
+
+const (
+	syncJobQueued         = "queued"
+	syncJobRunning        = "running"
+	syncJobRetrying       = "retrying"
+	syncJobSucceeded      = "succeeded"
+	syncJobFailed         = "failed"
+	syncJobPendingConfirm = "pending_confirm"
+	// syncJobUnrecorded marks a job whose push was accepted online but whose local bookkeeping failed.
+	syncJobUnrecorded = "unrecorded"
+
+	maxSyncRetryDelay = 10 * time.Minute
+)
+
+var errSyncJobActive = errors.New("sync job already active")
+
+type syncJobRow struct {
+	ID             int64
+	DraftVersionID int64
+	SyncTargetID   int64
+	TriggerBy      int64
+	Modules        []string
+	Confirm        bool
+	Attempts       int
+	MaxAttempts    int
+}
+
+// syncJobError marks a job failure that should not go through the generic
+// retry classification.
+type syncJobError struct {
+	Message   string
+	Retryable bool
+}
+
+func (e *syncJobError) Error() string {
+	return e.Message
+}
+
+// SyncRunner executes queued sync jobs in background workers.
+type SyncRunner struct {
+	db           *sql.DB
+	executor     *SyncHandler
+	workers      int
+	maxAttempts  int
+	retryBase    time.Duration
+	pollInterval time.Duration
+	wake         chan struct{}
+}
+
+// NewSyncRunner creates a background runner for sync jobs.
+// Args:
+//
+//	cfg: App config.
+//	db: Database connection.
+//
+// Returns:
+//
+//	*SyncRunner: Initialized runner.
+func NewSyncRunner(cfg *config.Config, db *sql.DB) *SyncRunner {
+	workers := cfg.SyncWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	maxAttempts := cfg.SyncMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+	retryBase := time.Duration(cfg.SyncRetryBaseSeconds) * time.Second
+	if retryBase <= 0 {
+		retryBase = 10 * time.Second
+	}
+	return &SyncRunner{
+		db:           db,
+		executor:     NewSyncHandler(cfg, db, nil),
+		workers:      workers,
+		maxAttempts:  maxAttempts,
+		retryBase:    retryBase,
+		pollInterval: 5 * time.Second,
+		wake:         make(chan struct{}, workers),
+	}
+}
+
+// Start resumes interrupted jobs and launches the worker pool.
+// Args:
+//
+//	ctx: Lifecycle context; workers stop when it is cancelled.
+//
+// Returns:
+//
+//	None.
+func (r *SyncRunner) Start(ctx context.Context) {
+	if r == nil || r.db == nil {
+		return
+	}
+	if err := r.resumeInterrupted(time.Now()); err != nil {
+		log.Printf("sync runner resume failed: %v", err)
+	}
+	for i := 0; i < r.workers; i++ {
+		go r.work(ctx)
+	}
+	r.Notify()
+}
+
+// Notify wakes an idle worker to pick up newly queued jobs.
+// Args:
+//
+//	None.
+//
+// Returns:
+//
+//	None.
+func (r *SyncRunner) Notify() {
+	if r == nil {
+		return
+	}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// SyncRetryDelay returns the backoff before the next attempt of a failed job.
+// Args:
+//
+//	attempt: Attempts already made (1-based).
+//	base: Delay after the first failure.
+//
+// Returns:
+//
+//	time.Duration: Exponential delay capped at ten minutes.
+func SyncRetryDelay(attempt int, base time.Duration) time.Duration {
+	if base <= 0 {
+		return 0
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxSyncRetryDelay {
+			return maxSyncRetryDelay
+		}
+	}
+	if delay > maxSyncRetryDelay {
+		return maxSyncRetryDelay
+	}
+	return delay
+}
+
+// IsRetryableSyncStatus reports whether a remote HTTP status is transient.
+// Args:
+//
+//	statusCode: HTTP status returned by the online sync API.
+//
+// Returns:
+//
+//	bool: True for timeouts, throttling and server errors.
+func IsRetryableSyncStatus(statusCode int) bool {
+	return statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode >= http.StatusInternalServerError
+}
+
+func (r *SyncRunner) enqueue(req syncRequest, modules []string, now time.Time) (int64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := lockDraftVersionTx(tx, req.DraftVersionID); err != nil {
+		return 0, err
+	}
+	jobID, err := r.enqueueTx(tx, req, modules, now)
+	if err != nil {
+		return jobID, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
+
+// enqueueTx queues a job inside tx; the caller must hold the draft version row lock.
+func (r *SyncRunner) enqueueTx(tx *sql.Tx, req syncRequest, modules []string, now time.Time) (int64, error) {
+	activeID, err := findActiveSyncJob(tx, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		return 0, err
+	}
+	if activeID > 0 {
+		return activeID, errSyncJobActive
+	}
+	rollingBack, err := syncRollbackInFlight(tx, req.DraftVersionID, req.SyncTargetID)
+	if err != nil {
+		return 0, err
+	}
+	if rollingBack {
+		return 0, errSyncJobActive
+	}
+	status, err := loadDraftStatus(tx, req.DraftVersionID)
+	if err != nil {
+		return 0, err
+	}
+	if status != DraftStatusApproved {
+		return 0, &draftLifecycleError{Status: status}
+	}
+	evaluation, err := evaluateDraftApprovals(tx, req.DraftVersionID)
+	if err != nil {
+		return 0, err
+	}
+	if !evaluation.Satisfied {
+		return 0, &approvalPendingError{Evaluation: evaluation}
+	}
+
+	jobID, err := insertSyncJob(tx, req.DraftVersionID, req.SyncTargetID, req.TriggerBy, modules, req.Confirm, r.maxAttempts, now)
+	if err != nil {
+		return 0, err
+	}
+	for _, moduleKey := range resolveSyncModules(modules) {
+		if _, err := insertSyncModuleJob(tx, jobID, req.DraftVersionID, req.TriggerBy, moduleKey, now); err != nil {
+			return 0, err
+		}
+	}
+	if err := updateDraftSyncState(tx, req.DraftVersionID, req.SyncTargetID, syncJobQueued, ""); err != nil {
+		return 0, err
+	}
+	detail := map[string]interface{}{"job_id": jobID, "sync_target_id": req.SyncTargetID}
+	if _, err := transitionDraftStatus(tx, req.DraftVersionID, DraftStatusApproved, DraftStatusSyncing, "sync", req.TriggerBy, detail, now); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
+
+func lockDraftVersionTx(tx *sql.Tx, draftVersionID int64) error {
+	var lockedID int64
+	return tx.QueryRow("SELECT id FROM app_db_version_names WHERE id = ? FOR UPDATE", draftVersionID).Scan(&lockedID)
+}
+
+func (r *SyncRunner) resumeInterrupted(now time.Time) error {
+	if _, err := r.db.Exec(
+		"UPDATE app_db_sync_module_jobs m JOIN app_db_sync_jobs j ON j.id = m.job_id SET m.status = IF(j.max_attempts > 0 AND j.attempts >= j.max_attempts, ?, ?), m.error_message = ? WHERE j.status = ?",
+		syncJobFailed,
+		syncJobRetrying,
+		"interrupted",
+		syncJobRunning,
+	); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(
+		"UPDATE app_db_sync_jobs SET status = ?, error_message = ?, finished_at = ?, updated_at = ? WHERE status = ? AND max_attempts > 0 AND attempts >= max_attempts",
+		syncJobFailed,
+		"interrupted",
+		now,
+		now,
+		syncJobRunning,
+	); err != nil {
+		return err
+	}
+	if _, err := r.db.Exec(
+		"UPDATE app_db_sync_jobs SET status = ?, error_message = ?, next_run_at = ?, updated_at = ? WHERE status = ?",
+		syncJobRetrying,
+		"interrupted",
+		now,
+		now,
+		syncJobRunning,
+	); err != nil {
+		return err
+	}
+	// A rollback push cut off by a restart never released its draft and target.
+	if _, err := r.db.Exec(
+		"UPDATE app_db_version_names SET sync_status = ?, sync_message = ? WHERE sync_status = ?",
+		syncJobFailed,
+		"rollback interrupted",
+		syncStatusRollingBack,
+	); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(
+		"UPDATE app_db_sync_target_states SET sync_status = ?, sync_message = ? WHERE sync_status = ?",
+		syncJobFailed,
+		"rollback interrupted",
+		syncStatusRollingBack,
+	)
+	return err
+}
+
+func (r *SyncRunner) work(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			job, ok, err := r.claimNext(time.Now())
+			if err != nil {
+				log.Printf("sync runner claim failed: %v", err)
+				break
+			}
+			if !ok {
+				break
+			}
+			r.runJob(ctx, job)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *SyncRunner) claimNext(now time.Time) (syncJobRow, bool, error) {
+	rows, err := r.db.Query(
+		"SELECT id FROM app_db_sync_jobs WHERE status IN (?, ?) AND (next_run_at IS NULL OR next_run_at <= ?) ORDER BY next_run_at ASC, id ASC LIMIT 10",
+		syncJobQueued,
+		syncJobRetrying,
+		now,
+	)
+	if err != nil {
+		return syncJobRow{}, false, err
+	}
+	candidates := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return syncJobRow{}, false, err
+		}
+		candidates = append(candidates, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return syncJobRow{}, false, err
+	}
+
+	for _, id := range candidates {
+		claimed, err := claimSyncJob(r.db, id, now)
+		if err != nil {
+			return syncJobRow{}, false, err
+		}
+		if !claimed {
+			continue
+		}
+		job, err := loadSyncJob(r.db, id)
+		if err != nil {
+			return syncJobRow{}, false, err
+		}
+		return job, true, nil
+	}
+	return syncJobRow{}, false, nil
+}
+
+func (r *SyncRunner) runJob(ctx context.Context, job syncJobRow) {
+	now := time.Now()
+	moduleJobs, err := loadSyncModuleJobIDs(r.db, job.ID)
+	if err != nil {
+		r.handleJobError(job, nil, err)
+		return
+	}
+	_ = startSyncModuleJobs(r.db, job.ID, now)
+
+	req := syncRequest{
+		DraftVersionID: job.DraftVersionID,
+		SyncTargetID:   job.SyncTargetID,
+		TriggerBy:      job.TriggerBy,
+		Confirm:        job.Confirm,
+		Modules:        job.Modules,
+	}
+	result, data, uploadCache, err := r.executor.executeSync(ctx, req)
+	if err != nil {
+		r.handleJobError(job, moduleJobs, err)
+		return
+	}
+
+	if err := r.executor.finishSyncSuccess(req, result, data, job.ID, moduleJobs, time.Now()); err != nil {
+		log.Printf("sync job %d finish failed: %v", job.ID, err)
+		r.finishUnrecorded(job, moduleJobs, result, err)
+		return
+	}
+
+	for _, entry := range uploadCache {
+		if entry.localAbs != "" {
+			_ = os.Remove(entry.localAbs)
+		}
+	}
+}
+
+func (r *SyncRunner) handleJobError(job syncJobRow, moduleJobs map[string]int64, err error) {
+	now := time.Now()
+	message := strings.TrimSpace(err.Error())
+	if message == "" {
+		message = "sync failed"
+	}
+
+	if pushErr := asSyncPushError(err); pushErr != nil && pushErr.NeedConfirm {
+		_ = UpdateDraftSyncStatus(r.db, job.DraftVersionID, job.SyncTargetID, syncJobPendingConfirm, pushErr.Message, pushErr.TargetID)
+		_ = r.executor.finishSyncJobWithError(job.ID, moduleJobs, syncJobPendingConfirm, pushErr.Message, pushErr.TargetID, now)
+		r.releaseDraft(job, syncJobPendingConfirm, now)
+		return
+	}
+
+	if isRetryableSyncError(err) && job.Attempts < job.MaxAttempts {
+		nextRunAt := now.Add(SyncRetryDelay(job.Attempts, r.retryBase))
+		retryErr := r.scheduleRetry(job, message, nextRunAt, now)
+		if retryErr == nil {
+			_ = updateDraftSyncState(r.db, job.DraftVersionID, job.SyncTargetID, syncJobRetrying, message)
+			return
+		}
+		log.Printf("sync job %d schedule retry failed, marking it failed: %v", job.ID, retryErr)
+	}
+
+	var targetID int64
+	if pushErr := asSyncPushError(err); pushErr != nil {
+		targetID = pushErr.TargetID
+	}
+	_ = updateDraftSyncState(r.db, job.DraftVersionID, job.SyncTargetID, syncJobFailed, message)
+	_ = r.executor.finishSyncJobWithError(job.ID, moduleJobs, syncJobFailed, message, targetID, now)
+	r.releaseDraft(job, syncJobFailed, now)
+}
+
+// scheduleRetry moves a job back to the retry queue.
+func (r *SyncRunner) scheduleRetry(job syncJobRow, message string, nextRunAt, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+	if err := retrySyncJob(tx, job.ID, message, nextRunAt, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// finishUnrecorded closes a job whose push was accepted online but whose local bookkeeping failed.
+// The push is never repeated and the draft is published, because the online data is already live;
+// id mappings and the revision snapshot of this push are missing and the next sync falls back to key matching.
+func (r *SyncRunner) finishUnrecorded(job syncJobRow, moduleJobs map[string]int64, result *syncPushResult, err error) {
+	now := time.Now()
+	message := "pushed online, local bookkeeping failed: " + strings.TrimSpace(err.Error())
+	targetID := result.TargetID
+	if updateErr := UpdateDraftSyncStatus(r.db, job.DraftVersionID, job.SyncTargetID, syncJobUnrecorded, message, targetID); updateErr != nil {
+		log.Printf("sync job %d record unrecorded state failed: %v", job.ID, updateErr)
+	}
+	if finishErr := r.executor.finishSyncJobWithError(job.ID, moduleJobs, syncJobUnrecorded, message, targetID, now); finishErr != nil {
+		log.Printf("sync job %d mark unrecorded failed: %v", job.ID, finishErr)
+	}
+
+	detail := map[string]interface{}{"job_id": job.ID, "sync_target_id": job.SyncTargetID, "reason": syncJobUnrecorded}
+	tx, txErr := r.db.Begin()
+	if txErr != nil {
+		log.Printf("sync job %d publish draft failed: %v", job.ID, txErr)
+		return
+	}
+	if _, transitionErr := transitionDraftStatus(tx, job.DraftVersionID, DraftStatusSyncing, DraftStatusPublished, "publish", job.TriggerBy, detail, now); transitionErr != nil {
+		_ = tx.Rollback()
+		log.Printf("sync job %d publish draft failed: %v", job.ID, transitionErr)
+		return
+	}
+	if commitErr := tx.Commit(); commitErr != nil {
+		log.Printf("sync job %d publish draft failed: %v", job.ID, commitErr)
+	}
+}
+
+// releaseDraft returns a draft whose sync ended without publishing to the approved state.
+func (r *SyncRunner) releaseDraft(job syncJobRow, reason string, now time.Time) {
+	detail := map[string]interface{}{"job_id": job.ID, "sync_target_id": job.SyncTargetID, "reason": reason}
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Printf("sync job %d release draft failed: %v", job.ID, err)
+		return
+	}
+	if _, err := transitionDraftStatus(tx, job.DraftVersionID, DraftStatusSyncing, DraftStatusApproved, "sync_failed", job.TriggerBy, detail, now); err != nil {
+		_ = tx.Rollback()
+		log.Printf("sync job %d release draft failed: %v", job.ID, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("sync job %d release draft failed: %v", job.ID, err)
+	}
+}
+
+// isRetryableSyncError classifies failures that happen before the online side accepted the push.
+// Errors without a classification come from draft reads, uploads or transport and are retried;
+// bookkeeping failures after an accepted push go through finishUnrecorded instead.
+func isRetryableSyncError(err error) bool {
+	var jobErr *syncJobError
+	if errors.As(err, &jobErr) {
+		return jobErr.Retryable
+	}
+	if pushErr := asSyncPushError(err); pushErr != nil {
+		return IsRetryableSyncStatus(pushErr.StatusCode)
+	}
+	return true
+}
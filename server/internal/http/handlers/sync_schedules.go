@@ -0,0 +1,594 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"shushu-app-ui-dashboard/internal/config"
+	"shushu-app-ui-dashboard/internal/http/middleware"
+)
+
+const (
+	syncScheduleScheduled = "scheduled"
+	syncScheduleRunning   = "running"
+	syncScheduleFired     = "fired"
+	syncScheduleRefused   = "refused"
+	syncScheduleFailed    = "failed"
+	syncScheduleCancelled = "cancelled"
+
+	syncSchedulePollInterval = 15 * time.Second
+)
+
+var syncScheduleLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+type syncScheduleRequest struct {
+	DraftVersionID int64    `json:"draft_version_id"`
+	SyncTargetID   int64    `json:"sync_target_id"`
+	Modules        []string `json:"modules"`
+	Confirm        bool     `json:"confirm"`
+	RunAt          string   `json:"run_at"`
+}
+
+type syncScheduleRow struct {
+	ID             int64
+	DraftVersionID int64
+	SyncTargetID   int64
+	Modules        []string
+	Confirm        bool
+	CreatedBy      int64
+}
+
+// SyncScheduler fires due sync schedules through the sync runner queue.
+type SyncScheduler struct {
+	db           *sql.DB
+	runner       *SyncRunner
+	pollInterval time.Duration
+}
+
+// NewSyncScheduler creates a scheduler for timed sync jobs.
+// Args:
+//
+//	db: Database connection.
+//	runner: Runner that executes the queued jobs.
+//
+// Returns:
+//
+//	*SyncScheduler: Initialized scheduler.
+func NewSyncScheduler(db *sql.DB, runner *SyncRunner) *SyncScheduler {
+	return &SyncScheduler{db: db, runner: runner, pollInterval: syncSchedulePollInterval}
+}
+
+// Start launches the scheduler goroutine.
+// Args:
+//
+//	ctx: Lifecycle context; the goroutine stops when it is cancelled.
+//
+// Returns:
+//
+//	None.
+func (s *SyncScheduler) Start(ctx context.Context) {
+	if s == nil || s.db == nil || s.runner == nil {
+		return
+	}
+	if _, err := s.db.Exec(
+		"UPDATE app_db_sync_schedules SET status = ?, updated_at = ? WHERE status = ?",
+		syncScheduleScheduled,
+		time.Now(),
+		syncScheduleRunning,
+	); err != nil {
+		log.Printf("sync scheduler resume failed: %v", err)
+	}
+	go func() {
+		ticker := time.NewTicker(s.pollInterval)
+		defer ticker.Stop()
+		for {
+			s.fireDue(time.Now())
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// ParseSyncScheduleTime parses a schedule time in the app timezone.
+// Args:
+//
+//	raw: RFC3339 time, or "YYYY-MM-DD HH:MM[:SS]" interpreted in loc.
+//	loc: App timezone.
+//
+// Returns:
+//
+//	time.Time: Parsed time.
+//	error: Error when the format is not supported.
+func ParseSyncScheduleTime(raw string, loc *time.Location) (time.Time, error) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return time.Time{}, errors.New("run_at is required")
+	}
+	if parsed, err := time.Parse(time.RFC3339, trimmed); err == nil {
+		return parsed, nil
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	for _, layout := range syncScheduleLayouts {
+		if parsed, err := time.ParseInLocation(layout, trimmed, loc); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid run_at %q", trimmed)
+}
+
+func (s *SyncScheduler) fireDue(now time.Time) {
+	rows, err := s.db.Query(
+		"SELECT id FROM app_db_sync_schedules WHERE status = ? AND run_at <= ? ORDER BY run_at ASC, id ASC LIMIT 20",
+		syncScheduleScheduled,
+		now,
+	)
+	if err != nil {
+		log.Printf("sync scheduler query failed: %v", err)
+		return
+	}
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			log.Printf("sync scheduler scan failed: %v", err)
+			return
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+
+	fired := false
+	for _, id := range ids {
+		ok, err := s.fire(id, time.Now())
+		if err != nil {
+			log.Printf("sync schedule %d failed: %v", id, err)
+			continue
+		}
+		fired = fired || ok
+	}
+	if fired {
+		s.runner.Notify()
+	}
+}
+
+// fire turns one due schedule into a sync job, or records why it was refused.
+func (s *SyncScheduler) fire(id int64, now time.Time) (bool, error) {
+	result, err := s.db.Exec(
+		"UPDATE app_db_sync_schedules SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+		syncScheduleRunning,
+		now,
+		id,
+		syncScheduleScheduled,
+	)
+	if err != nil {
+		return false, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	schedule, err := loadSyncSchedule(s.db, id)
+	if err != nil {
+		_ = finishSyncSchedule(s.db, id, syncScheduleFailed, 0, err.Error(), now)
+		return false, err
+	}
+
+	fired, err := s.fireTx(id, schedule, now)
+	if err != nil {
+		// The claim above left the schedule running; put it back so the next poll retries it and it stays cancellable.
+		if resetErr := finishSyncSchedule(s.db, id, syncScheduleScheduled, 0, err.Error(), now); resetErr != nil {
+			log.Printf("sync schedule %d reset failed: %v", id, resetErr)
+		}
+		return false, err
+	}
+	return fired, nil
+}
+
+// fireTx enqueues the job of a claimed schedule, or records the refusal, in one transaction.
+func (s *SyncScheduler) fireTx(id int64, schedule syncScheduleRow, now time.Time) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	req := syncRequest{
+		DraftVersionID: schedule.DraftVersionID,
+		SyncTargetID:   schedule.SyncTargetID,
+		TriggerBy:      schedule.CreatedBy,
+		Confirm:        schedule.Confirm,
+		Modules:        schedule.Modules,
+	}
+	if err := lockDraftVersionTx(tx, schedule.DraftVersionID); err != nil {
+		if err == sql.ErrNoRows {
+			if err := finishSyncSchedule(tx, id, syncScheduleFailed, 0, "draft version not found", now); err != nil {
+				return false, err
+			}
+			return false, tx.Commit()
+		}
+		return false, err
+	}
+
+	pending, err := countPendingConfirm(tx, schedule.DraftVersionID)
+	if err != nil {
+		return false, err
+	}
+	if pending > 0 {
+		message := fmt.Sprintf("pending_confirm: %d submissions await confirmation", pending)
+		jobID, err := s.recordRefusedJob(tx, req, id, message, now)
+		if err != nil {
+			return false, err
+		}
+		if err := finishSyncSchedule(tx, id, syncScheduleRefused, jobID, message, now); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+
+	jobID, err := s.runner.enqueueTx(tx, req, schedule.Modules, now)
+	if err != nil {
+		var (
+			lifecycleErr *draftLifecycleError
+			approvalErr  *approvalPendingError
+		)
+		if !errors.Is(err, errSyncJobActive) && !errors.As(err, &lifecycleErr) && !errors.As(err, &approvalErr) {
+			return false, err
+		}
+		status, message := syncScheduleFailed, "sync_in_progress"
+		if lifecycleErr != nil {
+			status, message = syncScheduleRefused, lifecycleErr.Error()
+		}
+		if approvalErr != nil {
+			status, message = syncScheduleRefused, approvalErr.Error()
+		}
+		refusedID, err := s.recordRefusedJob(tx, req, id, message, now)
+		if err != nil {
+			return false, err
+		}
+		if err := finishSyncSchedule(tx, id, status, refusedID, message, now); err != nil {
+			return false, err
+		}
+		return false, tx.Commit()
+	}
+	if _, err := tx.Exec("UPDATE app_db_sync_jobs SET schedule_id = ? WHERE id = ?", id, jobID); err != nil {
+		return false, err
+	}
+	if err := finishSyncSchedule(tx, id, syncScheduleFired, jobID, "", now); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// recordRefusedJob stores a failed job so refused schedules show up in the sync job history.
+func (s *SyncScheduler) recordRefusedJob(tx *sql.Tx, req syncRequest, scheduleID int64, message string, now time.Time) (int64, error) {
+	jobID, err := insertSyncJob(tx, req.DraftVersionID, req.SyncTargetID, req.TriggerBy, req.Modules, req.Confirm, 0, now)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("UPDATE app_db_sync_jobs SET schedule_id = ? WHERE id = ?", scheduleID, jobID); err != nil {
+		return 0, err
+	}
+	if err := failSyncJob(tx, jobID, syncJobFailed, message, 0, now); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
+
+// CreateSchedule schedules a sync of a draft version at a future time.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) CreateSchedule(c *gin.Context) {
+	if strings.ToLower(strings.TrimSpace(h.cfg.AppMode)) == "online" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not available in online mode"})
+		return
+	}
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+	if h.runner == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "sync runner not ready"})
+		return
+	}
+
+	claims, ok := middleware.GetAuthClaims(c)
+	if !ok || claims.UserID <= 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req syncScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+	if req.DraftVersionID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id is required"})
+		return
+	}
+	runAt, err := ParseSyncScheduleTime(req.RunAt, appLocation(h.cfg))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	if !runAt.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "run_at must be in the future"})
+		return
+	}
+
+	invalidModules := findInvalidModules(req.Modules)
+	if len(invalidModules) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid_modules", "modules": invalidModules})
+		return
+	}
+	modules := normalizeModules(req.Modules)
+
+	if _, err := h.resolveSyncTarget(req.SyncTargetID); err != nil {
+		respondSyncTargetError(c, err)
+		return
+	}
+	if _, err := loadDraftVersion(h.db, req.DraftVersionID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "draft version not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+
+	result, err := h.db.Exec(
+		"INSERT INTO app_db_sync_schedules (draft_version_id, sync_target_id, modules_json, confirm_overwrite, run_at, status, created_by, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		req.DraftVersionID,
+		req.SyncTargetID,
+		encodeSyncModules(modules),
+		req.Confirm,
+		runAt,
+		syncScheduleScheduled,
+		claims.UserID,
+		now,
+		now,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "insert failed"})
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":               id,
+		"draft_version_id": req.DraftVersionID,
+		"sync_target_id":   req.SyncTargetID,
+		"run_at":           runAt.In(appLocation(h.cfg)),
+		"status":           syncScheduleScheduled,
+	})
+}
+
+// ListSchedules returns sync schedules, optionally filtered by draft version and status.
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) ListSchedules(c *gin.Context) {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	query := `SELECT s.id, s.draft_version_id, v.location_name, v.app_version_name, s.sync_target_id, s.modules_json,
+      s.confirm_overwrite, s.run_at, s.status, s.sync_job_id, s.error_message, s.created_by, u.display_name,
+      s.cancelled_by, s.cancelled_at, s.fired_at, s.created_at
+      FROM app_db_sync_schedules s
+      LEFT JOIN app_db_version_names v ON v.id = s.draft_version_id
+      LEFT JOIN app_db_users u ON u.id = s.created_by
+      WHERE 1 = 1`
+	args := make([]any, 0)
+	if draftID := parseInt64Query(c, "draft_version_id"); draftID > 0 {
+		query += " AND s.draft_version_id = ?"
+		args = append(args, draftID)
+	}
+	if status := strings.TrimSpace(c.Query("status")); status != "" {
+		query += " AND s.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY s.run_at DESC, s.id DESC LIMIT 200"
+
+	rows, err := h.db.Query(query, args...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	defer rows.Close()
+
+	loc := appLocation(h.cfg)
+	items := make([]gin.H, 0)
+	for rows.Next() {
+		var (
+			id             int64
+			draftVersionID int64
+			locationName   sql.NullString
+			appVersionName sql.NullString
+			syncTargetID   int64
+			modulesJSON    sql.NullString
+			confirm        sql.NullBool
+			runAt          time.Time
+			status         string
+			jobID          sql.NullInt64
+			errorMessage   sql.NullString
+			createdBy      sql.NullInt64
+			createdByName  sql.NullString
+			cancelledBy    sql.NullInt64
+			cancelledAt    sql.NullTime
+			firedAt        sql.NullTime
+			createdAt      sql.NullTime
+		)
+		if err := rows.Scan(&id, &draftVersionID, &locationName, &appVersionName, &syncTargetID, &modulesJSON, &confirm, &runAt, &status, &jobID, &errorMessage, &createdBy, &createdByName, &cancelledBy, &cancelledAt, &firedAt, &createdAt); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "scan failed"})
+			return
+		}
+		items = append(items, gin.H{
+			"id":               id,
+			"draft_version_id": draftVersionID,
+			"location_name":    nullableString(locationName),
+			"app_version_name": nullableString(appVersionName),
+			"sync_target_id":   syncTargetID,
+			"modules":          decodeSyncModules(modulesJSON),
+			"confirm":          confirm.Valid && confirm.Bool,
+			"run_at":           runAt.In(loc),
+			"status":           status,
+			"sync_job_id":      nullableInt64Pointer(jobID),
+			"error_message":    nullableString(errorMessage),
+			"created_by":       nullableInt64Pointer(createdBy),
+			"created_by_name":  nullableString(createdByName),
+			"cancelled_by":     nullableInt64Pointer(cancelledBy),
+			"cancelled_at":     nullableTimePointer(cancelledAt),
+			"fired_at":         nullableTimePointer(firedAt),
+			"created_at":       nullableTimePointer(createdAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items, "timezone": loc.String()})
+}
+
+// CancelSchedule cancels a schedule that has not fired yet (creator or admin).
+// Args:
+//
+//	c: Gin context.
+//
+// Returns:
+//
+//	None.
+func (h *SyncHandler) CancelSchedule(c *gin.Context) {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "db not ready"})
+		return
+	}
+
+	id, err := parseInt64ParamValue(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+	claims, ok := middleware.GetAuthClaims(c)
+	if !ok || claims.UserID <= 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var (
+		status    string
+		createdBy sql.NullInt64
+	)
+	if err := h.db.QueryRow("SELECT status, created_by FROM app_db_sync_schedules WHERE id = ?", id).Scan(&status, &createdBy); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "query failed"})
+		return
+	}
+	if createdBy.Int64 != claims.UserID && !strings.EqualFold(claims.Role, "admin") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
+
+	now := time.Now()
+	result, err := h.db.Exec(
+		"UPDATE app_db_sync_schedules SET status = ?, cancelled_by = ?, cancelled_at = ?, updated_at = ? WHERE id = ? AND status = ?",
+		syncScheduleCancelled,
+		claims.UserID,
+		now,
+		now,
+		id,
+		syncScheduleScheduled,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "update failed"})
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "schedule not cancellable", "status": status})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"id": id, "status": syncScheduleCancelled})
+}
+
+func loadSyncSchedule(db *sql.DB, id int64) (syncScheduleRow, error) {
+	var (
+		schedule    syncScheduleRow
+		modulesJSON sql.NullString
+		confirm     sql.NullBool
+		createdBy   sql.NullInt64
+	)
+	err := db.QueryRow(
+		"SELECT id, draft_version_id, sync_target_id, modules_json, confirm_overwrite, created_by FROM app_db_sync_schedules WHERE id = ?",
+		id,
+	).Scan(&schedule.ID, &schedule.DraftVersionID, &schedule.SyncTargetID, &modulesJSON, &confirm, &createdBy)
+	if err != nil {
+		return schedule, err
+	}
+	schedule.Modules = decodeSyncModules(modulesJSON)
+	schedule.Confirm = confirm.Valid && confirm.Bool
+	schedule.CreatedBy = createdBy.Int64
+	return schedule, nil
+}
+
+func finishSyncSchedule(db sqlExecutor, id int64, status string, jobID int64, message string, now time.Time) error {
+	firedAt := interface{}(now)
+	if status == syncScheduleScheduled {
+		firedAt = nil
+	}
+	_, err := db.Exec(
+		"UPDATE app_db_sync_schedules SET status = ?, sync_job_id = ?, error_message = ?, fired_at = ?, updated_at = ? WHERE id = ?",
+		status,
+		nullableID(jobID),
+		nullIfEmpty(message),
+		firedAt,
+		now,
+		id,
+	)
+	return err
+}
+
+// appLocation returns the configured app timezone, falling back to the process timezone.
+func appLocation(cfg *config.Config) *time.Location {
+	if cfg != nil && strings.TrimSpace(cfg.AppTimezone) != "" {
+		if loc, err := time.LoadLocation(strings.TrimSpace(cfg.AppTimezone)); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
@@ -69,6 +69,9 @@ func (h *TaskHandler) CompleteUpload(c *gin.Context) {
     c.JSON(http.StatusBadRequest, gin.H{"error": "draft_version_id missing"})
     return
   }
+  if !requireDraftEditable(c, tx, draftVersionID.Int64) {
+    return
+  }
   module := strings.TrimSpace(moduleKey.String)
   if module == "" {
     c.JSON(http.StatusBadRequest, gin.H{"error": "module_key missing"})
@@ -173,7 +176,7 @@ func (h *TaskHandler) uploadBannerAssets(tx *sql.Tx, ossService *services.OSSSer
       return uploaded, err
     }
     if changed {
-      if _, err := tx.Exec("UPDATE app_db_banners SET image = ?, updated_at = ? WHERE id = ?", newPath, time.Now(), item.id); err != nil {
+      if _, err := tx.Exec("UPDATE app_db_banners SET image = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?", newPath, time.Now(), item.id); err != nil {
         return uploaded, err
       }
       uploaded++
@@ -215,7 +218,7 @@ func (h *TaskHandler) uploadIdentityAssets(tx *sql.Tx, ossService *services.OSSS
       return uploaded, err
     }
     if changed {
-      if _, err := tx.Exec("UPDATE app_db_identities SET image = ?, updated_at = ? WHERE id = ?", newPath, time.Now(), item.id); err != nil {
+      if _, err := tx.Exec("UPDATE app_db_identities SET image = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?", newPath, time.Now(), item.id); err != nil {
         return uploaded, err
       }
       uploaded++
@@ -287,7 +290,7 @@ func (h *TaskHandler) uploadSceneAssets(tx *sql.Tx, ossService *services.OSSServ
     }
     if updated {
       if _, err := tx.Exec(
-        "UPDATE app_db_scenes SET image = ?, music = ?, watermark_path = ?, updated_at = ? WHERE id = ?",
+        "UPDATE app_db_scenes SET image = ?, music = ?, watermark_path = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
         nullableStringValue(item.image),
         nullableStringValue(item.music),
         nullableStringValue(item.watermark),
@@ -357,7 +360,7 @@ func (h *TaskHandler) uploadAppUIAssets(tx *sql.Tx, ossService *services.OSSServ
 
   if updated {
     if _, err := tx.Exec(
-      "UPDATE app_db_app_ui_fields SET step1_music = ?, step2_music = ?, print_wait = ?, updated_at = ? WHERE id = ?",
+      "UPDATE app_db_app_ui_fields SET step1_music = ?, step2_music = ?, print_wait = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
       nullableStringValue(step1),
       nullableStringValue(step2),
       nullableStringValue(printWait),
@@ -403,7 +406,7 @@ func (h *TaskHandler) uploadExtraStepAssets(tx *sql.Tx, ossService *services.OSS
       return uploaded, err
     }
     if changed {
-      if _, err := tx.Exec("UPDATE app_db_config_extra_steps SET music = ?, updated_at = ? WHERE id = ?", newPath, time.Now(), item.id); err != nil {
+      if _, err := tx.Exec("UPDATE app_db_config_extra_steps SET music = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?", newPath, time.Now(), item.id); err != nil {
         return uploaded, err
       }
       uploaded++
@@ -463,7 +466,7 @@ func (h *TaskHandler) uploadClothesAssets(tx *sql.Tx, ossService *services.OSSSe
     }
     if updated {
       if _, err := tx.Exec(
-        "UPDATE app_db_clothes_categories SET image = ?, music = ?, updated_at = ? WHERE id = ?",
+        "UPDATE app_db_clothes_categories SET image = ?, music = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
         nullableStringValue(item.image),
         nullableStringValue(item.music),
         time.Now(),
@@ -527,7 +530,7 @@ func (h *TaskHandler) uploadPhotoAssets(tx *sql.Tx, ossService *services.OSSServ
     }
     if updated {
       if _, err := tx.Exec(
-        "UPDATE app_db_photo_hobbies SET image = ?, music = ?, updated_at = ? WHERE id = ?",
+        "UPDATE app_db_photo_hobbies SET image = ?, music = ?, updated_at = ?, row_version = row_version + 1 WHERE id = ?",
         nullableStringValue(item.image),
         nullableStringValue(item.music),
         time.Now(),
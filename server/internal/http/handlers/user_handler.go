@@ -13,6 +13,9 @@ import (
 	"shushu-app-ui-dashboard/internal/services"
 )
 
+// userRoles lists the accepted user roles; the lead roles sign off approval policies.
+var userRoles = []string{"admin", "user", "ops_lead", "content_lead"}
+
 type UserHandler struct {
 	cfg *config.Config
 	db  *sql.DB
@@ -83,7 +86,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 	if role == "" {
 		role = "user"
 	}
-	if role != "admin" && role != "user" {
+	if !IsValidUserRole(role) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
 		return
 	}
@@ -248,7 +251,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	if req.Role != nil {
 		role := strings.ToLower(strings.TrimSpace(*req.Role))
-		if role != "admin" && role != "user" {
+		if !IsValidUserRole(role) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid role"})
 			return
 		}
@@ -387,3 +390,20 @@ func (h *UserHandler) ChangeMyPassword(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"id": claims.UserID})
 }
+
+// IsValidUserRole reports whether role is an accepted user role.
+// Args:
+//
+//	role: Normalized role name.
+//
+// Returns:
+//
+//	bool: True when role is known.
+func IsValidUserRole(role string) bool {
+	for _, item := range userRoles {
+		if item == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+  "bytes"
+  "errors"
+  "io"
+  "net/http"
+  "time"
+
+  "github.com/gin-gonic/gin"
+  "github.com/redis/go-redis/v9"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+const syncNonceKeyPrefix = "sync:nonce:"
+
+// RequireSyncSignature validates HMAC-signed sync requests for online mode.
+// Each nonce is accepted once; Redis keeps it until the timestamp can no longer pass the skew check.
+// Args:
+//   cfg: App config instance.
+//   rdb: Redis client used as nonce cache.
+// Returns:
+//   gin.HandlerFunc: Middleware handler.
+func RequireSyncSignature(cfg *config.Config, rdb *redis.Client) gin.HandlerFunc {
+  signer, signerErr := services.NewSyncSigner(cfg)
+  maxBody := int64(64) << 20
+  if cfg != nil && cfg.SyncMaxBodyMB > 0 {
+    maxBody = int64(cfg.SyncMaxBodyMB) << 20
+  }
+
+  return func(c *gin.Context) {
+    if signerErr != nil {
+      c.JSON(http.StatusServiceUnavailable, gin.H{"error": signerErr.Error()})
+      c.Abort()
+      return
+    }
+    if rdb == nil {
+      c.JSON(http.StatusServiceUnavailable, gin.H{"error": "nonce store not ready"})
+      c.Abort()
+      return
+    }
+
+    var body []byte
+    if c.Request.Body != nil {
+      read, readErr := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBody))
+      if readErr != nil {
+        var tooLarge *http.MaxBytesError
+        if errors.As(readErr, &tooLarge) {
+          c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body too large"})
+        } else {
+          c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+        }
+        c.Abort()
+        return
+      }
+      body = read
+      c.Request.Body = io.NopCloser(bytes.NewReader(body))
+    }
+
+    info, verifyErr := signer.Verify(c.Request.Method, c.Request.URL.RequestURI(), c.Request.Header, body, time.Now())
+    if verifyErr != nil {
+      c.JSON(http.StatusUnauthorized, gin.H{"error": verifyErr.Error()})
+      c.Abort()
+      return
+    }
+
+    fresh, nonceErr := rdb.SetNX(c.Request.Context(), syncNonceKeyPrefix+info.KeyID+":"+info.Nonce, 1, 2*signer.Skew()).Result()
+    if nonceErr != nil {
+      c.JSON(http.StatusServiceUnavailable, gin.H{"error": "nonce store not ready"})
+      c.Abort()
+      return
+    }
+    if !fresh {
+      c.JSON(http.StatusUnauthorized, gin.H{"error": "replayed request"})
+      c.Abort()
+      return
+    }
+
+    c.Next()
+  }
+}
@@ -13,8 +13,9 @@ import (
 )
 
 type Deps struct {
-	DB    *sql.DB
-	Redis *redis.Client
+	DB         *sql.DB
+	Redis      *redis.Client
+	SyncRunner *handlers.SyncRunner
 }
 
 func NewRouter(cfg *config.Config, deps *Deps) *gin.Engine {
@@ -28,9 +29,10 @@ func NewRouter(cfg *config.Config, deps *Deps) *gin.Engine {
 
 	if strings.ToLower(strings.TrimSpace(cfg.AppMode)) == "online" {
 		syncPushHandler := handlers.NewSyncPushHandler(deps.DB)
-		api.POST("/sync/push", middleware.RequireAPIKey(cfg), syncPushHandler.Push)
-		api.GET("/sync/versions", middleware.RequireAPIKey(cfg), syncPushHandler.ListVersions)
-		api.GET("/sync/snapshot", middleware.RequireAPIKey(cfg), syncPushHandler.Snapshot)
+		syncSignature := middleware.RequireSyncSignature(cfg, deps.Redis)
+		api.POST("/sync/push", syncSignature, syncPushHandler.Push)
+		api.GET("/sync/versions", syncSignature, syncPushHandler.ListVersions)
+		api.GET("/sync/snapshot", syncSignature, syncPushHandler.Snapshot)
 		return router
 	}
 
@@ -90,23 +92,47 @@ func NewRouter(cfg *config.Config, deps *Deps) *gin.Engine {
 	draft.POST("/version-names", crudHandler.CreateVersionName)
 	draft.PUT("/version-names/:id", crudHandler.UpdateVersionName)
 	draft.DELETE("/version-names/:id", crudHandler.DeleteVersionName)
+	draft.POST("/version-names/:id/transition", crudHandler.TransitionVersionName)
+	approvalHandler := handlers.NewApprovalHandler(deps.DB)
+	draft.GET("/version-names/:id/approvals", approvalHandler.List)
+	draft.POST("/version-names/:id/approvals", approvalHandler.Decide)
+	secured.GET("/approval-policies", approvalHandler.ListPolicies)
+	secured.PUT("/approval-policies", middleware.RequireAdmin(), approvalHandler.ReplacePolicies)
+	cloneHandler := handlers.NewDraftCloneHandler(cfg, deps.DB)
+	draft.POST("/version-names/:id/clone", cloneHandler.Clone)
+	draft.GET("/version-names/:id/as-of", draftHandler.GetAsOf)
+	draft.POST("/version-names/:id/as-of", draftHandler.MaterializeAsOf)
+	draft.GET("/compare", crudHandler.Compare)
+	bundleHandler := handlers.NewDraftBundleHandler(cfg, deps.DB, deps.Redis)
+	draft.GET("/version-names/:id/export", bundleHandler.Export)
+	draft.POST("/import", bundleHandler.Import)
+	draft.GET("/spreadsheet/export", crudHandler.ExportSpreadsheet)
+	draft.POST("/spreadsheet/import", crudHandler.ImportSpreadsheet)
+	draft.GET("/trash", crudHandler.ListTrash)
+	draft.POST("/trash/:module/:id/restore", crudHandler.RestoreTrash)
+	draft.DELETE("/trash/:module/:id", crudHandler.PurgeTrash)
 	draft.POST("/banners", crudHandler.CreateBanner)
 	draft.PUT("/banners/:id", crudHandler.UpdateBanner)
 	draft.DELETE("/banners/:id", crudHandler.DeleteBanner)
+	draft.POST("/banners/reorder", crudHandler.ReorderBanners)
 	draft.POST("/identities", crudHandler.CreateIdentity)
 	draft.PUT("/identities/:id", crudHandler.UpdateIdentity)
 	draft.DELETE("/identities/:id", crudHandler.DeleteIdentity)
+	draft.POST("/identities/reorder", crudHandler.ReorderIdentities)
 	templateHandler := handlers.NewIdentityTemplateHandler(cfg, deps.DB, deps.Redis)
 	draft.POST("/identities/apply-template", templateHandler.ApplyTemplate)
 	draft.POST("/scenes", crudHandler.CreateScene)
 	draft.PUT("/scenes/:id", crudHandler.UpdateScene)
 	draft.DELETE("/scenes/:id", crudHandler.DeleteScene)
+	draft.POST("/scenes/reorder", crudHandler.ReorderScenes)
 	draft.POST("/clothes-categories", crudHandler.CreateClothesCategory)
 	draft.PUT("/clothes-categories/:id", crudHandler.UpdateClothesCategory)
 	draft.DELETE("/clothes-categories/:id", crudHandler.DeleteClothesCategory)
+	draft.POST("/clothes-categories/reorder", crudHandler.ReorderClothesCategories)
 	draft.POST("/photo-hobbies", crudHandler.CreatePhotoHobby)
 	draft.PUT("/photo-hobbies/:id", crudHandler.UpdatePhotoHobby)
 	draft.DELETE("/photo-hobbies/:id", crudHandler.DeletePhotoHobby)
+	draft.POST("/photo-hobbies/reorder", crudHandler.ReorderPhotoHobbies)
 	draft.POST("/config-extra-steps", crudHandler.CreateConfigExtraStep)
 	draft.PUT("/config-extra-steps/:id", crudHandler.UpdateConfigExtraStep)
 	draft.DELETE("/config-extra-steps/:id", crudHandler.DeleteConfigExtraStep)
@@ -114,7 +140,9 @@ func NewRouter(cfg *config.Config, deps *Deps) *gin.Engine {
 	submissionHandler := handlers.NewSubmissionHandler(deps.DB)
 	draft.POST("/submit", submissionHandler.Submit)
 	draft.POST("/confirm", submissionHandler.Confirm)
+	draft.POST("/reject", submissionHandler.Reject)
 	draft.GET("/submissions", submissionHandler.List)
+	draft.POST("/submissions/:id/restore", submissionHandler.Restore)
 
 	secured.GET("/identity-templates", templateHandler.ListTemplates)
 	secured.POST("/identity-templates", middleware.RequireAdmin(), templateHandler.CreateTemplate)
@@ -126,13 +154,30 @@ func NewRouter(cfg *config.Config, deps *Deps) *gin.Engine {
 	secured.DELETE("/identity-template-items/:id", middleware.RequireAdmin(), templateHandler.DeleteTemplateItem)
 
 	secured.GET("/audit/logs", historyHandler.ListAuditLogs)
+	secured.GET("/audit/export", historyHandler.ExportAuditLogs)
+	secured.GET("/audit/verify", middleware.RequireAdmin(), historyHandler.VerifyAuditLogs)
 	secured.GET("/field-history", historyHandler.ListFieldHistory)
 
-	syncHandler := handlers.NewSyncHandler(cfg, deps.DB)
+	syncHandler := handlers.NewSyncHandler(cfg, deps.DB, deps.SyncRunner)
 	secured.POST("/sync", syncHandler.Sync)
+	secured.POST("/sync/preview", syncHandler.Preview)
 	secured.GET("/sync/jobs", syncHandler.ListModuleJobs)
+	secured.GET("/sync/jobs/:id", syncHandler.GetJob)
 	secured.GET("/sync/online/versions", syncHandler.PullVersions)
 	secured.POST("/sync/import", syncHandler.ImportFromOnline)
+	secured.GET("/sync/revisions", syncHandler.ListRevisions)
+	secured.POST("/sync/rollback", middleware.RequireAdmin(), syncHandler.Rollback)
+	secured.GET("/sync/targets", syncHandler.ListTargets)
+	secured.POST("/sync/targets", middleware.RequireAdmin(), syncHandler.CreateTarget)
+	secured.PUT("/sync/targets/:id", middleware.RequireAdmin(), syncHandler.UpdateTarget)
+	secured.DELETE("/sync/targets/:id", middleware.RequireAdmin(), syncHandler.DeleteTarget)
+	secured.GET("/sync/states", syncHandler.ListTargetStates)
+	secured.GET("/sync/schedules", syncHandler.ListSchedules)
+	secured.POST("/sync/schedules", syncHandler.CreateSchedule)
+	secured.POST("/sync/schedules/:id/cancel", syncHandler.CancelSchedule)
+	secured.GET("/sync/drift", syncHandler.ListDrift)
+	secured.POST("/sync/drift/check", syncHandler.CheckDrift)
+	secured.POST("/sync/drift/import", syncHandler.ImportDrift)
 
 	dashboardHandler := handlers.NewDashboardHandler(deps.DB)
 	secured.GET("/dashboard/summary", dashboardHandler.Summary)
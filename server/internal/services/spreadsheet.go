@@ -0,0 +1,339 @@
+package services
+
+import (
+  "archive/zip"
+  "encoding/csv"
+  "encoding/xml"
+  "errors"
+  "fmt"
+  "io"
+  "path"
+  "strconv"
+  "strings"
+  "unicode/utf8"
+)
+
+const (
+  maxSpreadsheetPartSize = 64 << 20
+  // maxSpreadsheetColumns and maxSpreadsheetRows are the sheet bounds of Excel (column XFD, row 1048576).
+  maxSpreadsheetColumns = 16384
+  maxSpreadsheetRows    = 1048576
+  // MaxSpreadsheetImportRows caps the rows read from an uploaded sheet, header included.
+  MaxSpreadsheetImportRows = 10000
+)
+
+var errSpreadsheetTooManyRows = fmt.Errorf("spreadsheet has more than %d rows", MaxSpreadsheetImportRows)
+
+var errSpreadsheetEmpty = errors.New("spreadsheet has no sheet")
+
+// WriteCSV writes rows as UTF-8 CSV with a BOM so Excel detects the encoding.
+// Args:
+//   w: Output writer.
+//   rows: Cell rows, the first row is the header.
+// Returns:
+//   error: Error when writing fails.
+func WriteCSV(w io.Writer, rows [][]string) error {
+  if _, err := w.Write([]byte("\xef\xbb\xbf")); err != nil {
+    return err
+  }
+  writer := csv.NewWriter(w)
+  if err := writer.WriteAll(rows); err != nil {
+    return err
+  }
+  return writer.Error()
+}
+
+// ReadCSV reads CSV rows, skipping a leading UTF-8 BOM.
+// Args:
+//   r: Input reader.
+// Returns:
+//   [][]string: Cell rows.
+//   error: Error when content is not valid CSV or has more than MaxSpreadsheetImportRows rows.
+func ReadCSV(r io.Reader) ([][]string, error) {
+  reader := csv.NewReader(r)
+  reader.FieldsPerRecord = -1
+  rows := make([][]string, 0)
+  for {
+    record, err := reader.Read()
+    if err == io.EOF {
+      break
+    }
+    if err != nil {
+      return nil, err
+    }
+    if len(rows) >= MaxSpreadsheetImportRows {
+      return nil, errSpreadsheetTooManyRows
+    }
+    rows = append(rows, record)
+  }
+  if len(rows) > 0 && len(rows[0]) > 0 {
+    rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+  }
+  return rows, nil
+}
+
+// WriteXLSX writes rows as a single-sheet XLSX workbook using inline strings.
+// Args:
+//   w: Output writer.
+//   sheetName: Worksheet name.
+//   rows: Cell rows, the first row is the header.
+// Returns:
+//   error: Error when writing fails.
+func WriteXLSX(w io.Writer, sheetName string, rows [][]string) error {
+  sheetName = strings.TrimSpace(sheetName)
+  if sheetName == "" {
+    sheetName = "Sheet1"
+  }
+  if utf8.RuneCountInString(sheetName) > 31 {
+    sheetName = string([]rune(sheetName)[:31])
+  }
+
+  var sheet strings.Builder
+  sheet.WriteString(xml.Header)
+  sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+  for rowIndex, row := range rows {
+    fmt.Fprintf(&sheet, `<row r="%d">`, rowIndex+1)
+    for colIndex, value := range row {
+      ref := SpreadsheetColumnName(colIndex) + strconv.Itoa(rowIndex+1)
+      fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+      if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+        return err
+      }
+      sheet.WriteString(`</t></is></c>`)
+    }
+    sheet.WriteString(`</row>`)
+  }
+  sheet.WriteString(`</sheetData></worksheet>`)
+
+  var escapedName strings.Builder
+  if err := xml.EscapeText(&escapedName, []byte(sheetName)); err != nil {
+    return err
+  }
+  parts := []struct {
+    name    string
+    content string
+  }{
+    {"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`},
+    {"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+    {"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="` + escapedName.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`},
+    {"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`},
+    {"xl/worksheets/sheet1.xml", sheet.String()},
+  }
+
+  archive := zip.NewWriter(w)
+  for _, part := range parts {
+    writer, err := archive.Create(part.name)
+    if err != nil {
+      return err
+    }
+    if _, err := io.WriteString(writer, part.content); err != nil {
+      return err
+    }
+  }
+  return archive.Close()
+}
+
+type xlsxWorkbook struct {
+  Sheets []struct {
+    Name string `xml:"name,attr"`
+    RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+  } `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+  Items []struct {
+    ID     string `xml:"Id,attr"`
+    Target string `xml:"Target,attr"`
+  } `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+  Text string `xml:"t"`
+  Runs []struct {
+    Text string `xml:"t"`
+  } `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+  if len(t.Runs) == 0 {
+    return t.Text
+  }
+  var builder strings.Builder
+  builder.WriteString(t.Text)
+  for _, run := range t.Runs {
+    builder.WriteString(run.Text)
+  }
+  return builder.String()
+}
+
+type xlsxSharedStrings struct {
+  Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxSheet struct {
+  Rows []struct {
+    Index int `xml:"r,attr"`
+    Cells []struct {
+      Ref    string       `xml:"r,attr"`
+      Type   string       `xml:"t,attr"`
+      Value  string       `xml:"v"`
+      Inline xlsxRichText `xml:"is"`
+    } `xml:"c"`
+  } `xml:"sheetData>row"`
+}
+
+// ReadXLSX reads the first worksheet of an XLSX workbook as string rows.
+// Args:
+//   r: Workbook content.
+//   size: Content size in bytes.
+// Returns:
+//   [][]string: Cell rows, gaps filled with empty strings.
+//   error: Error when the workbook cannot be parsed, a cell lies outside the sheet bounds
+//     or the sheet has more than MaxSpreadsheetImportRows rows.
+func ReadXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+  archive, err := zip.NewReader(r, size)
+  if err != nil {
+    return nil, err
+  }
+  files := make(map[string]*zip.File, len(archive.File))
+  for _, file := range archive.File {
+    files[strings.TrimPrefix(file.Name, "/")] = file
+  }
+
+  var workbook xlsxWorkbook
+  if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil {
+    return nil, err
+  }
+  if len(workbook.Sheets) == 0 {
+    return nil, errSpreadsheetEmpty
+  }
+  sheetPath := "xl/worksheets/sheet1.xml"
+  var rels xlsxRelationships
+  if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err == nil {
+    for _, item := range rels.Items {
+      if item.ID != workbook.Sheets[0].RID {
+        continue
+      }
+      if strings.HasPrefix(item.Target, "/") {
+        sheetPath = strings.TrimPrefix(item.Target, "/")
+      } else {
+        sheetPath = path.Join("xl", item.Target)
+      }
+    }
+  }
+
+  var shared xlsxSharedStrings
+  if _, ok := files["xl/sharedStrings.xml"]; ok {
+    if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+      return nil, err
+    }
+  }
+
+  var sheet xlsxSheet
+  if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+    return nil, err
+  }
+
+  rows := make([][]string, 0, len(sheet.Rows))
+  for position, row := range sheet.Rows {
+    rowIndex := row.Index
+    if rowIndex <= 0 {
+      rowIndex = position + 1
+    }
+    if rowIndex > maxSpreadsheetRows {
+      return nil, fmt.Errorf("row %d is outside the sheet", rowIndex)
+    }
+    if rowIndex > MaxSpreadsheetImportRows || len(rows) >= MaxSpreadsheetImportRows {
+      return nil, errSpreadsheetTooManyRows
+    }
+    for len(rows) < rowIndex-1 {
+      rows = append(rows, []string{})
+    }
+    values := make([]string, 0, len(row.Cells))
+    for cellPosition, cell := range row.Cells {
+      colIndex := cellPosition
+      if cell.Ref != "" {
+        parsed, err := spreadsheetColumnIndex(cell.Ref)
+        if err != nil {
+          return nil, err
+        }
+        colIndex = parsed
+      }
+      if colIndex >= maxSpreadsheetColumns {
+        return nil, fmt.Errorf("column %d is outside the sheet", colIndex+1)
+      }
+      for len(values) < colIndex {
+        values = append(values, "")
+      }
+      value := cell.Value
+      switch cell.Type {
+      case "s":
+        index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+        if err != nil || index < 0 || index >= len(shared.Items) {
+          return nil, fmt.Errorf("invalid shared string at %s", cell.Ref)
+        }
+        value = shared.Items[index].String()
+      case "inlineStr":
+        value = cell.Inline.String()
+      }
+      if colIndex < len(values) {
+        values[colIndex] = value
+      } else {
+        values = append(values, value)
+      }
+    }
+    rows = append(rows, values)
+  }
+  return rows, nil
+}
+
+func decodeXLSXPart(files map[string]*zip.File, name string, target interface{}) error {
+  file, ok := files[name]
+  if !ok {
+    return fmt.Errorf("%s is missing", name)
+  }
+  if file.UncompressedSize64 > maxSpreadsheetPartSize {
+    return fmt.Errorf("%s is too large", name)
+  }
+  reader, err := file.Open()
+  if err != nil {
+    return err
+  }
+  defer func() {
+    _ = reader.Close()
+  }()
+  return xml.NewDecoder(io.LimitReader(reader, maxSpreadsheetPartSize)).Decode(target)
+}
+
+// SpreadsheetColumnName converts a zero-based column index into letters (0 -> A, 26 -> AA).
+// Args:
+//   index: Zero-based column index.
+// Returns:
+//   string: Column letters.
+func SpreadsheetColumnName(index int) string {
+  name := ""
+  for index >= 0 {
+    name = string(rune('A'+index%26)) + name
+    index = index/26 - 1
+  }
+  return name
+}
+
+func spreadsheetColumnIndex(ref string) (int, error) {
+  index := 0
+  letters := 0
+  for _, ch := range strings.ToUpper(ref) {
+    if ch < 'A' || ch > 'Z' {
+      break
+    }
+    index = index*26 + int(ch-'A'+1)
+    letters++
+    if index > maxSpreadsheetColumns {
+      return 0, fmt.Errorf("cell reference %q is outside the sheet", ref)
+    }
+  }
+  if letters == 0 {
+    return 0, fmt.Errorf("invalid cell reference %q", ref)
+  }
+  return index - 1, nil
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+  "crypto/hmac"
+  "crypto/rand"
+  "crypto/sha256"
+  "encoding/hex"
+  "errors"
+  "fmt"
+  "net/http"
+  "strconv"
+  "strings"
+  "time"
+
+  "shushu-app-ui-dashboard/internal/config"
+)
+
+const (
+  SyncKeyIDHeader     = "X-Sync-Key-Id"
+  SyncTimestampHeader = "X-Sync-Timestamp"
+  SyncNonceHeader     = "X-Sync-Nonce"
+  SyncSignatureHeader = "X-Sync-Signature"
+
+  defaultSyncKeyID         = "default"
+  defaultSyncSignatureSkew = 5 * time.Minute
+  minSyncNonceLength       = 16
+  maxSyncNonceLength       = 128
+)
+
+var (
+  ErrSyncKeysNotConfigured = errors.New("sync signing keys not configured")
+  ErrSyncSignatureMissing  = errors.New("missing sync signature")
+  ErrSyncSignatureExpired  = errors.New("sync signature expired")
+  ErrSyncKeyUnknown        = errors.New("unknown sync key")
+  ErrSyncSignatureInvalid  = errors.New("invalid sync signature")
+)
+
+// SyncKey is one named HMAC secret used to sign sync requests.
+type SyncKey struct {
+  ID        string
+  Secret    string
+  ExpiresAt time.Time
+}
+
+// SyncSigner signs outgoing sync requests and verifies incoming ones.
+type SyncSigner struct {
+  keys     []SyncKey
+  activeID string
+  skew     time.Duration
+}
+
+// SyncSignatureInfo describes a verified request.
+type SyncSignatureInfo struct {
+  KeyID string
+  Nonce string
+}
+
+// NewSyncSigner creates a signer from SYNC_KEYS, falling back to SYNC_API_KEY.
+// Args:
+//   cfg: App config instance.
+// Returns:
+//   *SyncSigner: Initialized signer.
+//   error: Error when keys are missing or malformed.
+func NewSyncSigner(cfg *config.Config) (*SyncSigner, error) {
+  if cfg == nil {
+    return nil, ErrSyncKeysNotConfigured
+  }
+  keys, err := ParseSyncKeys(cfg.SyncKeys)
+  if err != nil {
+    return nil, err
+  }
+  if len(keys) == 0 && strings.TrimSpace(cfg.SyncAPIKey) != "" {
+    keys = []SyncKey{{ID: defaultSyncKeyID, Secret: strings.TrimSpace(cfg.SyncAPIKey)}}
+  }
+  if len(keys) == 0 {
+    return nil, ErrSyncKeysNotConfigured
+  }
+
+  activeID := strings.TrimSpace(cfg.SyncKeyID)
+  if activeID == "" {
+    activeID = keys[0].ID
+  }
+  found := false
+  for _, key := range keys {
+    if key.ID == activeID {
+      found = true
+      break
+    }
+  }
+  if !found {
+    return nil, fmt.Errorf("sync key %q not found in SYNC_KEYS", activeID)
+  }
+
+  skew := time.Duration(cfg.SyncSignatureSkewSeconds) * time.Second
+  if skew <= 0 {
+    skew = defaultSyncSignatureSkew
+  }
+  return &SyncSigner{keys: keys, activeID: activeID, skew: skew}, nil
+}
+
+// NewSyncKeySigner creates a signer that signs with one named key.
+// Args:
+//   keyID: Key id sent in the key id header.
+//   secret: Shared key secret.
+// Returns:
+//   *SyncSigner: Initialized signer.
+//   error: Error when id or secret is empty.
+func NewSyncKeySigner(keyID, secret string) (*SyncSigner, error) {
+  keyID = strings.TrimSpace(keyID)
+  secret = strings.TrimSpace(secret)
+  if keyID == "" || secret == "" {
+    return nil, ErrSyncKeysNotConfigured
+  }
+  return &SyncSigner{
+    keys:     []SyncKey{{ID: keyID, Secret: secret}},
+    activeID: keyID,
+    skew:     defaultSyncSignatureSkew,
+  }, nil
+}
+
+// ParseSyncKeys parses "id:secret[:expires_at]" entries separated by commas.
+// Args:
+//   raw: Key list, expires_at is RFC3339 or unix seconds.
+// Returns:
+//   []SyncKey: Parsed keys in declaration order.
+//   error: Error when an entry is malformed or duplicated.
+func ParseSyncKeys(raw string) ([]SyncKey, error) {
+  keys := make([]SyncKey, 0)
+  seen := make(map[string]struct{})
+  for _, entry := range strings.Split(raw, ",") {
+    entry = strings.TrimSpace(entry)
+    if entry == "" {
+      continue
+    }
+    parts := strings.SplitN(entry, ":", 3)
+    if len(parts) < 2 {
+      return nil, fmt.Errorf("invalid sync key entry %q", entry)
+    }
+    key := SyncKey{ID: strings.TrimSpace(parts[0]), Secret: strings.TrimSpace(parts[1])}
+    if key.ID == "" || key.Secret == "" {
+      return nil, fmt.Errorf("invalid sync key entry %q", entry)
+    }
+    if _, ok := seen[key.ID]; ok {
+      return nil, fmt.Errorf("duplicate sync key %q", key.ID)
+    }
+    if len(parts) == 3 && strings.TrimSpace(parts[2]) != "" {
+      expiresAt, err := parseSyncKeyExpiry(strings.TrimSpace(parts[2]))
+      if err != nil {
+        return nil, fmt.Errorf("invalid expiry for sync key %q", key.ID)
+      }
+      key.ExpiresAt = expiresAt
+    }
+    seen[key.ID] = struct{}{}
+    keys = append(keys, key)
+  }
+  return keys, nil
+}
+
+// SyncSignature computes the hex HMAC-SHA256 signature of a sync request.
+// Args:
+//   secret: Shared key secret.
+//   method: HTTP method.
+//   path: Request path including the raw query string.
+//   timestamp: Unix seconds as sent in the timestamp header.
+//   nonce: Per-request random nonce.
+//   body: Raw request body.
+// Returns:
+//   string: Lowercase hex signature.
+func SyncSignature(secret, method, path, timestamp, nonce string, body []byte) string {
+  bodyHash := sha256.Sum256(body)
+  canonical := strings.Join([]string{
+    strings.ToUpper(method),
+    path,
+    timestamp,
+    nonce,
+    hex.EncodeToString(bodyHash[:]),
+  }, "\n")
+  mac := hmac.New(sha256.New, []byte(secret))
+  mac.Write([]byte(canonical))
+  return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Skew returns the accepted clock difference between peers.
+// Returns:
+//   time.Duration: Allowed skew.
+func (s *SyncSigner) Skew() time.Duration {
+  return s.skew
+}
+
+// Sign attaches key id, timestamp, nonce and signature headers to a request.
+// Args:
+//   req: Outgoing HTTP request.
+//   body: Raw body that will be sent with the request.
+//   now: Signing time.
+// Returns:
+//   error: Error when the active key is unusable.
+func (s *SyncSigner) Sign(req *http.Request, body []byte, now time.Time) error {
+  key, ok := s.lookup(s.activeID)
+  if !ok {
+    return ErrSyncKeysNotConfigured
+  }
+  if !key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt) {
+    return fmt.Errorf("sync key %q expired", key.ID)
+  }
+  nonce, err := newSyncNonce()
+  if err != nil {
+    return err
+  }
+  timestamp := strconv.FormatInt(now.Unix(), 10)
+  req.Header.Set(SyncKeyIDHeader, key.ID)
+  req.Header.Set(SyncTimestampHeader, timestamp)
+  req.Header.Set(SyncNonceHeader, nonce)
+  req.Header.Set(SyncSignatureHeader, SyncSignature(key.Secret, req.Method, req.URL.RequestURI(), timestamp, nonce, body))
+  return nil
+}
+
+// Verify checks the signature headers of an incoming request.
+// Replay protection is left to the caller, which must reject reused nonces.
+// Args:
+//   method: HTTP method.
+//   path: Request path including the raw query string.
+//   header: Request headers.
+//   body: Raw request body.
+//   now: Verification time.
+// Returns:
+//   SyncSignatureInfo: Key id and nonce of the request.
+//   error: Error when the signature is missing, stale or invalid.
+func (s *SyncSigner) Verify(method, path string, header http.Header, body []byte, now time.Time) (SyncSignatureInfo, error) {
+  keyID := strings.TrimSpace(header.Get(SyncKeyIDHeader))
+  timestamp := strings.TrimSpace(header.Get(SyncTimestampHeader))
+  nonce := strings.TrimSpace(header.Get(SyncNonceHeader))
+  signature := strings.ToLower(strings.TrimSpace(header.Get(SyncSignatureHeader)))
+  if keyID == "" || timestamp == "" || nonce == "" || signature == "" {
+    return SyncSignatureInfo{}, ErrSyncSignatureMissing
+  }
+  if len(nonce) < minSyncNonceLength || len(nonce) > maxSyncNonceLength {
+    return SyncSignatureInfo{}, ErrSyncSignatureInvalid
+  }
+
+  unix, err := strconv.ParseInt(timestamp, 10, 64)
+  if err != nil {
+    return SyncSignatureInfo{}, ErrSyncSignatureInvalid
+  }
+  diff := now.Sub(time.Unix(unix, 0))
+  if diff < 0 {
+    diff = -diff
+  }
+  if diff > s.skew {
+    return SyncSignatureInfo{}, ErrSyncSignatureExpired
+  }
+
+  key, ok := s.lookup(keyID)
+  if !ok || (!key.ExpiresAt.IsZero() && !now.Before(key.ExpiresAt)) {
+    return SyncSignatureInfo{}, ErrSyncKeyUnknown
+  }
+  expected := SyncSignature(key.Secret, method, path, timestamp, nonce, body)
+  if !hmac.Equal([]byte(expected), []byte(signature)) {
+    return SyncSignatureInfo{}, ErrSyncSignatureInvalid
+  }
+  return SyncSignatureInfo{KeyID: key.ID, Nonce: nonce}, nil
+}
+
+func (s *SyncSigner) lookup(id string) (SyncKey, bool) {
+  for _, key := range s.keys {
+    if key.ID == id {
+      return key, true
+    }
+  }
+  return SyncKey{}, false
+}
+
+func parseSyncKeyExpiry(raw string) (time.Time, error) {
+  if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+    return time.Unix(unix, 0), nil
+  }
+  return time.Parse(time.RFC3339, raw)
+}
+
+func newSyncNonce() (string, error) {
+  buf := make([]byte, 16)
+  if _, err := rand.Read(buf); err != nil {
+    return "", err
+  }
+  return hex.EncodeToString(buf), nil
+}
@@ -1,86 +1,395 @@
 package store
 
 import (
+  "context"
+  "crypto/sha256"
   "database/sql"
+  "encoding/hex"
   "fmt"
-  "os"
-  "path/filepath"
+  "io/fs"
+  "log"
+  "regexp"
   "sort"
+  "strconv"
   "strings"
+  "time"
 )
 
-func ApplyMigrations(db *sql.DB) error {
+const (
+  migrationLockName    = "schema_migrations"
+  migrationLockTimeout = 60
+)
+
+// Migration states reported by MigrationStatuses.
+const (
+  MigrationPending          = "pending"
+  MigrationApplied          = "applied"
+  MigrationChecksumMismatch = "checksum_mismatch"
+  MigrationMissing          = "missing"
+)
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([A-Za-z0-9_]+)\.sql$`)
+
+const createSchemaMigrationsSQL = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+  "`version` int unsigned NOT NULL, " +
+  "`name` varchar(255) COLLATE utf8mb4_unicode_ci NOT NULL, " +
+  "`checksum` char(64) COLLATE utf8mb4_unicode_ci NOT NULL, " +
+  "`applied_at` datetime NOT NULL, " +
+  "PRIMARY KEY (`version`)" +
+  ") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci"
+
+// MigrationSet is one group of migrations tracked in the schema_migrations table of a database.
+type MigrationSet struct {
+  Name  string
+  Files fs.FS
+  // LegacyMaxVersion marks migrations written for the untracked runner; they may ignore duplicate column errors.
+  LegacyMaxVersion int
+}
+
+// Migration is one versioned SQL file.
+type Migration struct {
+  Version  int
+  Name     string
+  Checksum string
+  SQL      string
+}
+
+// AppliedMigration is a row of schema_migrations.
+type AppliedMigration struct {
+  Version   int
+  Name      string
+  Checksum  string
+  AppliedAt time.Time
+}
+
+// MigrationStatus compares a migration file with its schema_migrations row.
+type MigrationStatus struct {
+  Version         int
+  Name            string
+  State           string
+  Checksum        string
+  AppliedChecksum string
+  AppliedAt       *time.Time
+}
+
+// LoadMigrations reads NNN_name.sql files from fsys ordered by version.
+// Args:
+//   fsys: Migration files.
+// Returns:
+//   []Migration: Migrations with their checksum.
+//   error: Error when a name is malformed or two files share a version.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+  entries, err := fs.ReadDir(fsys, ".")
+  if err != nil {
+    return nil, err
+  }
+
+  migrations := make([]Migration, 0, len(entries))
+  seen := make(map[int]string, len(entries))
+  for _, entry := range entries {
+    if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+      continue
+    }
+    matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+    if matches == nil {
+      return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+    }
+    version, err := strconv.Atoi(matches[1])
+    if err != nil || version <= 0 {
+      return nil, fmt.Errorf("invalid migration version %s", entry.Name())
+    }
+    if other, ok := seen[version]; ok {
+      return nil, fmt.Errorf("migration version %d used by %s and %s", version, other, entry.Name())
+    }
+    seen[version] = entry.Name()
+
+    raw, err := fs.ReadFile(fsys, entry.Name())
+    if err != nil {
+      return nil, fmt.Errorf("read migration %s failed: %w", entry.Name(), err)
+    }
+    content := strings.ReplaceAll(string(raw), "\r\n", "\n")
+    sum := sha256.Sum256([]byte(content))
+    migrations = append(migrations, Migration{
+      Version:  version,
+      Name:     matches[2],
+      Checksum: hex.EncodeToString(sum[:]),
+      SQL:      content,
+    })
+  }
+  sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+  return migrations, nil
+}
+
+// CompareMigrations pairs migration files with the rows recorded in schema_migrations.
+// Args:
+//   migrations: Files ordered by version.
+//   applied: Recorded rows keyed by version.
+// Returns:
+//   []MigrationStatus: One entry per file, then recorded rows without a file, ordered by version.
+func CompareMigrations(migrations []Migration, applied map[int]AppliedMigration) []MigrationStatus {
+  statuses := make([]MigrationStatus, 0, len(migrations))
+  known := make(map[int]struct{}, len(migrations))
+  for _, migration := range migrations {
+    known[migration.Version] = struct{}{}
+    status := MigrationStatus{
+      Version:  migration.Version,
+      Name:     migration.Name,
+      State:    MigrationPending,
+      Checksum: migration.Checksum,
+    }
+    if row, ok := applied[migration.Version]; ok {
+      appliedAt := row.AppliedAt
+      status.AppliedAt = &appliedAt
+      status.AppliedChecksum = row.Checksum
+      status.State = MigrationApplied
+      if row.Checksum != migration.Checksum {
+        status.State = MigrationChecksumMismatch
+      }
+    }
+    statuses = append(statuses, status)
+  }
+  for version, row := range applied {
+    if _, ok := known[version]; ok {
+      continue
+    }
+    appliedAt := row.AppliedAt
+    statuses = append(statuses, MigrationStatus{
+      Version:         version,
+      Name:            row.Name,
+      State:           MigrationMissing,
+      AppliedChecksum: row.Checksum,
+      AppliedAt:       &appliedAt,
+    })
+  }
+  sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+  return statuses
+}
+
+// ApplyMigrations applies every pending migration of a set once, recording it in schema_migrations.
+// Each migration runs in its own transaction on a single connection and is recorded only after all
+// statements succeed; MySQL still commits DDL implicitly, so migrations must stay idempotent.
+// Args:
+//   db: Database connection.
+//   set: Migration set to apply.
+// Returns:
+//   error: Error when a checksum no longer matches or a migration fails.
+func ApplyMigrations(db *sql.DB, set MigrationSet) error {
   if db == nil {
     return fmt.Errorf("db is nil")
   }
-  dir, err := discoverMigrationsDir()
+  migrations, err := LoadMigrations(set.Files)
+  if err != nil {
+    return err
+  }
+
+  ctx := context.Background()
+  conn, err := db.Conn(ctx)
   if err != nil {
     return err
   }
-  entries, err := os.ReadDir(dir)
+  defer conn.Close()
+
+  var locked sql.NullInt64
+  if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", migrationLockName, migrationLockTimeout).Scan(&locked); err != nil {
+    return err
+  }
+  if !locked.Valid || locked.Int64 != 1 {
+    return fmt.Errorf("wait for migration lock timed out")
+  }
+  defer func() {
+    _, _ = conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationLockName)
+  }()
+
+  if _, err := conn.ExecContext(ctx, createSchemaMigrationsSQL); err != nil {
+    return fmt.Errorf("create schema_migrations failed: %w", err)
+  }
+  applied, err := loadAppliedMigrations(ctx, conn)
   if err != nil {
     return err
   }
 
-  files := make([]string, 0)
-  for _, entry := range entries {
-    if entry.IsDir() {
+  statuses := CompareMigrations(migrations, applied)
+  mismatched := make([]string, 0)
+  for _, status := range statuses {
+    if status.State == MigrationChecksumMismatch {
+      mismatched = append(mismatched, fmt.Sprintf("%03d_%s", status.Version, status.Name))
+    }
+  }
+  if len(mismatched) > 0 {
+    return fmt.Errorf("%s migrations changed after being applied: %s", set.Name, strings.Join(mismatched, ", "))
+  }
+
+  for _, migration := range migrations {
+    if _, ok := applied[migration.Version]; ok {
       continue
     }
-    name := entry.Name()
-    if strings.HasSuffix(name, ".sql") {
-      files = append(files, name)
+    if err := applyMigration(ctx, conn, migration, migration.Version <= set.LegacyMaxVersion); err != nil {
+      return fmt.Errorf("apply migration %03d_%s failed: %w", migration.Version, migration.Name, err)
     }
+    log.Printf("applied %s migration %03d_%s", set.Name, migration.Version, migration.Name)
   }
-  sort.Strings(files)
+  return nil
+}
 
-  for _, name := range files {
-    path := filepath.Join(dir, name)
-    raw, err := os.ReadFile(path)
-    if err != nil {
-      return fmt.Errorf("read migration %s failed: %w", name, err)
+// MigrationStatuses reports which migrations of a set are applied, pending or changed.
+// Args:
+//   db: Database connection.
+//   set: Migration set to inspect.
+// Returns:
+//   []MigrationStatus: Status per migration.
+//   error: Error when files or schema_migrations cannot be read.
+func MigrationStatuses(db *sql.DB, set MigrationSet) ([]MigrationStatus, error) {
+  if db == nil {
+    return nil, fmt.Errorf("db is nil")
+  }
+  migrations, err := LoadMigrations(set.Files)
+  if err != nil {
+    return nil, err
+  }
+  ctx := context.Background()
+  conn, err := db.Conn(ctx)
+  if err != nil {
+    return nil, err
+  }
+  defer conn.Close()
+
+  applied := map[int]AppliedMigration{}
+  var exists int
+  if err := conn.QueryRowContext(
+    ctx,
+    "SELECT COUNT(*) FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'schema_migrations'",
+  ).Scan(&exists); err != nil {
+    return nil, err
+  }
+  if exists > 0 {
+    if applied, err = loadAppliedMigrations(ctx, conn); err != nil {
+      return nil, err
     }
-    statements := splitSQLStatements(string(raw))
-    for _, stmt := range statements {
-      if strings.TrimSpace(stmt) == "" {
-        continue
+  }
+  return CompareMigrations(migrations, applied), nil
+}
+
+// SplitSQLStatements splits a script on semicolons outside quotes, identifiers and comments.
+// Args:
+//   content: SQL script.
+// Returns:
+//   []string: Trimmed statements without comments; empty statements are dropped.
+func SplitSQLStatements(content string) []string {
+  runes := []rune(strings.ReplaceAll(content, "\r\n", "\n"))
+  statements := make([]string, 0)
+  var current strings.Builder
+  flush := func() {
+    if statement := strings.TrimSpace(current.String()); statement != "" {
+      statements = append(statements, statement)
+    }
+    current.Reset()
+  }
+
+  var quote rune
+  for i := 0; i < len(runes); i++ {
+    ch := runes[i]
+    next := rune(0)
+    if i+1 < len(runes) {
+      next = runes[i+1]
+    }
+
+    if quote != 0 {
+      current.WriteRune(ch)
+      switch {
+      case ch == '\\' && quote != '`' && next != 0:
+        current.WriteRune(next)
+        i++
+      case ch == quote && next == quote:
+        current.WriteRune(next)
+        i++
+      case ch == quote:
+        quote = 0
+      }
+      continue
+    }
+
+    switch {
+    case ch == '\'' || ch == '"' || ch == '`':
+      quote = ch
+      current.WriteRune(ch)
+    case ch == '#' || (ch == '-' && next == '-' && (i+2 >= len(runes) || isSQLSpace(runes[i+2]))):
+      for i < len(runes) && runes[i] != '\n' {
+        i++
       }
-      if _, err := db.Exec(stmt); err != nil {
-        if isDuplicateColumnError(err) {
-          continue
-        }
-        return fmt.Errorf("apply migration %s failed: %w", name, err)
+      current.WriteRune('\n')
+    case ch == '/' && next == '*' && (i+2 >= len(runes) || runes[i+2] != '!'):
+      i += 2
+      for i < len(runes) && !(runes[i] == '*' && i+1 < len(runes) && runes[i+1] == '/') {
+        i++
       }
+      i++
+      current.WriteRune(' ')
+    case ch == ';':
+      flush()
+    default:
+      current.WriteRune(ch)
     }
   }
-  return nil
+  flush()
+  return statements
 }
 
-func isDuplicateColumnError(err error) bool {
-  if err == nil {
-    return false
+func applyMigration(ctx context.Context, conn *sql.Conn, migration Migration, legacy bool) error {
+  tx, err := conn.BeginTx(ctx, nil)
+  if err != nil {
+    return err
   }
-  msg := strings.ToLower(err.Error())
-  return strings.Contains(msg, "duplicate column name")
+  defer func() {
+    _ = tx.Rollback()
+  }()
+
+  for _, statement := range SplitSQLStatements(migration.SQL) {
+    if _, err := tx.ExecContext(ctx, statement); err != nil {
+      if legacy && isDuplicateColumnError(err) {
+        continue
+      }
+      return err
+    }
+  }
+  if _, err := tx.ExecContext(
+    ctx,
+    "INSERT INTO `schema_migrations` (`version`, `name`, `checksum`, `applied_at`) VALUES (?, ?, ?, ?)",
+    migration.Version,
+    migration.Name,
+    migration.Checksum,
+    time.Now(),
+  ); err != nil {
+    return err
+  }
+  return tx.Commit()
 }
 
-func discoverMigrationsDir() (string, error) {
-  candidates := []string{
-    "migrations",
-    filepath.Join("server", "migrations"),
+func loadAppliedMigrations(ctx context.Context, conn *sql.Conn) (map[int]AppliedMigration, error) {
+  rows, err := conn.QueryContext(ctx, "SELECT `version`, `name`, `checksum`, `applied_at` FROM `schema_migrations`")
+  if err != nil {
+    return nil, err
   }
-  for _, dir := range candidates {
-    info, err := os.Stat(dir)
-    if err != nil || !info.IsDir() {
-      continue
+  defer rows.Close()
+
+  applied := make(map[int]AppliedMigration)
+  for rows.Next() {
+    var row AppliedMigration
+    if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+      return nil, err
     }
-    return dir, nil
+    applied[row.Version] = row
+  }
+  return applied, rows.Err()
+}
+
+func isDuplicateColumnError(err error) bool {
+  if err == nil {
+    return false
   }
-  return "", fmt.Errorf("migrations directory not found")
+  msg := strings.ToLower(err.Error())
+  return strings.Contains(msg, "duplicate column name")
 }
 
-func splitSQLStatements(content string) []string {
-  cleaned := strings.ReplaceAll(content, "\r\n", "\n")
-  parts := strings.Split(cleaned, ";")
-  return parts
+func isSQLSpace(ch rune) bool {
+  return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
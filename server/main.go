@@ -1,13 +1,15 @@
 package main
 
 import (
+  "context"
   "log"
   "net/http"
-  "strings"
+  "os"
   "time"
 
   "shushu-app-ui-dashboard/internal/config"
   apphttp "shushu-app-ui-dashboard/internal/http"
+  "shushu-app-ui-dashboard/internal/http/handlers"
   "shushu-app-ui-dashboard/internal/store"
 )
 
@@ -25,6 +27,10 @@ func main() {
     }
   }
 
+  if len(os.Args) > 1 && os.Args[1] == "migrate" {
+    os.Exit(runMigrateCommand(cfg, os.Args[2:]))
+  }
+
   var dbErr error
   var redisErr error
   var deps apphttp.Deps
@@ -33,9 +39,18 @@ func main() {
     deps.DB, dbErr = store.NewMySQL(cfg.MysqlDSN)
     if dbErr != nil {
       log.Printf("mysql connect failed: %v", dbErr)
-    } else if strings.ToLower(strings.TrimSpace(cfg.AppMode)) != "online" {
-      if err := store.ApplyMigrations(deps.DB); err != nil {
-        log.Printf("apply migrations failed: %v", err)
+    } else {
+      // Background workers write tables the migrations create or change, so they only
+      // start on a fully migrated schema; the API still serves reads for diagnosis.
+      if err := store.ApplyMigrations(deps.DB, migrationSet(cfg)); err != nil {
+        log.Printf("apply migrations failed, background workers not started: %v", err)
+      } else if !isOnlineMode(cfg) {
+        deps.SyncRunner = handlers.NewSyncRunner(cfg, deps.DB)
+        deps.SyncRunner.Start(context.Background())
+        handlers.NewSyncScheduler(deps.DB, deps.SyncRunner).Start(context.Background())
+        handlers.NewSyncDriftDetector(cfg, deps.DB).Start(context.Background())
+        handlers.NewDraftTrashPurger(cfg, deps.DB).Start(context.Background())
+        handlers.NewAuditAnchorer(cfg, deps.DB).Start(context.Background())
       }
     }
   } else {
@@ -0,0 +1,96 @@
+package main
+
+import (
+  "fmt"
+  "os"
+  "strings"
+  "text/tabwriter"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/store"
+  "shushu-app-ui-dashboard/migrations"
+)
+
+const migrateUsage = "usage: server migrate [status|up]"
+
+// runMigrateCommand handles "server migrate status" and "server migrate up" for the configured mode.
+// Args:
+//   cfg: App config instance.
+//   args: Arguments after "migrate".
+// Returns:
+//   int: Process exit code; status exits 1 when a migration is pending, changed or missing.
+func runMigrateCommand(cfg *config.Config, args []string) int {
+  command := "status"
+  if len(args) > 0 {
+    command = args[0]
+  }
+  if command != "status" && command != "up" {
+    fmt.Fprintln(os.Stderr, migrateUsage)
+    return 2
+  }
+  if cfg.MysqlDSN == "" {
+    fmt.Fprintln(os.Stderr, "MYSQL_DSN not set")
+    return 1
+  }
+  db, err := store.NewMySQL(cfg.MysqlDSN)
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "mysql connect failed: %v\n", err)
+    return 1
+  }
+  defer db.Close()
+
+  set := migrationSet(cfg)
+  if command == "up" {
+    if err := store.ApplyMigrations(db, set); err != nil {
+      fmt.Fprintf(os.Stderr, "apply migrations failed: %v\n", err)
+      return 1
+    }
+  }
+
+  statuses, err := store.MigrationStatuses(db, set)
+  if err != nil {
+    fmt.Fprintf(os.Stderr, "read migration status failed: %v\n", err)
+    return 1
+  }
+  fmt.Printf("%s migrations\n", set.Name)
+  writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+  fmt.Fprintln(writer, "VERSION\tNAME\tSTATE\tAPPLIED_AT\tCHECKSUM")
+  exitCode := 0
+  for _, status := range statuses {
+    appliedAt := "-"
+    if status.AppliedAt != nil {
+      appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+    }
+    checksum := status.Checksum
+    if checksum == "" {
+      checksum = status.AppliedChecksum
+    }
+    if len(checksum) > 12 {
+      checksum = checksum[:12]
+    }
+    fmt.Fprintf(writer, "%03d\t%s\t%s\t%s\t%s\n", status.Version, status.Name, status.State, appliedAt, checksum)
+    if status.State != store.MigrationApplied {
+      exitCode = 1
+    }
+  }
+  if err := writer.Flush(); err != nil {
+    return 1
+  }
+  return exitCode
+}
+
+// migrationSet returns the migrations of the configured app mode.
+func migrationSet(cfg *config.Config) store.MigrationSet {
+  if isOnlineMode(cfg) {
+    return store.MigrationSet{Name: "online", Files: migrations.Online()}
+  }
+  return store.MigrationSet{
+    Name:             "internal",
+    Files:            migrations.Internal(),
+    LegacyMaxVersion: migrations.InternalLegacyVersion,
+  }
+}
+
+func isOnlineMode(cfg *config.Config) bool {
+  return strings.ToLower(strings.TrimSpace(cfg.AppMode)) == "online"
+}
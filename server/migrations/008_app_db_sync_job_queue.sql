@@ -0,0 +1,53 @@
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `modules_json` json DEFAULT NULL AFTER `trigger_by`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `confirm_overwrite` tinyint(1) DEFAULT 0 AFTER `modules_json`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `attempts` int unsigned DEFAULT 0 AFTER `status`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `max_attempts` int unsigned DEFAULT 0 AFTER `attempts`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `next_run_at` datetime DEFAULT NULL AFTER `max_attempts`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `target_app_version_name_id` int unsigned DEFAULT NULL AFTER `next_run_at`;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `updated_at` datetime DEFAULT NULL AFTER `created_at`;
+
+ALTER TABLE `app_db_sync_module_jobs`
+  ADD COLUMN `job_id` bigint unsigned DEFAULT NULL AFTER `id`;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_sync_jobs'
+    AND INDEX_NAME = 'idx_status_next_run_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_sync_jobs` ADD KEY `idx_status_next_run_at` (`status`, `next_run_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_sync_module_jobs'
+    AND INDEX_NAME = 'idx_job_id'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_sync_module_jobs` ADD KEY `idx_job_id` (`job_id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `app_db_sync_revisions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned DEFAULT NULL,
+  `target_app_version_name_id` int unsigned NOT NULL,
+  `app_version_name` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `sync_job_id` bigint unsigned DEFAULT NULL,
+  `source` varchar(32) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `rollback_of_id` bigint unsigned DEFAULT NULL,
+  `snapshot_json` longtext COLLATE utf8mb4_unicode_ci NOT NULL,
+  `pushed_by` int unsigned DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_target_app_version_name_id` (`target_app_version_name_id`),
+  KEY `idx_draft_version_id` (`draft_version_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
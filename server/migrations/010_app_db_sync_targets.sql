@@ -0,0 +1,93 @@
+CREATE TABLE IF NOT EXISTS `app_db_sync_targets` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `name` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `url` varchar(512) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `key_id` varchar(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `secret` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `timeout_seconds` int unsigned DEFAULT NULL,
+  `is_enabled` tinyint(1) DEFAULT 1,
+  `remark` varchar(255) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `created_by` int unsigned DEFAULT NULL,
+  `updated_by` int unsigned DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `app_db_sync_target_states` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned NOT NULL,
+  `sync_target_id` int unsigned NOT NULL DEFAULT 0,
+  `sync_status` varchar(32) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `sync_message` text COLLATE utf8mb4_unicode_ci,
+  `target_app_version_name_id` int unsigned DEFAULT NULL,
+  `synced_at` datetime DEFAULT NULL,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_draft_target` (`draft_version_id`, `sync_target_id`),
+  KEY `idx_sync_target_id` (`sync_target_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `app_db_version_names`
+  ADD COLUMN `last_sync_target_id` int unsigned DEFAULT NULL AFTER `target_app_version_name_id`;
+
+INSERT IGNORE INTO `app_db_sync_target_states` (`draft_version_id`, `sync_target_id`, `sync_status`, `sync_message`, `target_app_version_name_id`, `synced_at`)
+SELECT `id`, 0, `sync_status`, `sync_message`, `target_app_version_name_id`, `synced_at`
+FROM `app_db_version_names`
+WHERE (`sync_status` IS NOT NULL OR `target_app_version_name_id` IS NOT NULL)
+  AND (`last_sync_target_id` IS NULL OR `last_sync_target_id` = 0);
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `sync_target_id` int unsigned NOT NULL DEFAULT 0 AFTER `draft_version_id`;
+
+ALTER TABLE `app_db_sync_revisions`
+  ADD COLUMN `sync_target_id` int unsigned NOT NULL DEFAULT 0 AFTER `draft_version_id`;
+
+ALTER TABLE `app_db_sync_id_map`
+  ADD COLUMN `sync_target_id` int unsigned NOT NULL DEFAULT 0 AFTER `draft_version_id`;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_sync_id_map'
+    AND INDEX_NAME = 'uk_draft_target_module_row'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_sync_id_map` DROP INDEX `uk_draft_module_row`, ADD UNIQUE KEY `uk_draft_target_module_row` (`draft_version_id`, `sync_target_id`, `module_key`, `draft_row_id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_sync_jobs'
+    AND INDEX_NAME = 'idx_draft_target_status'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_sync_jobs` ADD KEY `idx_draft_target_status` (`draft_version_id`, `sync_target_id`, `status`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_sync_revisions'
+    AND INDEX_NAME = 'idx_sync_target_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_sync_revisions` ADD KEY `idx_sync_target_version` (`sync_target_id`, `target_app_version_name_id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS `app_db_sync_schedules` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned NOT NULL,
+  `sync_target_id` int unsigned NOT NULL DEFAULT 0,
+  `modules_json` json DEFAULT NULL,
+  `confirm_overwrite` tinyint(1) DEFAULT 0,
+  `run_at` datetime NOT NULL,
+  `status` varchar(32) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `sync_job_id` bigint unsigned DEFAULT NULL,
+  `error_message` text COLLATE utf8mb4_unicode_ci,
+  `created_by` int unsigned DEFAULT NULL,
+  `cancelled_by` int unsigned DEFAULT NULL,
+  `cancelled_at` datetime DEFAULT NULL,
+  `fired_at` datetime DEFAULT NULL,
+  `created_at` datetime DEFAULT CURRENT_TIMESTAMP,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  KEY `idx_status_run_at` (`status`, `run_at`),
+  KEY `idx_draft_version_id` (`draft_version_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+ALTER TABLE `app_db_sync_jobs`
+  ADD COLUMN `schedule_id` bigint unsigned DEFAULT NULL AFTER `sync_target_id`;
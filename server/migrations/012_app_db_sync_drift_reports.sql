@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS `app_db_sync_drift_reports` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned NOT NULL,
+  `sync_target_id` int unsigned NOT NULL DEFAULT 0,
+  `module_key` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `target_app_version_name_id` int unsigned DEFAULT NULL,
+  `drifted` tinyint(1) NOT NULL DEFAULT 0,
+  `added_count` int unsigned NOT NULL DEFAULT 0,
+  `removed_count` int unsigned NOT NULL DEFAULT 0,
+  `modified_count` int unsigned NOT NULL DEFAULT 0,
+  `report_json` json DEFAULT NULL,
+  `error_message` text COLLATE utf8mb4_unicode_ci,
+  `checked_by` int unsigned DEFAULT NULL,
+  `checked_at` datetime NOT NULL,
+  `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_draft_target_module` (`draft_version_id`, `sync_target_id`, `module_key`),
+  KEY `idx_drifted` (`drifted`, `draft_version_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
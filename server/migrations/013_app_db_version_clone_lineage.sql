@@ -0,0 +1,59 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'cloned_from_id'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `cloned_from_id` int unsigned DEFAULT NULL AFTER `target_app_version_name_id`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'cloned_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `cloned_by` int unsigned DEFAULT NULL AFTER `cloned_from_id`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'cloned_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `cloned_at` datetime DEFAULT NULL AFTER `cloned_by`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND INDEX_NAME = 'idx_cloned_from_id'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD KEY `idx_cloned_from_id` (`cloned_from_id`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,119 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_app_ui_fields'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_app_ui_fields` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_banners'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_banners` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_identities'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_identities` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_scenes'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_scenes` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_clothes_categories'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_clothes_categories` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_photo_hobbies'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_photo_hobbies` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_config_extra_steps'
+    AND COLUMN_NAME = 'row_version'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_config_extra_steps` ADD COLUMN `row_version` int unsigned NOT NULL DEFAULT 1 AFTER `updated_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,269 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_banners'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_banners` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_banners'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_banners` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_banners'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_banners` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_identities'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_identities` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_identities'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_identities` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_identities'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_identities` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_scenes'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_scenes` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_scenes'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_scenes` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_scenes'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_scenes` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_clothes_categories'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_clothes_categories` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_clothes_categories'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_clothes_categories` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_clothes_categories'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_clothes_categories` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_photo_hobbies'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_photo_hobbies` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_photo_hobbies'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_photo_hobbies` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_photo_hobbies'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_photo_hobbies` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_config_extra_steps'
+    AND COLUMN_NAME = 'deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_config_extra_steps` ADD COLUMN `deleted_at` datetime DEFAULT NULL AFTER `row_version`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_config_extra_steps'
+    AND COLUMN_NAME = 'deleted_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_config_extra_steps` ADD COLUMN `deleted_by` int unsigned DEFAULT NULL AFTER `deleted_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_config_extra_steps'
+    AND INDEX_NAME = 'idx_draft_deleted_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_config_extra_steps` ADD KEY `idx_draft_deleted_at` (`draft_version_id`, `deleted_at`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,33 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'draft_status_changed_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `draft_status_changed_by` int unsigned DEFAULT NULL AFTER `draft_status`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'draft_status_changed_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `draft_status_changed_at` datetime DEFAULT NULL AFTER `draft_status_changed_by`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+UPDATE `app_db_version_names` SET `draft_status` = 'draft' WHERE `draft_status` IS NULL OR `draft_status` = '';
+UPDATE `app_db_version_names` SET `draft_status` = 'in_review' WHERE `draft_status` IN ('submitted', 'pending_confirm');
+UPDATE `app_db_version_names` SET `draft_status` = 'approved' WHERE `draft_status` = 'confirmed';
@@ -0,0 +1,42 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_version_names'
+    AND COLUMN_NAME = 'review_round'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_version_names` ADD COLUMN `review_round` int unsigned NOT NULL DEFAULT 0 AFTER `draft_status_changed_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `app_db_approval_policies` (
+  `id` int unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned NOT NULL DEFAULT 0,
+  `module_key` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+  `role` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+  `required_count` int unsigned NOT NULL DEFAULT 1,
+  `created_by` int unsigned DEFAULT NULL,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_policy_scope` (`draft_version_id`, `module_key`, `role`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE TABLE IF NOT EXISTS `app_db_approvals` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `draft_version_id` int unsigned NOT NULL,
+  `review_round` int unsigned NOT NULL DEFAULT 0,
+  `module_key` varchar(64) COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '',
+  `approver_id` int unsigned NOT NULL,
+  `approver_role` varchar(20) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `decision` varchar(16) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `reason` text COLLATE utf8mb4_unicode_ci,
+  `created_at` datetime NOT NULL,
+  `updated_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_approval_round` (`draft_version_id`, `review_round`, `module_key`, `approver_id`),
+  KEY `idx_approval_round` (`draft_version_id`, `review_round`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,74 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND COLUMN_NAME = 'rejected_by'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD COLUMN `rejected_by` int unsigned DEFAULT NULL AFTER `confirmed_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND COLUMN_NAME = 'rejected_at'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD COLUMN `rejected_at` datetime DEFAULT NULL AFTER `rejected_by`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND COLUMN_NAME = 'reject_reason'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD COLUMN `reject_reason` text COLLATE utf8mb4_unicode_ci AFTER `rejected_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND COLUMN_NAME = 'reverted'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD COLUMN `reverted` tinyint(1) NOT NULL DEFAULT 0 AFTER `reject_reason`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.STATISTICS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND INDEX_NAME = 'idx_draft_status'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD KEY `idx_draft_status` (`draft_version_id`, `status`)',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
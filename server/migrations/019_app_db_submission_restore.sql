@@ -0,0 +1,14 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_submissions'
+    AND COLUMN_NAME = 'restored_from_id'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_submissions` ADD COLUMN `restored_from_id` bigint unsigned DEFAULT NULL AFTER `prev_submission_id`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,16 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_field_history'
+    AND COLUMN_NAME = 'action'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_field_history` ADD COLUMN `action` varchar(32) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `new_value`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+UPDATE `app_db_field_history` SET `action` = 'submit' WHERE `action` IS NULL AND `submit_id` IS NOT NULL;
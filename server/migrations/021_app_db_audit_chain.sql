@@ -0,0 +1,50 @@
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_audit_logs'
+    AND COLUMN_NAME = 'prev_hash'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_audit_logs` ADD COLUMN `prev_hash` char(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `created_at`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+SET @exists := (
+  SELECT COUNT(*)
+  FROM INFORMATION_SCHEMA.COLUMNS
+  WHERE TABLE_SCHEMA = DATABASE()
+    AND TABLE_NAME = 'app_db_audit_logs'
+    AND COLUMN_NAME = 'hash'
+);
+SET @sql := IF(@exists = 0,
+  'ALTER TABLE `app_db_audit_logs` ADD COLUMN `hash` char(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL AFTER `prev_hash`',
+  'SELECT 1'
+);
+PREPARE stmt FROM @sql;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
+
+CREATE TABLE IF NOT EXISTS `app_db_audit_chain_head` (
+  `id` tinyint unsigned NOT NULL,
+  `first_id` bigint unsigned DEFAULT NULL,
+  `last_id` bigint unsigned DEFAULT NULL,
+  `last_hash` char(64) COLLATE utf8mb4_unicode_ci DEFAULT NULL,
+  `updated_at` datetime DEFAULT NULL,
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+INSERT IGNORE INTO `app_db_audit_chain_head` (`id`, `first_id`, `last_id`, `last_hash`, `updated_at`) VALUES (1, NULL, NULL, NULL, NULL);
+
+CREATE TABLE IF NOT EXISTS `app_db_audit_anchors` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `last_id` bigint unsigned NOT NULL,
+  `last_hash` char(64) COLLATE utf8mb4_unicode_ci NOT NULL,
+  `row_count` bigint unsigned NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (`id`),
+  KEY `idx_last_id` (`last_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,34 @@
+package migrations
+
+import (
+  "embed"
+  "io/fs"
+)
+
+// InternalLegacyVersion is the last internal migration written for the untracked runner,
+// which replayed every file on each start and ignored duplicate column errors.
+const InternalLegacyVersion = 21
+
+//go:embed *.sql
+var internal embed.FS
+
+//go:embed online/*.sql
+var online embed.FS
+
+// Internal returns the draft database migrations applied in internal mode.
+// Returns:
+//   fs.FS: Migration files named NNN_name.sql.
+func Internal() fs.FS {
+  return internal
+}
+
+// Online returns the migrations applied to the online database in online mode.
+// Returns:
+//   fs.FS: Migration files named NNN_name.sql.
+func Online() fs.FS {
+  sub, err := fs.Sub(online, "online")
+  if err != nil {
+    panic(err)
+  }
+  return sub
+}
@@ -0,0 +1,4 @@
+-- Online mode baseline.
+-- The online app tables are owned by the app itself; the sync push API only writes rows into them.
+-- This entry starts schema_migrations tracking on the online database so later online
+-- schema changes (indexes, sync bookkeeping) ship as numbered files in this directory.
@@ -0,0 +1,179 @@
+package handlers_test
+
+import (
+  "net/url"
+  "reflect"
+  "testing"
+  "time"
+
+  "shushu-app-ui-dashboard/internal/http/handlers"
+)
+
+func buildAuditChain(t *testing.T, count int) []handlers.AuditChainEntry {
+  t.Helper()
+  table := "app_db_museums"
+  action := "update"
+  createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+  entries := make([]handlers.AuditChainEntry, 0, count)
+  prevHash := ""
+  for i := 0; i < count; i++ {
+    id := int64(i + 1)
+    detail := `{"fields": ["name"]}`
+    entry := handlers.AuditChainEntry{
+      ID:          id,
+      EntityTable: &table,
+      EntityID:    &id,
+      Action:      &action,
+      DetailJSON:  &detail,
+      CreatedAt:   &createdAt,
+      PrevHash:    prevHash,
+    }
+    entry.Hash = handlers.ComputeAuditHash(entry)
+    prevHash = entry.Hash
+    entries = append(entries, entry)
+  }
+  return entries
+}
+
+func TestComputeAuditHash(t *testing.T) {
+  entry := buildAuditChain(t, 1)[0]
+  if len(entry.Hash) != 64 {
+    t.Fatalf("expected hex sha256, got %q", entry.Hash)
+  }
+
+  entry.Hash = "ignored"
+  if handlers.ComputeAuditHash(entry) != buildAuditChain(t, 1)[0].Hash {
+    t.Fatalf("hash should not depend on the stored hash")
+  }
+
+  local := entry.CreatedAt.In(time.FixedZone("CST", 8*3600))
+  entry.CreatedAt = &local
+  if handlers.ComputeAuditHash(entry) != buildAuditChain(t, 1)[0].Hash {
+    t.Fatalf("hash should not depend on the time zone")
+  }
+
+  entry.PrevHash = "x"
+  if handlers.ComputeAuditHash(entry) == buildAuditChain(t, 1)[0].Hash {
+    t.Fatalf("hash should cover the previous hash")
+  }
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+  entries := buildAuditChain(t, 3)
+  if broken := handlers.VerifyAuditChain(entries, ""); broken != nil {
+    t.Fatalf("expected intact chain, got %#v", broken)
+  }
+  if broken := handlers.VerifyAuditChain(entries[1:], entries[0].Hash); broken != nil {
+    t.Fatalf("expected intact page, got %#v", broken)
+  }
+
+  tampered := buildAuditChain(t, 3)
+  detail := `{"fields": ["title"]}`
+  tampered[1].DetailJSON = &detail
+  broken := handlers.VerifyAuditChain(tampered, "")
+  if broken == nil || broken.ID != 2 || broken.Reason != "hash_mismatch" {
+    t.Fatalf("expected hash_mismatch at 2, got %#v", broken)
+  }
+
+  removed := buildAuditChain(t, 3)
+  removed = append(removed[:1], removed[2:]...)
+  broken = handlers.VerifyAuditChain(removed, "")
+  if broken == nil || broken.ID != 3 || broken.Reason != "prev_hash_mismatch" {
+    t.Fatalf("expected prev_hash_mismatch at 3, got %#v", broken)
+  }
+
+  unhashed := buildAuditChain(t, 2)
+  unhashed[0].Hash = ""
+  broken = handlers.VerifyAuditChain(unhashed, "")
+  if broken == nil || broken.ID != 1 || broken.Reason != "missing_hash" {
+    t.Fatalf("expected missing_hash at 1, got %#v", broken)
+  }
+}
+
+func TestParseAuditLogFilter(t *testing.T) {
+  loc := time.FixedZone("CST", 8*3600)
+  filter, err := handlers.ParseAuditLogFilter(url.Values{
+    "action":   {"update, delete,"},
+    "actor_id": {"7"},
+    "from":     {"2024-05-01"},
+    "to":       {"2024-05-02"},
+    "order":    {"ASC"},
+  }, loc)
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if !reflect.DeepEqual(filter.Actions, []string{"update", "delete"}) || filter.ActorID != 7 {
+    t.Fatalf("unexpected filter: %#v", filter)
+  }
+  if filter.Sort != "id" || filter.Desc {
+    t.Fatalf("expected id asc, got %s desc=%v", filter.Sort, filter.Desc)
+  }
+  if !filter.From.Equal(time.Date(2024, 5, 1, 0, 0, 0, 0, loc)) || !filter.To.Equal(time.Date(2024, 5, 2, 23, 59, 59, 0, loc)) {
+    t.Fatalf("unexpected range: %v - %v", filter.From, filter.To)
+  }
+
+  for name, values := range map[string]url.Values{
+    "sort":   {"sort": {"actor"}},
+    "order":  {"order": {"up"}},
+    "from":   {"from": {"yesterday"}},
+    "range":  {"from": {"2024-05-03"}, "to": {"2024-05-01"}},
+    "cursor": {"cursor": {"???"}},
+    "cursor sort": {
+      "sort":   {"created_at"},
+      "cursor": {handlers.EncodeAuditLogCursor(handlers.AuditLogCursor{ID: 3})},
+    },
+  } {
+    if _, err := handlers.ParseAuditLogFilter(values, loc); err == nil {
+      t.Fatalf("expected %s error", name)
+    }
+  }
+}
+
+func TestBuildAuditLogQuery(t *testing.T) {
+  createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+  where, orderBy, args := handlers.BuildAuditLogQuery(handlers.AuditLogFilter{
+    DraftVersionID: 2,
+    Actions:        []string{"update", "delete"},
+    Actor:          "li_%",
+    Text:           "name",
+    Sort:           "created_at",
+    Desc:           true,
+    Cursor:         &handlers.AuditLogCursor{ID: 9, CreatedAt: &createdAt},
+  })
+  expectedWhere := " AND l.draft_version_id = ? AND l.action IN (?, ?)" +
+    " AND (u.username LIKE ? OR u.display_name LIKE ?)" +
+    " AND CAST(l.detail_json AS CHAR) LIKE ?" +
+    " AND (l.created_at < ? OR (l.created_at = ? AND l.id < ?))"
+  if where != expectedWhere {
+    t.Fatalf("unexpected where: %s", where)
+  }
+  if orderBy != " ORDER BY l.created_at DESC, l.id DESC" {
+    t.Fatalf("unexpected order: %s", orderBy)
+  }
+  expectedArgs := []interface{}{int64(2), "update", "delete", `%li\_\%%`, `%li\_\%%`, "%name%", createdAt, createdAt, int64(9)}
+  if !reflect.DeepEqual(args, expectedArgs) {
+    t.Fatalf("unexpected args: %#v", args)
+  }
+
+  where, orderBy, args = handlers.BuildAuditLogQuery(handlers.AuditLogFilter{
+    Sort:   "id",
+    Cursor: &handlers.AuditLogCursor{ID: 9},
+  })
+  if where != " AND l.id > ?" || orderBy != " ORDER BY l.id ASC" || !reflect.DeepEqual(args, []interface{}{int64(9)}) {
+    t.Fatalf("unexpected asc query: %s %s %#v", where, orderBy, args)
+  }
+}
+
+func TestAuditLogCursorRoundTrip(t *testing.T) {
+  createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+  cursor, err := handlers.DecodeAuditLogCursor(handlers.EncodeAuditLogCursor(handlers.AuditLogCursor{ID: 5, CreatedAt: &createdAt}))
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if cursor.ID != 5 || cursor.CreatedAt == nil || !cursor.CreatedAt.Equal(createdAt) {
+    t.Fatalf("unexpected cursor: %#v", cursor)
+  }
+  if _, err := handlers.DecodeAuditLogCursor(handlers.EncodeAuditLogCursor(handlers.AuditLogCursor{})); err == nil {
+    t.Fatalf("expected error for empty cursor")
+  }
+}
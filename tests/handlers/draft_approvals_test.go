@@ -0,0 +1,59 @@
+package handlers_test
+
+import (
+  "testing"
+
+  "shushu-app-ui-dashboard/internal/http/handlers"
+)
+
+func TestEvaluateApprovals(t *testing.T) {
+  requirements := []handlers.ApprovalRequirement{
+    {ModuleKey: "", Role: "content_lead", RequiredCount: 1},
+    {ModuleKey: "scenes", Role: "", RequiredCount: 2},
+  }
+  records := []handlers.ApprovalRecord{
+    {ApproverID: 1, ApproverRole: "content_lead", Decision: "approved"},
+    {ApproverID: 2, ModuleKey: "scenes", ApproverRole: "user", Decision: "approved"},
+    {ApproverID: 2, ModuleKey: "scenes", ApproverRole: "user", Decision: "approved"},
+  }
+  evaluation := handlers.EvaluateApprovals(requirements, records)
+  if evaluation.Satisfied || len(evaluation.Missing) != 1 {
+    t.Fatalf("expected one unmet requirement, got %+v", evaluation)
+  }
+  if gap := evaluation.Missing[0]; gap.ModuleKey != "scenes" || gap.Approved != 1 || gap.Missing != 1 {
+    t.Fatalf("unexpected gap %+v", gap)
+  }
+
+  records = append(records, handlers.ApprovalRecord{ApproverID: 3, ModuleKey: "scenes", ApproverRole: "ops_lead", Decision: "approved"})
+  if evaluation := handlers.EvaluateApprovals(requirements, records); !evaluation.Satisfied {
+    t.Fatalf("expected policy satisfied, got %+v", evaluation)
+  }
+
+  records = append(records, handlers.ApprovalRecord{ApproverID: 4, ApproverRole: "ops_lead", Decision: "rejected", Reason: "copy"})
+  if evaluation := handlers.EvaluateApprovals(requirements, records); evaluation.Satisfied || len(evaluation.Rejected) != 1 {
+    t.Fatalf("expected rejection to block, got %+v", evaluation)
+  }
+  if evaluation := handlers.EvaluateApprovals(nil, nil); !evaluation.Satisfied {
+    t.Fatalf("expected empty policy to pass")
+  }
+}
+
+func TestNormalizeApprovalRequirements(t *testing.T) {
+  normalized, err := handlers.NormalizeApprovalRequirements([]handlers.ApprovalRequirement{
+    {ModuleKey: "Scenes", Role: "ops_lead", RequiredCount: 1},
+    {ModuleKey: "scenes", Role: "OPS_LEAD", RequiredCount: 2},
+    {Role: "", RequiredCount: 1},
+  })
+  if err != nil || len(normalized) != 2 {
+    t.Fatalf("expected 2 requirements, got %+v, err=%v", normalized, err)
+  }
+  if normalized[1].ModuleKey != "scenes" || normalized[1].RequiredCount != 2 {
+    t.Fatalf("expected merged scenes requirement, got %+v", normalized[1])
+  }
+  if _, err := handlers.NormalizeApprovalRequirements([]handlers.ApprovalRequirement{{Role: "guest", RequiredCount: 1}}); err == nil {
+    t.Fatalf("expected invalid role error")
+  }
+  if _, err := handlers.NormalizeApprovalRequirements([]handlers.ApprovalRequirement{{RequiredCount: 0}}); err == nil {
+    t.Fatalf("expected invalid count error")
+  }
+}
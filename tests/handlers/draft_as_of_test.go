@@ -0,0 +1,57 @@
+package handlers_test
+
+import (
+  "testing"
+  "time"
+
+  "shushu-app-ui-dashboard/internal/http/handlers"
+)
+
+// TestRewindFieldHistory verifies field changes are replayed backwards.
+func TestRewindFieldHistory(t *testing.T) {
+  rows := map[int64]map[string]interface{}{
+    1: {"id": int64(1), "title": "c", "sort": int64(3)},
+  }
+  first := `"a"`
+  second := `"b"`
+  changes := []handlers.FieldHistoryChange{
+    {EntityID: 1, Field: "title", OldValue: &second},
+    {EntityID: 1, Field: "title", OldValue: &first},
+    {EntityID: 1, Field: "sort", OldValue: nil},
+    {EntityID: 1, Field: "unknown", OldValue: &first},
+    {EntityID: 2, Field: "title", OldValue: &first},
+  }
+
+  applied := handlers.RewindFieldHistory(rows, changes)
+  if applied != 3 {
+    t.Fatalf("expected 3 applied changes, got %d", applied)
+  }
+  if rows[1]["title"] != "a" {
+    t.Fatalf("expected oldest title, got %#v", rows[1]["title"])
+  }
+  if rows[1]["sort"] != nil {
+    t.Fatalf("expected nil sort, got %#v", rows[1]["sort"])
+  }
+  if _, ok := rows[1]["unknown"]; ok {
+    t.Fatalf("unexpected unknown field")
+  }
+}
+
+func TestSubmissionStatusAt(t *testing.T) {
+  at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+  before := at.Add(-time.Hour)
+  after := at.Add(time.Hour)
+
+  if got := handlers.SubmissionStatusAt(true, &after, nil, at); got != "pending_confirm" {
+    t.Fatalf("expected pending_confirm, got %s", got)
+  }
+  if got := handlers.SubmissionStatusAt(true, &before, nil, at); got != "confirmed" {
+    t.Fatalf("expected confirmed, got %s", got)
+  }
+  if got := handlers.SubmissionStatusAt(false, nil, &after, at); got != "submitted" {
+    t.Fatalf("expected submitted, got %s", got)
+  }
+  if got := handlers.SubmissionStatusAt(true, nil, &at, at); got != "rejected" {
+    t.Fatalf("expected rejected, got %s", got)
+  }
+}
@@ -1,8 +1,10 @@
 package handlers_test
 
 import (
+  "database/sql"
   "reflect"
   "testing"
+  "time"
 
   "shushu-app-ui-dashboard/internal/http/handlers"
 )
@@ -72,3 +74,222 @@ func TestBuildUpdateSQL(t *testing.T) {
     t.Fatalf("unexpected args: %#v", args)
   }
 }
+
+func TestBuildRevisionedUpdateSQL(t *testing.T) {
+  payload := map[string]interface{}{ "name": "a", "status": 1 }
+  sqlText, args, err := handlers.BuildRevisionedUpdateSQL("app_db_demo", "id", 5, 3, payload)
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if sqlText != "UPDATE `app_db_demo` SET `name` = ?,`status` = ?,`row_version` = `row_version` + 1 WHERE `id` = ? AND `row_version` = ?" {
+    t.Fatalf("unexpected sql: %s", sqlText)
+  }
+  expected := []any{"a", 1, int64(5), int64(3)}
+  if !reflect.DeepEqual(args, expected) {
+    t.Fatalf("unexpected args: %#v", args)
+  }
+}
+
+func TestParseRevisionToken(t *testing.T) {
+  cases := []struct {
+    header   string
+    body     interface{}
+    expected int64
+    ok       bool
+  }{
+    {header: `"3"`, expected: 3, ok: true},
+    {header: `W/"4"`, body: float64(9), expected: 4, ok: true},
+    {header: "5", expected: 5, ok: true},
+    {body: float64(7), expected: 7, ok: true},
+    {body: "8", expected: 8, ok: true},
+    {header: "*", body: float64(2), ok: false},
+    {body: float64(0), ok: false},
+    {},
+  }
+  for _, item := range cases {
+    revision, ok := handlers.ParseRevisionToken(item.header, item.body)
+    if revision != item.expected || ok != item.ok {
+      t.Fatalf("header %q body %v: got %d %v", item.header, item.body, revision, ok)
+    }
+  }
+}
+
+func TestCompareDraftModuleMatchesByKey(t *testing.T) {
+  text := func(value string) sql.NullString { return sql.NullString{String: value, Valid: true} }
+  columns := []string{"name", "image", "sort"}
+  left := []handlers.SyncDiffRow{
+    {DraftID: 1, Key: "a", Values: []sql.NullString{text("a"), text("local://a.png"), text("1")}},
+    {DraftID: 2, Key: "b", Values: []sql.NullString{text("b"), text("local://b.png"), text("2")}},
+    {DraftID: 3, Key: "c", Values: []sql.NullString{text("c"), text("local://c.png"), text("3")}},
+  }
+  right := []handlers.SyncDiffRow{
+    {DraftID: 11, Key: "a", Values: []sql.NullString{text("a"), text("local://a-copy.png"), text("1")}},
+    {DraftID: 12, Key: "b", Values: []sql.NullString{text("b"), text("local://b.png"), text("5")}},
+    {DraftID: 14, Key: "d", Values: []sql.NullString{text("d"), {}, text("4")}},
+  }
+  hashes := map[string]string{"local://a.png": "h1", "local://a-copy.png": "h1"}
+
+  result := handlers.CompareDraftModule("identities", columns, left, right, hashes)
+  if result.Unchanged != 1 || result.Changed != 1 || result.Removed != 1 || result.Added != 1 {
+    t.Fatalf("unexpected counts: %#v", result)
+  }
+  for _, row := range result.Rows {
+    if row.Action == "changed" {
+      if row.LeftID != 2 || row.RightID != 12 || len(row.Diff) != 1 || row.Diff[0].Field != "sort" || row.Diff[0].New != int64(5) {
+        t.Fatalf("unexpected changed row: %#v", row)
+      }
+    }
+  }
+}
+
+func TestValidateDraftBundleManifest(t *testing.T) {
+  if errs := handlers.ValidateDraftBundleManifest(handlers.DraftBundleManifest{SchemaVersion: 99}); len(errs) != 1 || errs[0].Field != "schema_version" {
+    t.Fatalf("expected schema version error, got %#v", errs)
+  }
+
+  manifest := handlers.DraftBundleManifest{
+    SchemaVersion: handlers.DraftBundleSchemaVersion,
+    Version:       handlers.DraftBundleVersion{AppVersionName: "BOWUGUAN", AiModal: "SD"},
+    Modules: map[string][]map[string]interface{}{
+      "identities": {
+        {"name": "学生", "image": "local://drafts/1/identities/a.png", "sort": float64(1)},
+        {"name": "", "sort": "abc"},
+      },
+    },
+  }
+  errs := handlers.ValidateDraftBundleManifest(manifest)
+  if len(errs) != 2 {
+    t.Fatalf("expected 2 errors, got %#v", errs)
+  }
+  for _, item := range errs {
+    if item.ModuleKey != "identities" || item.Row != 2 {
+      t.Fatalf("unexpected error: %#v", item)
+    }
+  }
+
+  manifest.Modules["identities"] = manifest.Modules["identities"][:1]
+  if errs := handlers.ValidateDraftBundleManifest(manifest); len(errs) != 0 {
+    t.Fatalf("expected valid manifest, got %#v", errs)
+  }
+}
+
+func TestParseSpreadsheetRows(t *testing.T) {
+  rows := [][]string{
+    {"Name", "sort", "status", "备注"},
+    {"学生", "1", "1", "x"},
+    {"", "", "", ""},
+    {"", "2", "1"},
+    {"教师", "abc", "3"},
+    {"学生", "3", "0"},
+    {"医生", "", ""},
+  }
+  accepted, ignored, errs := handlers.ParseSpreadsheetRows("identities", rows)
+  if len(accepted) != 2 || accepted[0].Row != 2 || accepted[1].Row != 7 || accepted[1].Values["sort"] != nil {
+    t.Fatalf("unexpected accepted rows: %#v", accepted)
+  }
+  if len(ignored) != 1 || ignored[0] != "备注" {
+    t.Fatalf("unexpected ignored columns: %#v", ignored)
+  }
+  expected := map[int]int{4: 1, 5: 2, 6: 1}
+  counts := map[int]int{}
+  for _, item := range errs {
+    counts[item.Row]++
+  }
+  if !reflect.DeepEqual(counts, expected) {
+    t.Fatalf("unexpected errors: %#v", errs)
+  }
+
+  if _, _, errs := handlers.ParseSpreadsheetRows("banners", rows); len(errs) != 1 {
+    t.Fatalf("expected unsupported module error, got %#v", errs)
+  }
+}
+
+func TestDraftTrashCutoff(t *testing.T) {
+  now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+  cutoff, ok := handlers.DraftTrashCutoff(now, 30)
+  if !ok || !cutoff.Equal(time.Date(2024, 2, 9, 8, 0, 0, 0, time.UTC)) {
+    t.Fatalf("unexpected cutoff: %v %v", cutoff, ok)
+  }
+  if _, ok := handlers.DraftTrashCutoff(now, 0); ok {
+    t.Fatalf("expected retention 0 to disable purge")
+  }
+}
+
+func TestDraftRowTrashed(t *testing.T) {
+  trashed := map[string]interface{}{"id": int64(3), "deleted_at": time.Now()}
+  if !handlers.DraftRowTrashed("app_db_scenes", trashed) {
+    t.Fatalf("expected trashed scene to be reported")
+  }
+  if handlers.DraftRowTrashed("app_db_scenes", map[string]interface{}{"id": int64(3), "deleted_at": nil}) {
+    t.Fatalf("expected live scene to be editable")
+  }
+  if handlers.DraftRowTrashed("app_db_app_ui_fields", trashed) {
+    t.Fatalf("tables without trash must not be reported")
+  }
+  if handlers.DraftRowTrashed("app_db_scenes", nil) {
+    t.Fatalf("missing rows must not be reported")
+  }
+}
+
+func TestValidateReorderIDs(t *testing.T) {
+  issues := handlers.ValidateReorderIDs([]int64{1, 2, 3}, []int64{3, 1, 2})
+  if !issues.Empty() {
+    t.Fatalf("expected valid order, got %+v", issues)
+  }
+
+  issues = handlers.ValidateReorderIDs([]int64{1, 2, 3}, []int64{3, 3, 9})
+  if !reflect.DeepEqual(issues.Missing, []int64{1, 2}) {
+    t.Fatalf("unexpected missing: %v", issues.Missing)
+  }
+  if !reflect.DeepEqual(issues.Foreign, []int64{9}) {
+    t.Fatalf("unexpected foreign: %v", issues.Foreign)
+  }
+  if !reflect.DeepEqual(issues.Duplicate, []int64{3}) {
+    t.Fatalf("unexpected duplicate: %v", issues.Duplicate)
+  }
+}
+
+func TestBuildReorderSort(t *testing.T) {
+  sorts := handlers.BuildReorderSort([]int64{7, 4, 9})
+  expected := map[int64]int{7: 3, 4: 2, 9: 1}
+  if !reflect.DeepEqual(sorts, expected) {
+    t.Fatalf("unexpected sorts: %v", sorts)
+  }
+}
+
+// TestBuildRowChangeDiff verifies bookkeeping columns are ignored and empty values skipped on create.
+func TestBuildRowChangeDiff(t *testing.T) {
+  before := map[string]interface{}{
+    "id":          int64(1),
+    "title":       "old",
+    "sort":        int64(2),
+    "row_version": int64(3),
+    "updated_at":  "2024-05-01T10:00:00+08:00",
+  }
+  after := map[string]interface{}{
+    "id":          int64(1),
+    "title":       "new",
+    "sort":        int64(2),
+    "row_version": int64(4),
+    "updated_at":  "2024-05-01T11:00:00+08:00",
+  }
+
+  diff := handlers.BuildRowChangeDiff(before, after)
+  if len(diff) != 1 || diff[0].Field != "title" || diff[0].Old != "old" || diff[0].New != "new" {
+    t.Fatalf("unexpected update diff: %#v", diff)
+  }
+
+  created := handlers.BuildRowChangeDiff(nil, map[string]interface{}{
+    "id":    int64(2),
+    "title": "x",
+    "image": nil,
+  })
+  if len(created) != 1 || created[0].Field != "title" || created[0].Old != nil {
+    t.Fatalf("unexpected create diff: %#v", created)
+  }
+
+  removed := handlers.BuildRowChangeDiff(before, nil)
+  if len(removed) != 2 || removed[0].Field != "sort" || removed[1].Field != "title" {
+    t.Fatalf("unexpected delete diff: %#v", removed)
+  }
+}
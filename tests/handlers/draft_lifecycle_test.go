@@ -0,0 +1,44 @@
+package handlers_test
+
+import (
+  "errors"
+  "testing"
+
+  "shushu-app-ui-dashboard/internal/http/handlers"
+)
+
+func TestNormalizeDraftStatus(t *testing.T) {
+  cases := map[string]string{
+    "":                "draft",
+    "submitted":       "in_review",
+    "pending_confirm": "in_review",
+    "confirmed":       "approved",
+    "Published":       "published",
+  }
+  for raw, expected := range cases {
+    if got := handlers.NormalizeDraftStatus(raw); got != expected {
+      t.Fatalf("expected %s for %q, got %s", expected, raw, got)
+    }
+  }
+  if handlers.DraftStatusEditable("syncing") || !handlers.DraftStatusEditable("in_review") {
+    t.Fatalf("unexpected editable states")
+  }
+}
+
+func TestCheckDraftTransition(t *testing.T) {
+  if action, err := handlers.CheckDraftTransition("draft", "in_review", "user"); err != nil || action != "submit_review" {
+    t.Fatalf("expected submit_review, got %s, err=%v", action, err)
+  }
+  if _, err := handlers.CheckDraftTransition("in_review", "approved", "user"); !errors.Is(err, handlers.ErrDraftTransitionForbidden) {
+    t.Fatalf("expected forbidden for non-admin approve, got %v", err)
+  }
+  if _, err := handlers.CheckDraftTransition("in_review", "approved", "admin"); err != nil {
+    t.Fatalf("expected admin approve, got %v", err)
+  }
+  if _, err := handlers.CheckDraftTransition("draft", "published", "admin"); !errors.Is(err, handlers.ErrDraftTransitionInvalid) {
+    t.Fatalf("expected publish from draft to be invalid, got %v", err)
+  }
+  if _, err := handlers.CheckDraftTransition("approved", "syncing", "admin"); !errors.Is(err, handlers.ErrDraftTransitionInvalid) {
+    t.Fatalf("expected syncing to be reachable only through sync, got %v", err)
+  }
+}
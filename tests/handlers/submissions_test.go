@@ -1,8 +1,10 @@
 package handlers_test
 
 import (
+  "errors"
   "reflect"
   "testing"
+  "time"
 
   "shushu-app-ui-dashboard/internal/http/handlers"
 )
@@ -44,3 +46,76 @@ func TestSubmissionStatus(t *testing.T) {
     t.Fatalf("expected submitted, got %s", got)
   }
 }
+
+func TestBuildSubmissionRevertPayload(t *testing.T) {
+  payload := map[string]interface{}{
+    "draft_version_id": 3.0,
+    "name":             "旧名称",
+    "sort":             2.0,
+    "created_by":       1.0,
+    "unknown":          "x",
+  }
+  restored, err := handlers.BuildSubmissionRevertPayload("app_db_scenes", payload)
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  expected := map[string]interface{}{"name": "旧名称", "sort": 2.0}
+  if !reflect.DeepEqual(restored, expected) {
+    t.Fatalf("unexpected payload: %#v", restored)
+  }
+  if _, err := handlers.BuildSubmissionRevertPayload("app_db_users", payload); err == nil {
+    t.Fatalf("expected unknown table to fail")
+  }
+  if _, err := handlers.BuildSubmissionRevertPayload("app_db_scenes", map[string]interface{}{"draft_version_id": 3.0}); err == nil {
+    t.Fatalf("expected empty payload to fail")
+  }
+}
+
+func TestBuildSubmissionRestorePlan(t *testing.T) {
+  payload := `{"id": 7, "draft_version_id": 3, "name": "旧名称", "sort": 2, "updated_by": 1, "unknown": "x"}`
+  current := map[string]interface{}{
+    "id":               int64(7),
+    "draft_version_id": int64(3),
+    "name":             "新名称",
+    "sort":             int64(2),
+    "updated_by":       int64(4),
+    "deleted_at":       nil,
+  }
+
+  plan, err := handlers.BuildSubmissionRestorePlan("app_db_scenes", "confirmed", payload, current)
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if !reflect.DeepEqual(plan.Restored, map[string]interface{}{"name": "旧名称", "sort": 2.0}) {
+    t.Fatalf("expected only writable columns, got %#v", plan.Restored)
+  }
+  if !reflect.DeepEqual(plan.Base, map[string]interface{}{"name": "新名称", "sort": int64(2)}) {
+    t.Fatalf("unexpected base: %#v", plan.Base)
+  }
+  if len(plan.Changes) != 1 || plan.Changes[0].Field != "name" || plan.Changes[0].Old != "新名称" || plan.Changes[0].New != "旧名称" {
+    t.Fatalf("unexpected changes: %#v", plan.Changes)
+  }
+  if plan.Payload["unknown"] != "x" {
+    t.Fatalf("submission payload should be kept whole: %#v", plan.Payload)
+  }
+
+  current["name"] = "旧名称"
+  unchanged, err := handlers.BuildSubmissionRestorePlan("app_db_scenes", "submitted", payload, current)
+  if err != nil || len(unchanged.Changes) != 0 {
+    t.Fatalf("expected unchanged plan, got %#v (%v)", unchanged.Changes, err)
+  }
+
+  if _, err := handlers.BuildSubmissionRestorePlan("app_db_scenes", "rejected", payload, current); !errors.Is(err, handlers.ErrSubmissionRejected) {
+    t.Fatalf("expected ErrSubmissionRejected, got %v", err)
+  }
+  if _, err := handlers.BuildSubmissionRestorePlan("app_db_scenes", "confirmed", "{", current); !errors.Is(err, handlers.ErrSubmissionPayloadInvalid) {
+    t.Fatalf("expected ErrSubmissionPayloadInvalid, got %v", err)
+  }
+  if _, err := handlers.BuildSubmissionRestorePlan("app_db_users", "confirmed", payload, current); err == nil {
+    t.Fatalf("expected unknown table to fail")
+  }
+  current["deleted_at"] = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+  if _, err := handlers.BuildSubmissionRestorePlan("app_db_scenes", "confirmed", payload, current); !errors.Is(err, handlers.ErrSubmissionEntityTrashed) {
+    t.Fatalf("expected ErrSubmissionEntityTrashed, got %v", err)
+  }
+}
@@ -36,3 +36,16 @@ func TestBuildDefaultFeishuFields(t *testing.T) {
     t.Fatalf("expected SD模式 appended")
   }
 }
+
+func TestBuildCloneVersionName(t *testing.T) {
+  if got := handlers.BuildCloneVersionName("bowuguan", nil); got != "BOWUGUAN_COPY" {
+    t.Fatalf("expected BOWUGUAN_COPY, got %s", got)
+  }
+  existing := []string{"BOWUGUAN", "BOWUGUAN_COPY", "bowuguan_copy2"}
+  if got := handlers.BuildCloneVersionName("BOWUGUAN", existing); got != "BOWUGUAN_COPY3" {
+    t.Fatalf("expected BOWUGUAN_COPY3, got %s", got)
+  }
+  if got := handlers.BuildCloneVersionName("", nil); got != "DRAFT_COPY" {
+    t.Fatalf("expected DRAFT_COPY, got %s", got)
+  }
+}
@@ -0,0 +1,112 @@
+package services_test
+
+import (
+  "archive/zip"
+  "bytes"
+  "fmt"
+  "reflect"
+  "strings"
+  "testing"
+
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+func TestXLSXRoundTrip(t *testing.T) {
+  rows := [][]string{
+    {"name", "sort", "desc"},
+    {"学生", "1", "a < b & \"c\""},
+    {"教师", "", "line1\nline2"},
+  }
+  var buffer bytes.Buffer
+  if err := services.WriteXLSX(&buffer, "identities", rows); err != nil {
+    t.Fatalf("write failed: %v", err)
+  }
+  got, err := services.ReadXLSX(bytes.NewReader(buffer.Bytes()), int64(buffer.Len()))
+  if err != nil {
+    t.Fatalf("read failed: %v", err)
+  }
+  if !reflect.DeepEqual(got, rows) {
+    t.Fatalf("unexpected rows: %#v", got)
+  }
+}
+
+func TestCSVRoundTripStripsBOM(t *testing.T) {
+  rows := [][]string{{"name", "sort"}, {"学生", "1"}}
+  var buffer bytes.Buffer
+  if err := services.WriteCSV(&buffer, rows); err != nil {
+    t.Fatalf("write failed: %v", err)
+  }
+  got, err := services.ReadCSV(&buffer)
+  if err != nil {
+    t.Fatalf("read failed: %v", err)
+  }
+  if !reflect.DeepEqual(got, rows) {
+    t.Fatalf("unexpected rows: %#v", got)
+  }
+}
+
+func TestSpreadsheetColumnName(t *testing.T) {
+  cases := map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"}
+  for index, expected := range cases {
+    if got := services.SpreadsheetColumnName(index); got != expected {
+      t.Fatalf("index %d: expected %s, got %s", index, expected, got)
+    }
+  }
+}
+
+func buildTestXLSX(t *testing.T, sheetData string) *bytes.Reader {
+  t.Helper()
+  var buffer bytes.Buffer
+  archive := zip.NewWriter(&buffer)
+  parts := map[string]string{
+    "xl/workbook.xml":          `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="s" r:id="rId1"/></sheets></workbook>`,
+    "xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + sheetData + `</sheetData></worksheet>`,
+  }
+  for name, content := range parts {
+    writer, err := archive.Create(name)
+    if err != nil {
+      t.Fatalf("zip failed: %v", err)
+    }
+    if _, err := writer.Write([]byte(content)); err != nil {
+      t.Fatalf("zip failed: %v", err)
+    }
+  }
+  if err := archive.Close(); err != nil {
+    t.Fatalf("zip failed: %v", err)
+  }
+  return bytes.NewReader(buffer.Bytes())
+}
+
+func TestReadXLSXRejectsOutOfBoundsCells(t *testing.T) {
+  for name, sheetData := range map[string]string{
+    "overflowing column":  `<row r="1"><c r="ZZZZZZZZZZZZZZ1" t="inlineStr"><is><t>x</t></is></c></row>`,
+    "column past XFD":     `<row r="1"><c r="XFE1" t="inlineStr"><is><t>x</t></is></c></row>`,
+    "row past sheet":      `<row r="1048577"><c r="A1048577" t="inlineStr"><is><t>x</t></is></c></row>`,
+    "row past import cap": fmt.Sprintf(`<row r="%d"><c r="A1" t="inlineStr"><is><t>x</t></is></c></row>`, services.MaxSpreadsheetImportRows+1),
+  } {
+    reader := buildTestXLSX(t, sheetData)
+    if _, err := services.ReadXLSX(reader, reader.Size()); err == nil {
+      t.Fatalf("expected %s to be rejected", name)
+    }
+  }
+
+  reader := buildTestXLSX(t, `<row r="1"><c r="C1" t="inlineStr"><is><t>x</t></is></c></row><row r="3"><c r="XFD3" t="inlineStr"><is><t>y</t></is></c></row>`)
+  rows, err := services.ReadXLSX(reader, reader.Size())
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if len(rows) != 3 || !reflect.DeepEqual(rows[0], []string{"", "", "x"}) || len(rows[1]) != 0 || len(rows[2]) != 16384 || rows[2][16383] != "y" {
+    t.Fatalf("unexpected rows: %d", len(rows))
+  }
+}
+
+func TestReadCSVCapsRowCount(t *testing.T) {
+  content := strings.Repeat("a,b\n", services.MaxSpreadsheetImportRows)
+  rows, err := services.ReadCSV(strings.NewReader(content))
+  if err != nil || len(rows) != services.MaxSpreadsheetImportRows {
+    t.Fatalf("expected %d rows, got %d (%v)", services.MaxSpreadsheetImportRows, len(rows), err)
+  }
+  if _, err := services.ReadCSV(strings.NewReader(content + "a,b\n")); err == nil {
+    t.Fatalf("expected too many rows to be rejected")
+  }
+}
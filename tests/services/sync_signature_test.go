@@ -0,0 +1,130 @@
+package services_test
+
+import (
+  "errors"
+  "net/http"
+  "strings"
+  "testing"
+  "time"
+
+  "shushu-app-ui-dashboard/internal/config"
+  "shushu-app-ui-dashboard/internal/services"
+)
+
+func TestParseSyncKeys(t *testing.T) {
+  keys, err := services.ParseSyncKeys("k1:secret1, k2:secret2:2026-01-02T03:04:05Z,k3:secret3:1700000000")
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if len(keys) != 3 || keys[0].ID != "k1" || keys[1].Secret != "secret2" {
+    t.Fatalf("unexpected keys: %#v", keys)
+  }
+  if !keys[1].ExpiresAt.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+    t.Fatalf("unexpected rfc3339 expiry: %v", keys[1].ExpiresAt)
+  }
+  if keys[2].ExpiresAt.Unix() != 1700000000 {
+    t.Fatalf("unexpected unix expiry: %v", keys[2].ExpiresAt)
+  }
+
+  if _, err := services.ParseSyncKeys("k1:a,k1:b"); err == nil {
+    t.Fatalf("expected duplicate key error")
+  }
+  if _, err := services.ParseSyncKeys("missing-secret"); err == nil {
+    t.Fatalf("expected malformed entry error")
+  }
+}
+
+func TestSyncSignerSignVerify(t *testing.T) {
+  signer, err := services.NewSyncSigner(&config.Config{SyncKeys: "k1:secret1", SyncSignatureSkewSeconds: 60})
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  now := time.Unix(1800000000, 0)
+  body := []byte(`{"modules":["banners"]}`)
+  req, _ := http.NewRequest(http.MethodPost, "http://online.local/api/sync/push?x=1", nil)
+  if err := signer.Sign(req, body, now); err != nil {
+    t.Fatalf("sign failed: %v", err)
+  }
+
+  info, err := signer.Verify(http.MethodPost, "/api/sync/push?x=1", req.Header, body, now.Add(30*time.Second))
+  if err != nil {
+    t.Fatalf("verify failed: %v", err)
+  }
+  if info.KeyID != "k1" || info.Nonce == "" {
+    t.Fatalf("unexpected info: %#v", info)
+  }
+
+  if _, err := signer.Verify(http.MethodPost, "/api/sync/push?x=1", req.Header, []byte(`{}`), now); !errors.Is(err, services.ErrSyncSignatureInvalid) {
+    t.Fatalf("expected invalid signature for tampered body, got %v", err)
+  }
+  if _, err := signer.Verify(http.MethodPost, "/api/sync/push?x=2", req.Header, body, now); !errors.Is(err, services.ErrSyncSignatureInvalid) {
+    t.Fatalf("expected invalid signature for tampered path, got %v", err)
+  }
+  if _, err := signer.Verify(http.MethodPost, "/api/sync/push?x=1", req.Header, body, now.Add(2*time.Minute)); !errors.Is(err, services.ErrSyncSignatureExpired) {
+    t.Fatalf("expected expired signature, got %v", err)
+  }
+}
+
+func TestSyncSignerKeyRotation(t *testing.T) {
+  expiry := time.Unix(1800000600, 0).UTC().Format(time.RFC3339)
+  oldSigner, err := services.NewSyncSigner(&config.Config{SyncKeys: "old:secret-old"})
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  verifier, err := services.NewSyncSigner(&config.Config{
+    SyncKeys:  "new:secret-new,old:secret-old:" + expiry,
+    SyncKeyID: "new",
+  })
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+
+  now := time.Unix(1800000000, 0)
+  req, _ := http.NewRequest(http.MethodGet, "http://online.local/api/sync/versions", nil)
+  if err := oldSigner.Sign(req, nil, now); err != nil {
+    t.Fatalf("sign failed: %v", err)
+  }
+  if _, err := verifier.Verify(http.MethodGet, "/api/sync/versions", req.Header, nil, now); err != nil {
+    t.Fatalf("old key should be valid during grace period: %v", err)
+  }
+
+  later := time.Unix(1800000700, 0)
+  req, _ = http.NewRequest(http.MethodGet, "http://online.local/api/sync/versions", nil)
+  if err := oldSigner.Sign(req, nil, later); err != nil {
+    t.Fatalf("sign failed: %v", err)
+  }
+  if _, err := verifier.Verify(http.MethodGet, "/api/sync/versions", req.Header, nil, later); !errors.Is(err, services.ErrSyncKeyUnknown) {
+    t.Fatalf("expected old key rejected after expiry, got %v", err)
+  }
+
+  req, _ = http.NewRequest(http.MethodGet, "http://online.local/api/sync/versions", nil)
+  if err := verifier.Sign(req, nil, later); err != nil {
+    t.Fatalf("sign failed: %v", err)
+  }
+  if got := req.Header.Get(services.SyncKeyIDHeader); got != "new" {
+    t.Fatalf("expected active key new, got %q", got)
+  }
+  if sig := req.Header.Get(services.SyncSignatureHeader); len(sig) != 64 || strings.ToLower(sig) != sig {
+    t.Fatalf("unexpected signature format: %q", sig)
+  }
+}
+
+func TestNewSyncSignerFallsBackToAPIKey(t *testing.T) {
+  if _, err := services.NewSyncSigner(&config.Config{}); !errors.Is(err, services.ErrSyncKeysNotConfigured) {
+    t.Fatalf("expected not configured error, got %v", err)
+  }
+  signer, err := services.NewSyncSigner(&config.Config{SyncAPIKey: "legacy"})
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  req, _ := http.NewRequest(http.MethodGet, "http://online.local/api/sync/versions", nil)
+  if err := signer.Sign(req, nil, time.Now()); err != nil {
+    t.Fatalf("sign failed: %v", err)
+  }
+  if req.Header.Get(services.SyncKeyIDHeader) != "default" {
+    t.Fatalf("expected default key id")
+  }
+  if _, err := services.NewSyncSigner(&config.Config{SyncKeys: "a:b", SyncKeyID: "missing"}); err == nil {
+    t.Fatalf("expected missing active key error")
+  }
+}
@@ -0,0 +1,115 @@
+package store_test
+
+import (
+  "reflect"
+  "strings"
+  "testing"
+  "testing/fstest"
+  "time"
+
+  "shushu-app-ui-dashboard/internal/store"
+  "shushu-app-ui-dashboard/migrations"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+  script := "-- header; ignored\r\n" +
+    "CREATE TABLE `a;b` (`id` int);\n" +
+    "INSERT INTO t (v) VALUES ('x;y', 'it''s', \"q;\\\"r\"); # trailing; comment\n" +
+    "/* block; comment */ SET @sql := 'SELECT 1';\n" +
+    "SELECT 2 -- tail\n" +
+    ";;\n"
+  expected := []string{
+    "CREATE TABLE `a;b` (`id` int)",
+    "INSERT INTO t (v) VALUES ('x;y', 'it''s', \"q;\\\"r\")",
+    "SET @sql := 'SELECT 1'",
+    "SELECT 2",
+  }
+  if got := store.SplitSQLStatements(script); !reflect.DeepEqual(got, expected) {
+    t.Fatalf("unexpected statements: %#v", got)
+  }
+
+  if got := store.SplitSQLStatements("SELECT 1--2;"); !reflect.DeepEqual(got, []string{"SELECT 1--2"}) {
+    t.Fatalf("double dash without space is not a comment: %#v", got)
+  }
+  if got := store.SplitSQLStatements("-- only a comment\n"); len(got) != 0 {
+    t.Fatalf("expected no statements, got %#v", got)
+  }
+}
+
+func TestLoadMigrations(t *testing.T) {
+  files := fstest.MapFS{
+    "010_second.sql": {Data: []byte("SELECT 2;")},
+    "002_first.sql":  {Data: []byte("SELECT 1;\r\n")},
+    "README.md":      {Data: []byte("ignored")},
+  }
+  loaded, err := store.LoadMigrations(files)
+  if err != nil {
+    t.Fatalf("unexpected error: %v", err)
+  }
+  if len(loaded) != 2 || loaded[0].Version != 2 || loaded[0].Name != "first" || loaded[1].Version != 10 {
+    t.Fatalf("unexpected migrations: %#v", loaded)
+  }
+  if loaded[0].SQL != "SELECT 1;\n" || len(loaded[0].Checksum) != 64 {
+    t.Fatalf("unexpected content or checksum: %#v", loaded[0])
+  }
+
+  unix, err := store.LoadMigrations(fstest.MapFS{"002_first.sql": {Data: []byte("SELECT 1;\n")}})
+  if err != nil || unix[0].Checksum != loaded[0].Checksum {
+    t.Fatalf("checksum should ignore line endings")
+  }
+
+  if _, err := store.LoadMigrations(fstest.MapFS{
+    "005_a.sql": {Data: []byte("SELECT 1;")},
+    "005_b.sql": {Data: []byte("SELECT 2;")},
+  }); err == nil || !strings.Contains(err.Error(), "005_a.sql") {
+    t.Fatalf("expected duplicate version error, got %v", err)
+  }
+  if _, err := store.LoadMigrations(fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}}); err == nil {
+    t.Fatalf("expected invalid name error")
+  }
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+  internal, err := store.LoadMigrations(migrations.Internal())
+  if err != nil {
+    t.Fatalf("internal migrations: %v", err)
+  }
+  if len(internal) == 0 || internal[0].Version != 1 {
+    t.Fatalf("unexpected internal migrations: %d", len(internal))
+  }
+  for _, migration := range internal {
+    if len(store.SplitSQLStatements(migration.SQL)) == 0 {
+      t.Fatalf("migration %03d_%s has no statements", migration.Version, migration.Name)
+    }
+  }
+  if _, err := store.LoadMigrations(migrations.Online()); err != nil {
+    t.Fatalf("online migrations: %v", err)
+  }
+}
+
+func TestCompareMigrations(t *testing.T) {
+  appliedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+  statuses := store.CompareMigrations(
+    []store.Migration{
+      {Version: 1, Name: "init", Checksum: "a"},
+      {Version: 2, Name: "users", Checksum: "b"},
+      {Version: 3, Name: "audit", Checksum: "c"},
+    },
+    map[int]store.AppliedMigration{
+      1: {Version: 1, Name: "init", Checksum: "a", AppliedAt: appliedAt},
+      2: {Version: 2, Name: "users", Checksum: "old", AppliedAt: appliedAt},
+      9: {Version: 9, Name: "dropped", Checksum: "z", AppliedAt: appliedAt},
+    },
+  )
+  states := make([]string, 0, len(statuses))
+  for _, status := range statuses {
+    states = append(states, status.State)
+  }
+  expected := []string{store.MigrationApplied, store.MigrationChecksumMismatch, store.MigrationPending, store.MigrationMissing}
+  if !reflect.DeepEqual(states, expected) {
+    t.Fatalf("unexpected states: %v", states)
+  }
+  if statuses[1].AppliedChecksum != "old" || statuses[2].AppliedAt != nil || statuses[3].Name != "dropped" {
+    t.Fatalf("unexpected statuses: %#v", statuses)
+  }
+}
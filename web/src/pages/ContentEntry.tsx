@@ -10,12 +10,14 @@ import PhotoPanel from "./content/PhotoPanel";
 import ScenePanel from "./content/ScenePanel";
 import { formatDate } from "./content/constants";
 import type { DraftVersion } from "./content/constants";
+import { describeRequestError, waitForSyncJob } from "./content/utils";
 import type { Notify, RequestFn, TTSPreset, TTSFn, UploadFn } from "./content/utils";
 
 const { Title, Text } = Typography;
 
 type SyncJob = {
   id: number;
+  job_id?: number | null;
   module_key?: string | null;
   status?: string | null;
   error_message?: string | null;
@@ -25,6 +27,9 @@ type SyncJob = {
   trigger_by?: number | null;
   trigger_name?: string | null;
   trigger_username?: string | null;
+  attempts?: number | null;
+  max_attempts?: number | null;
+  next_run_at?: string | null;
 };
 
 const ContentEntry = () => {
@@ -58,7 +63,7 @@ const ContentEntry = () => {
     const response = await fetch(path, { ...options, headers });
     const data = await response.json().catch(() => ({}));
     if (!response.ok) {
-      throw new Error((data as { error?: string }).error || "请求失败");
+      throw new Error(describeRequestError(response.status, data, "请求失败"));
     }
     return data;
   };
@@ -196,10 +201,16 @@ const ContentEntry = () => {
   ];
 
   const syncStatusMap: Record<string, { label: string; color: string }> = {
+    queued: { label: "排队中", color: "default" },
     running: { label: "同步中", color: "blue" },
+    retrying: { label: "重试中", color: "orange" },
+    succeeded: { label: "已同步", color: "green" },
     success: { label: "已同步", color: "green" },
     failed: { label: "失败", color: "red" },
-    pending_confirm: { label: "待确认", color: "gold" }
+    pending_confirm: { label: "待确认", color: "gold" },
+    unrecorded: { label: "已推送未记录", color: "volcano" },
+    rolled_back: { label: "已回滚", color: "purple" },
+    rolling_back: { label: "回滚中", color: "purple" }
   };
 
   const latestSyncByModule = useMemo(() => {
@@ -261,10 +272,26 @@ const ContentEntry = () => {
         return;
       }
       if (!response.ok) {
-        throw new Error((data as { error?: string }).error || "同步失败");
+        throw new Error(describeRequestError(response.status, data, "同步失败"));
       }
-      notify.success("同步完成");
       void loadSyncJobs(selectedVersion.id);
+      const job = await waitForSyncJob(token, (data as { job_id: number }).job_id);
+      void loadSyncJobs(selectedVersion.id);
+      if (job.status === "pending_confirm") {
+        setSyncing(false);
+        Modal.confirm({
+          title: "检测到线上已有版本",
+          content: "继续同步将覆盖线上同名景区的数据。",
+          okText: "继续同步",
+          cancelText: "取消",
+          onOk: () => handleSync(true)
+        });
+        return;
+      }
+      if (job.status !== "succeeded") {
+        throw new Error(job.error_message || "同步失败");
+      }
+      notify.success("同步完成");
     } catch (error) {
       notify.error(error instanceof Error ? error.message : "同步失败");
     } finally {
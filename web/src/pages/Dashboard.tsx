@@ -21,6 +21,9 @@ type DashboardSummary = {
   sync: {
     pending_versions: number;
     last_sync_at?: string | null;
+    drifted?: boolean;
+    drifted_modules?: string[];
+    drifted_versions?: number;
   };
 };
 
@@ -115,6 +118,8 @@ const Dashboard = () => {
   const todayPending = summary?.media.today_pending ?? 0;
   const pendingVersions = summary?.sync.pending_versions ?? 0;
   const lastSyncAt = summary?.sync.last_sync_at ?? null;
+  const driftedModules = summary?.sync.drifted_modules ?? [];
+  const driftedVersions = summary?.sync.drifted_versions ?? 0;
 
   return (
     <Space direction="vertical" size={24} style={{ width: "100%" }}>
@@ -168,6 +173,12 @@ const Dashboard = () => {
             <Space direction="vertical">
               <Text>待同步景区 {summaryLoading ? "..." : pendingVersions} 个</Text>
               <Text type="secondary">上次同步: {formatDate(lastSyncAt)}</Text>
+              <Text type={driftedVersions > 0 ? "danger" : "secondary"}>
+                线上已变更景区 {summaryLoading ? "..." : driftedVersions} 个
+              </Text>
+              {driftedModules.length > 0 ? (
+                <Text type="danger">当前版本线上变更模块: {driftedModules.join("、")}</Text>
+              ) : null}
               <Button onClick={() => navigate("/history")}>打开同步中心</Button>
             </Space>
           </Card>
@@ -31,6 +31,7 @@ type FieldHistoryItem = {
   field_name: string;
   old_value?: string | null;
   new_value?: string | null;
+  action?: string | null;
   submit_id?: number | null;
   changed_by?: number | null;
   changed_name?: string | null;
@@ -49,6 +50,25 @@ type AuditLogItem = {
   actor_username?: string | null;
   detail?: unknown;
   created_at?: string | null;
+  prev_hash?: string | null;
+  hash?: string | null;
+};
+
+type AuditVerifyResult = {
+  valid: boolean;
+  checked: number;
+  unchained: number;
+  head_id?: number | null;
+  broken?: { id: number; reason: string; expected?: string; actual?: string } | null;
+};
+
+const auditBreakLabels: Record<string, string> = {
+  missing_hash: "缺少哈希",
+  missing_row: "链首记录缺失",
+  prev_hash_mismatch: "前序哈希不匹配",
+  hash_mismatch: "内容哈希不匹配",
+  head_mismatch: "链尾记录缺失",
+  anchor_mismatch: "锚点不匹配"
 };
 
 type MediaVersionItem = {
@@ -80,7 +100,7 @@ const moduleOptions = [
 ];
 
 const History = () => {
-  const { token } = useAuth();
+  const { token, user } = useAuth();
   const [messageApi, contextHolder] = message.useMessage();
   const [versions, setVersions] = useState<DraftVersion[]>([]);
   const [versionLoading, setVersionLoading] = useState(false);
@@ -97,6 +117,14 @@ const History = () => {
   const [fieldLoading, setFieldLoading] = useState(false);
   const [auditLogs, setAuditLogs] = useState<AuditLogItem[]>([]);
   const [auditLoading, setAuditLoading] = useState(false);
+  const [verifyLoading, setVerifyLoading] = useState(false);
+  const [auditActor, setAuditActor] = useState<string>("");
+  const [auditText, setAuditText] = useState<string>("");
+  const [auditFrom, setAuditFrom] = useState<string>("");
+  const [auditTo, setAuditTo] = useState<string>("");
+  const [auditOrder, setAuditOrder] = useState<"desc" | "asc">("desc");
+  const [auditCursor, setAuditCursor] = useState<string | null>(null);
+  const [exportLoading, setExportLoading] = useState(false);
   const [mediaVersions, setMediaVersions] = useState<MediaVersionItem[]>([]);
   const [mediaLoading, setMediaLoading] = useState(false);
   const [diffOpen, setDiffOpen] = useState(false);
@@ -208,27 +236,49 @@ const History = () => {
     }
   };
 
-  const loadAuditLogs = async () => {
-    if (!selectedVersionId) {
-      messageApi.warning("请选择版本");
-      return;
+  const buildAuditParams = () => {
+    const params = new URLSearchParams({ order: auditOrder });
+    if (selectedVersionId) {
+      params.set("draft_version_id", String(selectedVersionId));
+    }
+    if (entityTable) {
+      params.set("entity_table", entityTable);
+    }
+    if (entityId.trim()) {
+      params.set("entity_id", entityId.trim());
+    }
+    if (actionFilter.trim()) {
+      params.set("action", actionFilter.trim());
+    }
+    if (auditActor.trim()) {
+      params.set("actor", auditActor.trim());
+    }
+    if (auditText.trim()) {
+      params.set("q", auditText.trim());
     }
+    if (auditFrom.trim()) {
+      params.set("from", auditFrom.trim());
+    }
+    if (auditTo.trim()) {
+      params.set("to", auditTo.trim());
+    }
+    return params;
+  };
+
+  const loadAuditLogs = async (cursor?: string) => {
     setAuditLoading(true);
     try {
-      const params = new URLSearchParams({
-        draft_version_id: String(selectedVersionId)
-      });
-      if (entityTable) {
-        params.set("entity_table", entityTable);
+      const params = buildAuditParams();
+      params.set("limit", "100");
+      if (cursor) {
+        params.set("cursor", cursor);
       }
-      if (entityId.trim()) {
-        params.set("entity_id", entityId.trim());
-      }
-      if (actionFilter.trim()) {
-        params.set("action", actionFilter.trim());
-      }
-      const res = await request<{ data: AuditLogItem[] }>(`/api/audit/logs?${params.toString()}`);
-      setAuditLogs(res.data || []);
+      const res = await request<{ data: AuditLogItem[]; next_cursor?: string | null }>(
+        `/api/audit/logs?${params.toString()}`
+      );
+      const items = res.data || [];
+      setAuditLogs((prev) => (cursor ? [...prev, ...items] : items));
+      setAuditCursor(res.next_cursor || null);
     } catch (error) {
       messageApi.error(error instanceof Error ? error.message : "获取审计日志失败");
     } finally {
@@ -236,6 +286,62 @@ const History = () => {
     }
   };
 
+  const exportAuditLogs = async (format: "csv" | "jsonl") => {
+    if (!token) {
+      messageApi.error("缺少登录凭证");
+      return;
+    }
+    setExportLoading(true);
+    try {
+      const params = buildAuditParams();
+      params.set("format", format);
+      const response = await fetch(`/api/audit/export?${params.toString()}`, {
+        headers: { Authorization: `Bearer ${token}` }
+      });
+      if (!response.ok) {
+        const data = await response.json().catch(() => ({}));
+        throw new Error(data?.error || "导出失败");
+      }
+      const disposition = response.headers.get("Content-Disposition") || "";
+      const matched = disposition.match(/filename="?([^"]+)"?/);
+      const url = URL.createObjectURL(await response.blob());
+      const link = document.createElement("a");
+      link.href = url;
+      link.download = matched ? matched[1] : `audit_logs.${format}`;
+      link.click();
+      URL.revokeObjectURL(url);
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "导出审计日志失败");
+    } finally {
+      setExportLoading(false);
+    }
+  };
+
+  const verifyAuditChain = async () => {
+    setVerifyLoading(true);
+    try {
+      const res = await request<AuditVerifyResult>("/api/audit/verify");
+      if (res.valid) {
+        Modal.success({
+          title: "审计链完整",
+          content: `已校验 ${res.checked} 条记录${res.unchained ? `，另有 ${res.unchained} 条历史记录未入链` : ""}`
+        });
+        return;
+      }
+      const broken = res.broken;
+      Modal.error({
+        title: "审计链已被破坏",
+        content: broken
+          ? `记录 #${broken.id}：${auditBreakLabels[broken.reason] || broken.reason}（已校验 ${res.checked} 条）`
+          : "校验失败"
+      });
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "校验审计链失败");
+    } finally {
+      setVerifyLoading(false);
+    }
+  };
+
   const loadMediaVersions = async () => {
     if (!selectedVersionId) {
       messageApi.warning("请选择版本");
@@ -349,6 +455,7 @@ const History = () => {
       key: "new_value",
       render: (value: string) => <Text type="secondary">{value || "-"}</Text>
     },
+    { title: "动作", dataIndex: "action", key: "action", render: (value: string) => value || "-" },
     { title: "提交ID", dataIndex: "submit_id", key: "submit_id" },
     {
       title: "操作者",
@@ -392,6 +499,16 @@ const History = () => {
         </Text>
       )
     },
+    {
+      title: "哈希",
+      dataIndex: "hash",
+      key: "hash",
+      render: (value: string) => (
+        <Text type="secondary" copyable={value ? { text: value } : false}>
+          {value ? value.slice(0, 12) : "未入链"}
+        </Text>
+      )
+    },
     {
       title: "时间",
       dataIndex: "created_at",
@@ -547,15 +664,63 @@ const History = () => {
                 <Card style={{ borderRadius: 20 }}>
                   <Space direction="vertical" size={12} style={{ width: "100%" }}>
                     {baseFilterControls}
-                    <Input
-                      style={{ width: 200 }}
-                      placeholder="动作过滤(可选)"
-                      value={actionFilter}
-                      onChange={(event) => setActionFilter(event.target.value)}
-                    />
-                    <Button onClick={loadAuditLogs} loading={auditLoading}>
-                      查询审计日志
-                    </Button>
+                    <Space wrap>
+                      <Input
+                        style={{ width: 200 }}
+                        placeholder="动作过滤，逗号分隔(可选)"
+                        value={actionFilter}
+                        onChange={(event) => setActionFilter(event.target.value)}
+                      />
+                      <Input
+                        style={{ width: 160 }}
+                        placeholder="操作人(可选)"
+                        value={auditActor}
+                        onChange={(event) => setAuditActor(event.target.value)}
+                      />
+                      <Input
+                        style={{ width: 200 }}
+                        placeholder="详情关键字(可选)"
+                        value={auditText}
+                        onChange={(event) => setAuditText(event.target.value)}
+                      />
+                      <Input
+                        style={{ width: 180 }}
+                        placeholder="开始 YYYY-MM-DD"
+                        value={auditFrom}
+                        onChange={(event) => setAuditFrom(event.target.value)}
+                      />
+                      <Input
+                        style={{ width: 180 }}
+                        placeholder="结束 YYYY-MM-DD"
+                        value={auditTo}
+                        onChange={(event) => setAuditTo(event.target.value)}
+                      />
+                      <Select
+                        style={{ width: 120 }}
+                        value={auditOrder}
+                        onChange={(value) => setAuditOrder(value)}
+                        options={[
+                          { value: "desc", label: "最新优先" },
+                          { value: "asc", label: "最早优先" }
+                        ]}
+                      />
+                    </Space>
+                    <Space wrap>
+                      <Button onClick={() => loadAuditLogs()} loading={auditLoading}>
+                        查询审计日志
+                      </Button>
+                      <Button onClick={() => exportAuditLogs("csv")} loading={exportLoading}>
+                        导出 CSV
+                      </Button>
+                      <Button onClick={() => exportAuditLogs("jsonl")} loading={exportLoading}>
+                        导出 JSONL
+                      </Button>
+                      {user?.role === "admin" ? (
+                        <Button onClick={verifyAuditChain} loading={verifyLoading}>
+                          校验审计链
+                        </Button>
+                      ) : null}
+                    </Space>
                   </Space>
                 </Card>
                 <Card style={{ borderRadius: 20 }}>
@@ -566,6 +731,11 @@ const History = () => {
                     loading={auditLoading}
                     pagination={{ pageSize: 8 }}
                   />
+                  {auditCursor ? (
+                    <Button style={{ marginTop: 12 }} onClick={() => loadAuditLogs(auditCursor)} loading={auditLoading}>
+                      加载更多
+                    </Button>
+                  ) : null}
                 </Card>
               </Space>
             )
@@ -26,7 +26,9 @@ type UserFormValues = {
 
 const roleOptions = [
   { value: "admin", label: "管理员" },
-  { value: "user", label: "成员" }
+  { value: "user", label: "成员" },
+  { value: "ops_lead", label: "运营负责人" },
+  { value: "content_lead", label: "内容负责人" }
 ];
 
 const statusOptions = [
@@ -155,7 +157,10 @@ const Users = () => {
         title: "角色",
         dataIndex: "role",
         key: "role",
-        render: (value: string) => (value === "admin" ? <Tag color="gold">管理员</Tag> : <Tag>成员</Tag>)
+        render: (value: string) => {
+          const label = roleOptions.find((item) => item.value === value)?.label || "成员";
+          return value === "admin" ? <Tag color="gold">{label}</Tag> : <Tag>{label}</Tag>;
+        }
       },
       {
         title: "状态",
@@ -3,7 +3,18 @@ import { Button, Card, Empty, Input, Modal, Select, Space, Table, Tag, Typograph
 import { CloudDownloadOutlined, DeleteOutlined, EditOutlined, PlusOutlined, ReloadOutlined } from "@ant-design/icons";
 import { useAuth } from "../contexts/AuthContext";
 import VersionEditorModal, { VersionEditorValues } from "./version/VersionEditorModal";
-import { DraftVersion, formatDate, OnlineVersion } from "./version/constants";
+import { describeRequestError, waitForSyncJob, withRevision } from "./content/utils";
+import {
+  ApprovalSummary,
+  approvalRoleLabels,
+  DraftTransition,
+  DraftVersion,
+  draftStatusLabels,
+  draftTransitionLabels,
+  formatDate,
+  OnlineVersion,
+  SyncTarget
+} from "./version/constants";
 
 const { Title, Text } = Typography;
 const { Search } = Input;
@@ -18,12 +29,19 @@ const VersionManager = () => {
   const [editorSubmitting, setEditorSubmitting] = useState(false);
   const [editingVersion, setEditingVersion] = useState<DraftVersion | null>(null);
   const [syncingId, setSyncingId] = useState<number | null>(null);
+  const [transitioningId, setTransitioningId] = useState<number | null>(null);
+  const [approvalVersion, setApprovalVersion] = useState<DraftVersion | null>(null);
+  const [approvalSummary, setApprovalSummary] = useState<ApprovalSummary | null>(null);
+  const [approvalReason, setApprovalReason] = useState("");
+  const [approvalSubmitting, setApprovalSubmitting] = useState(false);
   const [importOpen, setImportOpen] = useState(false);
   const [importing, setImporting] = useState(false);
   const [onlineLoading, setOnlineLoading] = useState(false);
   const [onlineVersions, setOnlineVersions] = useState<OnlineVersion[]>([]);
   const [selectedOnlineId, setSelectedOnlineId] = useState<number | null>(null);
   const [selectedDraftId, setSelectedDraftId] = useState<number | null>(null);
+  const [syncTargets, setSyncTargets] = useState<SyncTarget[]>([]);
+  const [syncTargetId, setSyncTargetId] = useState<number>(0);
 
   const request = async <T,>(path: string, options: RequestInit = {}): Promise<T> => {
     if (!token) {
@@ -37,7 +55,7 @@ const VersionManager = () => {
     const response = await fetch(path, { ...options, headers });
     const data = await response.json().catch(() => ({}));
     if (!response.ok) {
-      throw new Error((data as { error?: string }).error || "请求失败");
+      throw new Error(describeRequestError(response.status, data, "请求失败"));
     }
     return data as T;
   };
@@ -54,8 +72,22 @@ const VersionManager = () => {
     }
   };
 
+  const loadSyncTargets = async () => {
+    try {
+      const res = await request<{ data: SyncTarget[] }>("/api/sync/targets");
+      const enabled = (res.data || []).filter((item) => item.is_enabled !== 0);
+      setSyncTargets(enabled);
+      if (enabled.length > 0 && !enabled.some((item) => item.id === syncTargetId)) {
+        setSyncTargetId(enabled[0].id);
+      }
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "获取同步目标失败");
+    }
+  };
+
   useEffect(() => {
     void loadVersions();
+    void loadSyncTargets();
   }, []);
 
   const filteredVersions = useMemo(() => {
@@ -89,10 +121,10 @@ const VersionManager = () => {
         payload.feishu_field_names = values.feishu_field_names;
       }
       if (editingVersion) {
-        await request(`/api/draft/version-names/${editingVersion.id}`, {
+        await request(`/api/draft/version-names/${editingVersion.id}`, withRevision(editingVersion.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         messageApi.success("版本已更新");
       } else {
         await request("/api/draft/version-names", {
@@ -122,7 +154,7 @@ const VersionManager = () => {
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`/api/draft/version-names/${version.id}`, { method: "DELETE" });
+          await request(`/api/draft/version-names/${version.id}`, withRevision(version.row_version, { method: "DELETE" }));
           messageApi.success("已删除");
           void loadVersions();
         } catch (error) {
@@ -153,6 +185,7 @@ const VersionManager = () => {
         },
         body: JSON.stringify({
           draft_version_id: version.id,
+          sync_target_id: syncTargetId,
           trigger_by: user.id,
           confirm,
           modules: ["version_names"]
@@ -171,7 +204,22 @@ const VersionManager = () => {
         return;
       }
       if (!response.ok) {
-        throw new Error((data as { error?: string }).error || "同步失败");
+        throw new Error(describeRequestError(response.status, data, "同步失败"));
+      }
+      const job = await waitForSyncJob(token, (data as { job_id: number }).job_id);
+      if (job.status === "pending_confirm") {
+        setSyncingId(null);
+        Modal.confirm({
+          title: "检测到线上已有版本",
+          content: "继续同步将覆盖线上同名景区的版本配置。",
+          okText: "继续同步",
+          cancelText: "取消",
+          onOk: () => handleSync(version, true)
+        });
+        return;
+      }
+      if (job.status !== "succeeded") {
+        throw new Error(job.error_message || "同步失败");
       }
       messageApi.success("版本配置已同步");
     } catch (error) {
@@ -180,13 +228,70 @@ const VersionManager = () => {
       }
     } finally {
       setSyncingId(null);
+      void loadVersions();
+    }
+  };
+
+  const handleTransition = async (version: DraftVersion, transition: DraftTransition) => {
+    setTransitioningId(version.id);
+    try {
+      await request(`/api/draft/version-names/${version.id}/transition`, {
+        method: "POST",
+        body: JSON.stringify({ to: transition.to })
+      });
+      messageApi.success(`${draftTransitionLabels[transition.action] || transition.to}成功`);
+      void loadVersions();
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "状态变更失败");
+    } finally {
+      setTransitioningId(null);
+    }
+  };
+
+  const loadApprovals = async (versionId: number) => {
+    try {
+      const res = await request<ApprovalSummary>(`/api/draft/version-names/${versionId}/approvals`);
+      setApprovalSummary(res);
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "获取审批记录失败");
+    }
+  };
+
+  const openApprovals = (version: DraftVersion) => {
+    setApprovalVersion(version);
+    setApprovalSummary(null);
+    setApprovalReason("");
+    void loadApprovals(version.id);
+  };
+
+  const handleDecision = async (decision: "approve" | "reject") => {
+    if (!approvalVersion) {
+      return;
+    }
+    if (decision === "reject" && !approvalReason.trim()) {
+      messageApi.warning("驳回时请填写原因");
+      return;
+    }
+    setApprovalSubmitting(true);
+    try {
+      await request(`/api/draft/version-names/${approvalVersion.id}/approvals`, {
+        method: "POST",
+        body: JSON.stringify({ decision, reason: approvalReason.trim() })
+      });
+      messageApi.success(decision === "approve" ? "已通过" : "已驳回");
+      setApprovalReason("");
+      void loadApprovals(approvalVersion.id);
+    } catch (error) {
+      messageApi.error(error instanceof Error ? error.message : "审批失败");
+    } finally {
+      setApprovalSubmitting(false);
     }
   };
 
   const loadOnlineVersions = async () => {
     setOnlineLoading(true);
     try {
-      const res = await request<{ data: OnlineVersion[] }>("/api/sync/online/versions");
+      const res = await request<{ data: OnlineVersion[] }>(`/api/sync/online/versions?sync_target_id=${syncTargetId}`);
       setOnlineVersions(res.data || []);
     } catch (error) {
       messageApi.error(error instanceof Error ? error.message : "获取线上版本失败");
@@ -206,6 +311,7 @@ const VersionManager = () => {
     setImporting(true);
     try {
       const payload: Record<string, unknown> = {
+        sync_target_id: syncTargetId,
         target_app_version_name_id: selectedOnlineId
       };
       if (draftId) {
@@ -273,7 +379,12 @@ const VersionManager = () => {
       title: "版本名",
       dataIndex: "app_version_name",
       key: "app_version_name",
-      render: (value: string) => <Tag color="orange">{value || "自动生成"}</Tag>
+      render: (value: string, record: DraftVersion) => (
+        <Space size={4}>
+          <Tag color="orange">{value || "自动生成"}</Tag>
+          {record.drifted ? <Tag color="red">线上已变更</Tag> : null}
+        </Space>
+      )
     },
     {
       title: "模型",
@@ -287,6 +398,15 @@ const VersionManager = () => {
       key: "feishu_field_list",
       render: (_: string, record: DraftVersion) => renderFields(record.feishu_field_list || [])
     },
+    {
+      title: "草稿状态",
+      dataIndex: "draft_status",
+      key: "draft_status",
+      render: (value: string) => {
+        const meta = draftStatusLabels[value] || { label: value || "草稿", color: "default" };
+        return <Tag color={meta.color}>{meta.label}</Tag>;
+      }
+    },
     {
       title: "提交版本",
       dataIndex: "submit_version",
@@ -307,10 +427,26 @@ const VersionManager = () => {
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
           </Button>
+          {(record.draft_transitions || []).map((transition) => (
+            <Button
+              key={transition.to}
+              size="small"
+              loading={transitioningId === record.id}
+              onClick={() => handleTransition(record, transition)}
+            >
+              {draftTransitionLabels[transition.action] || transition.to}
+            </Button>
+          ))}
+          {record.draft_status === "in_review" ? (
+            <Button size="small" onClick={() => openApprovals(record)}>
+              审批
+            </Button>
+          ) : null}
           <Button
             size="small"
             type="primary"
             loading={syncingId === record.id}
+            disabled={record.draft_status !== "approved"}
             onClick={() => handleSync(record, false)}
           >
             同步
@@ -342,6 +478,16 @@ const VersionManager = () => {
               onChange={(event) => setSearchText(event.target.value)}
               style={{ width: 240 }}
             />
+            <Select
+              style={{ width: 200 }}
+              placeholder="同步目标"
+              value={syncTargets.length ? syncTargetId : undefined}
+              onChange={(value) => setSyncTargetId(value)}
+              options={syncTargets.map((item) => ({
+                value: item.id,
+                label: item.name
+              }))}
+            />
             <Button icon={<ReloadOutlined />} onClick={loadVersions}>
               刷新
             </Button>
@@ -379,6 +525,54 @@ const VersionManager = () => {
         onSubmit={handleSubmit}
       />
 
+      <Modal
+        title={`审批 ${approvalVersion?.app_version_name || ""}`}
+        open={approvalVersion !== null}
+        onCancel={() => setApprovalVersion(null)}
+        footer={[
+          <Button key="reject" danger loading={approvalSubmitting} onClick={() => handleDecision("reject")}>
+            驳回
+          </Button>,
+          <Button key="approve" type="primary" loading={approvalSubmitting} onClick={() => handleDecision("approve")}>
+            通过
+          </Button>
+        ]}
+      >
+        <Space direction="vertical" style={{ width: "100%" }} size={12}>
+          {approvalSummary ? (
+            <>
+              <div>
+                <Text strong>第 {approvalSummary.review_round} 轮审核</Text>{" "}
+                {approvalSummary.evaluation.satisfied ? <Tag color="green">已满足</Tag> : <Tag color="orange">未满足</Tag>}
+              </div>
+              {approvalSummary.evaluation.missing.map((gap) => (
+                <Text key={`${gap.module_key}-${gap.role}`} type="warning">
+                  {gap.module_key || "整个版本"}：还需 {approvalRoleLabels[gap.role] || "任意角色"} {gap.missing} 人审批
+                  （已 {gap.approved}/{gap.required}）
+                </Text>
+              ))}
+              {approvalSummary.approvals.map((record) => (
+                <Text key={record.id}>
+                  <Tag color={record.decision === "approved" ? "green" : "red"}>
+                    {record.decision === "approved" ? "通过" : "驳回"}
+                  </Tag>
+                  {record.approver_name || record.approver_id}（{approvalRoleLabels[record.approver_role] || record.approver_role}）
+                  {record.reason ? `：${record.reason}` : ""}
+                </Text>
+              ))}
+            </>
+          ) : (
+            <Text type="secondary">加载中...</Text>
+          )}
+          <Input.TextArea
+            rows={3}
+            placeholder="审批意见（驳回时必填）"
+            value={approvalReason}
+            onChange={(event) => setApprovalReason(event.target.value)}
+          />
+        </Space>
+      </Modal>
+
       <Modal
         title="从线上导入版本"
         open={importOpen}
@@ -45,6 +45,7 @@ const AppUIFieldsPanel = ({ version, request, uploadFile, generateTTS, notify, o
   const [printPreview, setPrintPreview] = useState<string | null>(null);
   const [hasRecord, setHasRecord] = useState(false);
   const [recordId, setRecordId] = useState<number | null>(null);
+  const [rowVersion, setRowVersion] = useState<number | null>(null);
   const [submitStatus, setSubmitStatus] = useState<string | null>(null);
   const [lastSubmitAt, setLastSubmitAt] = useState<string | null>(null);
   const step1Value = Form.useWatch("step1_music", form);
@@ -58,6 +59,7 @@ const AppUIFieldsPanel = ({ version, request, uploadFile, generateTTS, notify, o
     if (!version?.id) {
       setHasRecord(false);
       setRecordId(null);
+      setRowVersion(null);
       setSubmitStatus(null);
       setLastSubmitAt(null);
       form.resetFields();
@@ -71,6 +73,7 @@ const AppUIFieldsPanel = ({ version, request, uploadFile, generateTTS, notify, o
       const data = res.data ?? null;
       setHasRecord(Boolean(data?.id));
       setRecordId(data?.id ?? null);
+      setRowVersion(data?.row_version ?? null);
       setSubmitStatus(data?.submit_status ?? null);
       setLastSubmitAt(data?.last_submit_at ?? null);
       form.setFieldsValue({
@@ -128,6 +131,9 @@ const AppUIFieldsPanel = ({ version, request, uploadFile, generateTTS, notify, o
       if (!hasRecord && operatorId) {
         payload.created_by = operatorId;
       }
+      if (hasRecord && rowVersion) {
+        payload.row_version = rowVersion;
+      }
       await request("/api/draft/app-ui-fields", {
         method: "POST",
         body: JSON.stringify(payload)
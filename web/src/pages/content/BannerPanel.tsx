@@ -23,7 +23,7 @@ import UploadField from "./UploadField";
 import SubmissionActions from "./SubmissionActions";
 import { BannerItem, DraftVersion, bannerTypeOptions, formatDate, statusOptions, submitStatusLabels } from "./constants";
 import type { Notify, RequestFn, UploadFn } from "./utils";
-import { buildLocalDraftKey, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload } from "./utils";
+import { buildLocalDraftKey, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload, withRevision } from "./utils";
 
 const { Text } = Typography;
 
@@ -1044,10 +1044,10 @@ const BannerPanel = ({ version, request, uploadFile, notify, operatorId }: Banne
         payload.created_by = operatorId;
       }
       if (editingItem) {
-        await request(`/api/draft/banners/${editingItem.id}`, {
+        await request(`/api/draft/banners/${editingItem.id}`, withRevision(editingItem.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         notify.success("轮播图已更新");
       } else {
         await request("/api/draft/banners", {
@@ -1079,7 +1079,7 @@ const BannerPanel = ({ version, request, uploadFile, notify, operatorId }: Banne
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`/api/draft/banners/${item.id}`, { method: "DELETE" });
+          await request(`/api/draft/banners/${item.id}`, withRevision(item.row_version, { method: "DELETE" }));
           notify.success("已删除");
           void loadItems();
         } catch (error) {
@@ -1196,13 +1196,13 @@ const BannerPanel = ({ version, request, uploadFile, notify, operatorId }: Banne
       const total = sortDraft.length;
       const results = await Promise.allSettled(
         sortDraft.map((item, index) =>
-          request(`/api/draft/banners/${item.id}`, {
+          request(`/api/draft/banners/${item.id}`, withRevision(item.row_version, {
             method: "PUT",
             body: JSON.stringify({
               sort: total - index,
               updated_by: operatorId ?? undefined
             })
-          })
+          }))
         )
       );
       const failed = results.filter((item) => item.status === "rejected").length;
@@ -1221,13 +1221,13 @@ const BannerPanel = ({ version, request, uploadFile, notify, operatorId }: Banne
   const handleInlineStatusChange = async (record: BannerItem, checked: boolean) => {
     setStatusUpdating((prev) => ({ ...prev, [record.id]: true }));
     try {
-      await request(`/api/draft/banners/${record.id}`, {
+      await request(`/api/draft/banners/${record.id}`, withRevision(record.row_version, {
         method: "PUT",
         body: JSON.stringify({
           is_active: checked ? 1 : 0,
           updated_by: operatorId ?? undefined
         })
-      });
+      }));
       notify.success("状态已更新");
       void loadItems();
     } catch (error) {
@@ -1314,6 +1314,7 @@ const BannerPanel = ({ version, request, uploadFile, notify, operatorId }: Banne
             notify={notify}
             getPayload={() => record as Record<string, unknown>}
             disabled={!version?.id}
+            onRestored={() => void loadItems()}
           />
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
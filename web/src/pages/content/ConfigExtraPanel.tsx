@@ -23,7 +23,7 @@ import SubmissionActions from "./SubmissionActions";
 import TTSInlinePanel from "./TTSInlinePanel";
 import { DraftVersion, ExtraStepItem, formatDate, statusOptions, submitStatusLabels } from "./constants";
 import type { Notify, RequestFn, TTSPreset, TTSFn, TTSResult, UploadFn } from "./utils";
-import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload } from "./utils";
+import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload, withRevision } from "./utils";
 
 const { Text } = Typography;
 
@@ -145,10 +145,10 @@ const ConfigExtraPanel = ({ version, request, uploadFile, generateTTS, notify, o
         payload.created_by = operatorId;
       }
       if (editingItem) {
-        await request(`/api/draft/config-extra-steps/${editingItem.id}`, {
+        await request(`/api/draft/config-extra-steps/${editingItem.id}`, withRevision(editingItem.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         notify.success("额外配置已更新");
       } else {
         await request("/api/draft/config-extra-steps", {
@@ -180,7 +180,7 @@ const ConfigExtraPanel = ({ version, request, uploadFile, generateTTS, notify, o
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`/api/draft/config-extra-steps/${item.id}`, { method: "DELETE" });
+          await request(`/api/draft/config-extra-steps/${item.id}`, withRevision(item.row_version, { method: "DELETE" }));
           notify.success("已删除");
           void loadItems();
         } catch (error) {
@@ -274,13 +274,13 @@ const ConfigExtraPanel = ({ version, request, uploadFile, generateTTS, notify, o
       const total = sortDraft.length;
       const results = await Promise.allSettled(
         sortDraft.map((item, index) =>
-          request(`/api/draft/config-extra-steps/${item.id}`, {
+          request(`/api/draft/config-extra-steps/${item.id}`, withRevision(item.row_version, {
             method: "PUT",
             body: JSON.stringify({
               step_index: total - index,
               updated_by: operatorId ?? undefined
             })
-          })
+          }))
         )
       );
       const failed = results.filter((item) => item.status === "rejected").length;
@@ -299,13 +299,13 @@ const ConfigExtraPanel = ({ version, request, uploadFile, generateTTS, notify, o
   const handleInlineStatusChange = async (record: ExtraStepItem, checked: boolean) => {
     setStatusUpdating((prev) => ({ ...prev, [record.id]: true }));
     try {
-      await request(`/api/draft/config-extra-steps/${record.id}`, {
+      await request(`/api/draft/config-extra-steps/${record.id}`, withRevision(record.row_version, {
         method: "PUT",
         body: JSON.stringify({
           status: checked ? 1 : 0,
           updated_by: operatorId ?? undefined
         })
-      });
+      }));
       notify.success("状态已更新");
       void loadItems();
     } catch (error) {
@@ -458,6 +458,7 @@ const ConfigExtraPanel = ({ version, request, uploadFile, generateTTS, notify, o
             notify={notify}
             getPayload={() => record as Record<string, unknown>}
             disabled={!version?.id}
+            onRestored={() => void loadItems()}
           />
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
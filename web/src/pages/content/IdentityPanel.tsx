@@ -23,7 +23,7 @@ import UploadField from "./UploadField";
 import SubmissionActions from "./SubmissionActions";
 import { DraftVersion, IdentityItem, identityNameOptions, formatDate, statusOptions, submitStatusLabels } from "./constants";
 import type { Notify, RequestFn, UploadFn } from "./utils";
-import { buildLocalDraftKey, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload } from "./utils";
+import { buildLocalDraftKey, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload, withRevision } from "./utils";
 
 const { Text } = Typography;
 
@@ -147,10 +147,10 @@ const IdentityPanel = ({ version, request, uploadFile, notify, operatorId }: Ide
         payload.created_by = operatorId;
       }
       if (editingItem) {
-        await request(`/api/draft/identities/${editingItem.id}`, {
+        await request(`/api/draft/identities/${editingItem.id}`, withRevision(editingItem.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         notify.success("身份信息已更新");
       } else {
         await request("/api/draft/identities", {
@@ -182,7 +182,7 @@ const IdentityPanel = ({ version, request, uploadFile, notify, operatorId }: Ide
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`/api/draft/identities/${item.id}`, { method: "DELETE" });
+          await request(`/api/draft/identities/${item.id}`, withRevision(item.row_version, { method: "DELETE" }));
           notify.success("已删除");
           void loadItems();
         } catch (error) {
@@ -349,13 +349,13 @@ const IdentityPanel = ({ version, request, uploadFile, notify, operatorId }: Ide
       const total = sortDraft.length;
       const results = await Promise.allSettled(
         sortDraft.map((item, index) =>
-          request(`/api/draft/identities/${item.id}`, {
+          request(`/api/draft/identities/${item.id}`, withRevision(item.row_version, {
             method: "PUT",
             body: JSON.stringify({
               sort: total - index,
               updated_by: operatorId ?? undefined
             })
-          })
+          }))
         )
       );
       const failed = results.filter((item) => item.status === "rejected").length;
@@ -374,13 +374,13 @@ const IdentityPanel = ({ version, request, uploadFile, notify, operatorId }: Ide
   const handleInlineStatusChange = async (record: IdentityItem, checked: boolean) => {
     setStatusUpdating((prev) => ({ ...prev, [record.id]: true }));
     try {
-      await request(`/api/draft/identities/${record.id}`, {
+      await request(`/api/draft/identities/${record.id}`, withRevision(record.row_version, {
         method: "PUT",
         body: JSON.stringify({
           status: checked ? 1 : 0,
           updated_by: operatorId ?? undefined
         })
-      });
+      }));
       notify.success("状态已更新");
       void loadItems();
     } catch (error) {
@@ -491,6 +491,7 @@ const IdentityPanel = ({ version, request, uploadFile, notify, operatorId }: Ide
             notify={notify}
             getPayload={() => record as Record<string, unknown>}
             disabled={!version?.id}
+            onRestored={() => void loadItems()}
           />
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
@@ -24,7 +24,7 @@ import TTSInlinePanel from "./TTSInlinePanel";
 import SubmissionActions from "./SubmissionActions";
 import { DraftVersion, formatDate, statusOptions, submitStatusLabels } from "./constants";
 import type { Notify, RequestFn, TTSPreset, TTSFn, TTSResult, UploadFn } from "./utils";
-import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload } from "./utils";
+import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload, withRevision } from "./utils";
 
 const { Text } = Typography;
 
@@ -41,6 +41,7 @@ type PreferenceItem = {
   desc?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 type PreferencePanelProps = {
@@ -185,10 +186,10 @@ const PreferencePanel = ({
         payload.created_by = operatorId;
       }
       if (editingItem) {
-        await request(`${updateEndpoint}/${editingItem.id}`, {
+        await request(`${updateEndpoint}/${editingItem.id}`, withRevision(editingItem.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         notify.success(`${title}已更新`);
       } else {
         await request(createEndpoint, {
@@ -221,7 +222,7 @@ const PreferencePanel = ({
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`${deleteEndpoint}/${item.id}`, { method: "DELETE" });
+          await request(`${deleteEndpoint}/${item.id}`, withRevision(item.row_version, { method: "DELETE" }));
           notify.success("已删除");
           void loadItems();
         } catch (error) {
@@ -292,13 +293,13 @@ const PreferencePanel = ({
       const total = sortDraft.length;
       const results = await Promise.allSettled(
         sortDraft.map((item, index) =>
-          request(`${updateEndpoint}/${item.id}`, {
+          request(`${updateEndpoint}/${item.id}`, withRevision(item.row_version, {
             method: "PUT",
             body: JSON.stringify({
               sort: total - index,
               updated_by: operatorId ?? undefined
             })
-          })
+          }))
         )
       );
       const failed = results.filter((item) => item.status === "rejected").length;
@@ -317,13 +318,13 @@ const PreferencePanel = ({
   const handleInlineStatusChange = async (record: PreferenceItem, checked: boolean) => {
     setStatusUpdating((prev) => ({ ...prev, [record.id]: true }));
     try {
-      await request(`${updateEndpoint}/${record.id}`, {
+      await request(`${updateEndpoint}/${record.id}`, withRevision(record.row_version, {
         method: "PUT",
         body: JSON.stringify({
           status: checked ? 1 : 0,
           updated_by: operatorId ?? undefined
         })
-      });
+      }));
       notify.success("状态已更新");
       void loadItems();
     } catch (error) {
@@ -501,6 +502,7 @@ const PreferencePanel = ({
             notify={notify}
             getPayload={() => record as Record<string, unknown>}
             disabled={!version?.id}
+            onRestored={() => void loadItems()}
           />
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
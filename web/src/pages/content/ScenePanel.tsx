@@ -24,7 +24,7 @@ import SubmissionActions from "./SubmissionActions";
 import TTSInlinePanel from "./TTSInlinePanel";
 import { DraftVersion, SceneItem, formatDate, statusOptions, submitStatusLabels } from "./constants";
 import type { Notify, RequestFn, TTSPreset, TTSFn, TTSResult, UploadFn } from "./utils";
-import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload } from "./utils";
+import { buildLocalDraftKey, generateTTSBatch, loadLocalDraft, saveLocalDraft, sanitizeSubmissionPayload, withRevision } from "./utils";
 
 const { Text } = Typography;
 const { TextArea } = Input;
@@ -143,10 +143,10 @@ const ScenePanel = ({ version, request, uploadFile, generateTTS, notify, operato
         payload.created_by = operatorId;
       }
       if (editingItem) {
-        await request(`/api/draft/scenes/${editingItem.id}`, {
+        await request(`/api/draft/scenes/${editingItem.id}`, withRevision(editingItem.row_version, {
           method: "PUT",
           body: JSON.stringify(payload)
-        });
+        }));
         notify.success("场景已更新");
       } else {
         await request("/api/draft/scenes", {
@@ -179,7 +179,7 @@ const ScenePanel = ({ version, request, uploadFile, generateTTS, notify, operato
       okButtonProps: { danger: true },
       onOk: async () => {
         try {
-          await request(`/api/draft/scenes/${item.id}`, { method: "DELETE" });
+          await request(`/api/draft/scenes/${item.id}`, withRevision(item.row_version, { method: "DELETE" }));
           notify.success("已删除");
           void loadItems();
         } catch (error) {
@@ -250,13 +250,13 @@ const ScenePanel = ({ version, request, uploadFile, generateTTS, notify, operato
       const total = sortDraft.length;
       const results = await Promise.allSettled(
         sortDraft.map((item, index) =>
-          request(`/api/draft/scenes/${item.id}`, {
+          request(`/api/draft/scenes/${item.id}`, withRevision(item.row_version, {
             method: "PUT",
             body: JSON.stringify({
               sort: total - index,
               updated_by: operatorId ?? undefined
             })
-          })
+          }))
         )
       );
       const failed = results.filter((item) => item.status === "rejected").length;
@@ -275,13 +275,13 @@ const ScenePanel = ({ version, request, uploadFile, generateTTS, notify, operato
   const handleInlineStatusChange = async (record: SceneItem, checked: boolean) => {
     setStatusUpdating((prev) => ({ ...prev, [record.id]: true }));
     try {
-      await request(`/api/draft/scenes/${record.id}`, {
+      await request(`/api/draft/scenes/${record.id}`, withRevision(record.row_version, {
         method: "PUT",
         body: JSON.stringify({
           status: checked ? 1 : 0,
           updated_by: operatorId ?? undefined
         })
-      });
+      }));
       notify.success("状态已更新");
       void loadItems();
     } catch (error) {
@@ -459,6 +459,7 @@ const ScenePanel = ({ version, request, uploadFile, generateTTS, notify, operato
             notify={notify}
             getPayload={() => record as Record<string, unknown>}
             disabled={!version?.id}
+            onRestored={() => void loadItems()}
           />
           <Button size="small" icon={<EditOutlined />} onClick={() => openEditor(record)}>
             编辑
@@ -1,5 +1,5 @@
 import { useState } from "react";
-import { Button, Drawer, Empty, Modal, Space, Table, Tag, Typography } from "antd";
+import { Button, Checkbox, Drawer, Empty, Input, Modal, Select, Space, Table, Tag, Typography } from "antd";
 import type { Notify, RequestFn } from "./utils";
 import { sanitizeSubmissionPayload } from "./utils";
 import { formatDate } from "./constants";
@@ -25,6 +25,13 @@ type SubmissionItem = {
   confirmed_name?: string | null;
   confirmed_username?: string | null;
   confirmed_at?: string | null;
+  prev_submission_id?: number | null;
+  restored_from_id?: number | null;
+  rejected_name?: string | null;
+  rejected_username?: string | null;
+  rejected_at?: string | null;
+  reject_reason?: string | null;
+  reverted?: boolean;
   diff?: DiffItem[];
 };
 
@@ -38,6 +45,7 @@ type SubmissionActionsProps = {
   notify: Notify;
   getPayload: () => Record<string, unknown>;
   disabled?: boolean;
+  onRestored?: () => void;
 };
 
 const SubmissionActions = ({
@@ -49,7 +57,8 @@ const SubmissionActions = ({
   request,
   notify,
   getPayload,
-  disabled
+  disabled,
+  onRestored
 }: SubmissionActionsProps) => {
   const [submitting, setSubmitting] = useState(false);
   const [diffOpen, setDiffOpen] = useState(false);
@@ -59,6 +68,11 @@ const SubmissionActions = ({
   const [historyOpen, setHistoryOpen] = useState(false);
   const [historyLoading, setHistoryLoading] = useState(false);
   const [historyItems, setHistoryItems] = useState<SubmissionItem[]>([]);
+  const [historyStatus, setHistoryStatus] = useState("");
+  const [rejecting, setRejecting] = useState<SubmissionItem | null>(null);
+  const [rejectReason, setRejectReason] = useState("");
+  const [rejectRevert, setRejectRevert] = useState(false);
+  const [rejectSubmitting, setRejectSubmitting] = useState(false);
 
   const handleSubmit = async () => {
     if (!operatorId) {
@@ -117,7 +131,79 @@ const SubmissionActions = ({
     }
   };
 
-  const loadHistory = async () => {
+  const handleRestore = (record: SubmissionItem) => {
+    if (!operatorId) {
+      notify.warning("缺少提交人信息");
+      return;
+    }
+    Modal.confirm({
+      title: `恢复到第 ${record.submit_version} 版？`,
+      content: "将用该版本的内容覆盖当前数据，并生成一条新的提交记录。",
+      okText: "恢复",
+      cancelText: "取消",
+      onOk: async () => {
+        try {
+          const res = await request<{ unchanged?: boolean; submit_version?: number }>(
+            `/api/draft/submissions/${record.id}/restore`,
+            {
+              method: "POST",
+              body: JSON.stringify({ submit_by: operatorId })
+            }
+          );
+          notify.success(res.unchanged ? "当前内容与该版本一致" : `已恢复，生成第 ${res.submit_version} 版`);
+          void loadHistory();
+          onRestored?.();
+        } catch (error) {
+          if (error instanceof Error) {
+            notify.error(error.message);
+          }
+        }
+      }
+    });
+  };
+
+  const openReject = (record: SubmissionItem) => {
+    setRejecting(record);
+    setRejectReason("");
+    setRejectRevert(false);
+  };
+
+  const handleReject = async () => {
+    if (!rejecting) {
+      return;
+    }
+    if (!operatorId) {
+      notify.warning("缺少审核人信息");
+      return;
+    }
+    if (!rejectReason.trim()) {
+      notify.warning("请填写驳回原因");
+      return;
+    }
+    setRejectSubmitting(true);
+    try {
+      await request("/api/draft/reject", {
+        method: "POST",
+        body: JSON.stringify({
+          submission_id: rejecting.id,
+          rejected_by: operatorId,
+          reason: rejectReason.trim(),
+          revert: rejectRevert
+        })
+      });
+      notify.success(rejectRevert ? "已驳回并恢复到上一版本" : "已驳回");
+      setRejecting(null);
+      void loadHistory();
+    } catch (error) {
+      if (error instanceof Error) {
+        notify.error(error.message);
+      }
+    } finally {
+      setRejectSubmitting(false);
+    }
+  };
+
+  const loadHistory = async (status = historyStatus) => {
     setHistoryLoading(true);
     try {
       const params = new URLSearchParams({
@@ -126,6 +212,9 @@ const SubmissionActions = ({
         entity_table: entityTable,
         entity_id: String(entityId)
       });
+      if (status) {
+        params.set("status", status);
+      }
       const res = await request<{ data: SubmissionItem[] }>(`/api/draft/submissions?${params.toString()}`);
       setHistoryItems(res.data || []);
     } catch (error) {
@@ -159,7 +248,16 @@ const SubmissionActions = ({
   ];
 
   const historyColumns = [
-    { title: "版本", dataIndex: "submit_version", key: "submit_version" },
+    {
+      title: "版本",
+      key: "submit_version",
+      render: (_: string, record: SubmissionItem) => (
+        <Text>
+          {record.submit_version}
+          {record.restored_from_id ? <Text type="secondary">（恢复）</Text> : null}
+        </Text>
+      )
+    },
     {
       title: "提交人",
       key: "submit_by",
@@ -178,9 +276,27 @@ const SubmissionActions = ({
         if (value === "pending_confirm") {
           return <Tag color="gold">待确认</Tag>;
         }
+        if (value === "rejected") {
+          return <Tag color="red">已驳回</Tag>;
+        }
         return <Tag>已提交</Tag>;
       }
     },
+    {
+      title: "驳回原因",
+      key: "reject_reason",
+      render: (_: string, record: SubmissionItem) =>
+        record.status === "rejected" ? (
+          <Text type="secondary">
+            {record.reject_reason}
+            {record.reverted ? "（已恢复）" : ""}
+            {" - "}
+            {record.rejected_name || record.rejected_username || "-"}
+          </Text>
+        ) : (
+          "-"
+        )
+    },
     {
       title: "提交时间",
       dataIndex: "created_at",
@@ -208,6 +324,16 @@ const SubmissionActions = ({
               确认
             </Button>
           ) : null}
+          {record.status === "pending_confirm" || record.status === "submitted" ? (
+            <Button size="small" danger onClick={() => openReject(record)}>
+              驳回
+            </Button>
+          ) : null}
+          {record.status !== "rejected" ? (
+            <Button size="small" onClick={() => handleRestore(record)}>
+              恢复此版本
+            </Button>
+          ) : null}
         </Space>
       )
     }
@@ -249,7 +375,24 @@ const SubmissionActions = ({
         title="提交历史"
         open={historyOpen}
         onClose={() => setHistoryOpen(false)}
-        width={720}
+        width={820}
+        extra={
+          <Select
+            style={{ width: 140 }}
+            value={historyStatus}
+            onChange={(value) => {
+              setHistoryStatus(value);
+              void loadHistory(value);
+            }}
+            options={[
+              { value: "", label: "全部状态" },
+              { value: "submitted", label: "已提交" },
+              { value: "pending_confirm", label: "待确认" },
+              { value: "confirmed", label: "已确认" },
+              { value: "rejected", label: "已驳回" }
+            ]}
+          />
+        }
       >
         {historyItems.length ? (
           <Table
@@ -263,6 +406,33 @@ const SubmissionActions = ({
           <Empty description="暂无提交记录" />
         )}
       </Drawer>
+
+      <Modal
+        title="驳回提交"
+        open={rejecting !== null}
+        onCancel={() => setRejecting(null)}
+        onOk={handleReject}
+        confirmLoading={rejectSubmitting}
+        okText="驳回"
+        okButtonProps={{ danger: true }}
+        cancelText="取消"
+      >
+        <Space direction="vertical" style={{ width: "100%" }} size={12}>
+          <Input.TextArea
+            rows={3}
+            placeholder="请填写驳回原因（必填）"
+            value={rejectReason}
+            onChange={(event) => setRejectReason(event.target.value)}
+          />
+          <Checkbox
+            checked={rejectRevert}
+            disabled={!rejecting?.prev_submission_id}
+            onChange={(event) => setRejectRevert(event.target.checked)}
+          >
+            恢复到上一次提交的内容
+          </Checkbox>
+        </Space>
+      </Modal>
     </Space>
   );
 };
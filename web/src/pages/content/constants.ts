@@ -16,6 +16,7 @@ export type BannerItem = {
   app_version_name?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type IdentityItem = {
@@ -28,6 +29,7 @@ export type IdentityItem = {
   app_version_name?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type SceneItem = {
@@ -43,6 +45,7 @@ export type SceneItem = {
   app_version_name?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type ClothesItem = {
@@ -59,6 +62,7 @@ export type ClothesItem = {
   app_version_name?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type PhotoHobbyItem = {
@@ -75,6 +79,7 @@ export type PhotoHobbyItem = {
   app_version_name?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type ExtraStepItem = {
@@ -89,6 +94,7 @@ export type ExtraStepItem = {
   status?: number | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export type AppUIFields = {
@@ -111,6 +117,7 @@ export type AppUIFields = {
   print_wait_url?: string | null;
   submit_status?: string | null;
   last_submit_at?: string | null;
+  row_version?: number;
 };
 
 export const bannerTypeOptions = [
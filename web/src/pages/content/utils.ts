@@ -115,10 +115,72 @@ export const sanitizeSubmissionPayload = (payload: Record<string, unknown>) => {
     if (key.endsWith("_url")) {
       return;
     }
-    if (key === "created_at" || key === "updated_at" || key === "deleted_at") {
+    if (key === "created_at" || key === "updated_at" || key === "deleted_at" || key === "row_version") {
       return;
     }
     cleaned[key] = value;
   });
   return cleaned;
 };
+
+export const withRevision = (rowVersion: number | null | undefined, options: RequestInit = {}): RequestInit => {
+  const headers = new Headers(options.headers);
+  if (rowVersion) {
+    headers.set("If-Match", `"${rowVersion}"`);
+  }
+  return { ...options, headers };
+};
+
+export const describeRequestError = (status: number, data: unknown, fallback: string) => {
+  const error = (data as { error?: string }).error;
+  if (status === 409 && error === "revision_conflict") {
+    return "数据已被他人修改，请刷新后重试";
+  }
+  if (status === 409 && error === "draft_locked") {
+    return "当前草稿状态不允许修改";
+  }
+  if (status === 409 && error === "draft_not_approved") {
+    return "草稿尚未审批，无法同步";
+  }
+  if (status === 409 && error === "pending_confirm") {
+    return "仍有待确认的提交，无法审批";
+  }
+  if (status === 409 && error === "approval_required") {
+    const missing = (data as { missing?: { missing: number }[] }).missing || [];
+    const rejected = (data as { rejected?: unknown[] }).rejected || [];
+    const count = missing.reduce((total, item) => total + item.missing, 0);
+    return rejected.length > 0 ? `审批被驳回 ${rejected.length} 次，无法继续` : `还缺少 ${count} 个审批`;
+  }
+  if (status === 409 && error === "not_in_review") {
+    return "草稿不在审核中，无法审批";
+  }
+  return error || fallback;
+};
+
+export type SyncJobResult = {
+  id: number;
+  status?: string | null;
+  error_message?: string | null;
+  attempts?: number | null;
+  max_attempts?: number | null;
+  target_app_version_name_id?: number | null;
+};
+
+const syncJobTerminalStatuses = new Set(["succeeded", "failed", "pending_confirm"]);
+
+export const waitForSyncJob = async (token: string, jobId: number, intervalMs = 2000) => {
+  for (;;) {
+    const response = await fetch(`/api/sync/jobs/${jobId}`, {
+      headers: { Authorization: `Bearer ${token}` }
+    });
+    const data = await response.json().catch(() => ({}));
+    if (!response.ok) {
+      throw new Error((data as { error?: string }).error || "获取同步任务失败");
+    }
+    const job = (data as { data?: SyncJobResult }).data;
+    if (job && syncJobTerminalStatuses.has(job.status || "")) {
+      return job;
+    }
+    await new Promise((resolve) => setTimeout(resolve, intervalMs));
+  }
+};
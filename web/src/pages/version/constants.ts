@@ -7,12 +7,78 @@ export type DraftVersion = {
   ai_modal?: string | null;
   status?: number | null;
   draft_status?: string | null;
+  draft_status_changed_at?: string | null;
+  draft_transitions?: DraftTransition[];
   submit_version?: number | null;
   last_submit_by?: number | null;
   last_submit_at?: string | null;
   confirmed_by?: number | null;
   confirmed_at?: string | null;
   target_app_version_name_id?: number | null;
+  drifted?: boolean;
+  drifted_modules?: number;
+  cloned_from_id?: number | null;
+  row_version?: number;
+};
+
+export type DraftTransition = {
+  to: string;
+  action: string;
+};
+
+export const draftStatusLabels: Record<string, { label: string; color: string }> = {
+  draft: { label: "草稿", color: "default" },
+  in_review: { label: "审核中", color: "processing" },
+  approved: { label: "已审批", color: "green" },
+  syncing: { label: "同步中", color: "blue" },
+  published: { label: "已发布", color: "purple" },
+  archived: { label: "已归档", color: "default" }
+};
+
+export const draftTransitionLabels: Record<string, string> = {
+  submit_review: "提交审核",
+  withdraw: "撤回",
+  approve: "审批通过",
+  reopen: "重新编辑",
+  abort_sync: "终止同步",
+  archive: "归档",
+  unarchive: "取消归档"
+};
+
+export type ApprovalGap = {
+  module_key: string;
+  role: string;
+  required: number;
+  approved: number;
+  missing: number;
+};
+
+export type ApprovalRecord = {
+  id: number;
+  module_key: string;
+  approver_id: number;
+  approver_name: string;
+  approver_role: string;
+  decision: string;
+  reason: string;
+  updated_at: string;
+};
+
+export type ApprovalSummary = {
+  review_round: number;
+  approvals: ApprovalRecord[];
+  evaluation: {
+    satisfied: boolean;
+    missing: ApprovalGap[];
+    rejected: ApprovalRecord[];
+  };
+};
+
+export const approvalRoleLabels: Record<string, string> = {
+  admin: "管理员",
+  user: "普通用户",
+  ops_lead: "运营负责人",
+  content_lead: "内容负责人"
 };
 
 export type OnlineVersion = {
@@ -25,6 +91,14 @@ export type OnlineVersion = {
   updated_at?: string | null;
 };
 
+export type SyncTarget = {
+  id: number;
+  name: string;
+  url?: string | null;
+  is_enabled?: number | null;
+  source?: string | null;
+};
+
 export const baseFeishuFields = [
   "编号顺序",
   "场景",